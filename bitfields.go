@@ -0,0 +1,435 @@
+package cxgo
+
+import (
+	"go/ast"
+	token2 "go/token"
+	"strconv"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// bitFieldLayout describes how C bit-fields of a struct are packed into Go storage fields.
+type bitFieldLayout struct {
+	head   []*types.Field          // hidden fields to insert at the beginning of the struct
+	before map[int][]*types.Field  // hidden fields to insert before C field with a given index
+	tail   []*types.Field          // hidden fields to append to the struct
+	fields map[int]*types.BitField // storage of C bit-fields by field index
+}
+
+// bitFieldUnit is an unsigned integer field in Go struct that holds one or more C bit-fields.
+type bitFieldUnit struct {
+	id    *types.Ident
+	start int // in bytes
+	size  int // in bytes
+}
+
+func alignUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+func (g *translator) hasBitFields(t cc.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.FieldByIndex([]int{i}).IsBitField() {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutBitFields packs bit-fields of a struct into unsigned storage fields.
+//
+// Offsets of bit-fields are already decided by the ABI, so we only pick the largest storage unit (up to the size of
+// the declared bit-field type) that holds the bits without overlapping other fields. Go aligns the storage the same way
+// C aligns regular fields, so only the gaps that Go cannot infer are filled with padding.
+//
+// Bit offsets of the storage depend on the target byte order: big-endian targets allocate bit-fields starting
+// from the most significant bit of the storage.
+func (g *translator) layoutBitFields(t cc.Type, where token.Position) (*bitFieldLayout, error) {
+	l := &bitFieldLayout{
+		before: make(map[int][]*types.Field),
+		fields: make(map[int]*types.BitField),
+	}
+	var (
+		be      = g.env.Config().BigEndian
+		goOff   = 0 // current offset in Go struct, in bytes
+		goAlign = 1
+		unit    *bitFieldUnit
+		units   = 0
+	)
+	padField := func(n int) *types.Field {
+		return &types.Field{
+			Name:   types.NewIdentGo("", "_", types.ArrayT(types.UintT(1), n)),
+			Hidden: true,
+		}
+	}
+	// nextOffset returns the offset of the first regular field after the given one
+	nextOffset := func(i int) int {
+		for j := i + 1; j < t.NumField(); j++ {
+			if f := t.FieldByIndex([]int{j}); !f.IsBitField() {
+				return int(f.Offset())
+			}
+		}
+		return int(t.Size())
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.FieldByIndex([]int{i})
+		if !f.IsBitField() {
+			unit = nil
+			goOff = int(f.Offset()) + int(f.Type().Size())
+			if a := f.Type().FieldAlign(); a > goAlign {
+				goAlign = a
+			}
+			continue
+		}
+		w := f.BitFieldWidth()
+		if w == 0 {
+			// only affects the layout, which is already decided by the ABI
+			unit = nil
+			continue
+		} else if f.Name() == 0 {
+			continue // padding bits, never accessed
+		}
+		// bit is an index of the first bit of the field in memory order: from the least significant bit
+		// of the first byte on little-endian targets, and from the most significant bit on big-endian ones
+		bit := int(f.Offset())*8 + f.BitFieldOffset()
+		if be {
+			// for big-endian targets, cc counts bits from the least significant bit of the block
+			bit = int(f.Offset())*8 + f.BitFieldBlockWidth() - f.BitFieldOffset() - w
+		}
+		if unit == nil || bit < unit.start*8 || bit+w > (unit.start+unit.size)*8 {
+			unit = nil
+			limit := nextOffset(i)
+			addUnit := func(start, size int, typ types.Type, align int) {
+				if natural := alignUp(goOff, align); start > natural {
+					l.before[i] = append(l.before[i], padField(start-natural))
+				}
+				unit = &bitFieldUnit{
+					id:    types.NewIdentGo("", "bitfield"+strconv.Itoa(units), typ),
+					start: start,
+					size:  size,
+				}
+				units++
+				l.before[i] = append(l.before[i], &types.Field{Name: unit.id, Hidden: true})
+				goOff = start + size
+				if align > goAlign {
+					goAlign = align
+				}
+			}
+			for sz := int(f.Type().Size()); sz >= 1; sz /= 2 {
+				start := bit / 8 &^ (sz - 1)
+				if start < goOff || start+sz > limit || bit+w > (start+sz)*8 {
+					continue
+				}
+				addUnit(start, sz, types.UintT(sz), sz)
+				break
+			}
+			if unit == nil {
+				// bits may share the block with a regular field (as in GCC), so Go cannot align an integer there;
+				// fallback to a byte array that is accessed byte by byte
+				start, end := bit/8, (bit+w+7)/8
+				if start < goOff || end > limit || end-start > 8 {
+					return nil, ErrorfWithPos(where, "unsupported layout of bit-field %q", f.Name())
+				}
+				addUnit(start, end-start, types.ArrayT(types.UintT(1), end-start), 1)
+			}
+		}
+		off := bit - unit.start*8
+		if be {
+			off = (unit.start+unit.size)*8 - bit - w
+		}
+		l.fields[i] = &types.BitField{
+			Storage:   unit.id,
+			Offset:    off,
+			Width:     w,
+			BigEndian: be,
+		}
+	}
+	if a := t.Align(); a > goAlign {
+		// C aligns the struct to the declared type of bit-fields, even if the storage is smaller
+		l.head = append(l.head, &types.Field{
+			Name:   types.NewIdentGo("", "_", types.ArrayT(types.UintT(a), 0)),
+			Hidden: true,
+		})
+		goAlign = a
+	}
+	if sz := int(t.Size()); sz > alignUp(goOff, goAlign) {
+		l.tail = append(l.tail, padField(sz-goOff))
+	}
+	return l, nil
+}
+
+// bitFieldOf returns the bit-field for a selector on a struct, or nil if it's a regular field.
+// The second value is the named type that has accessor methods for the field, if any.
+func bitFieldOf(base types.Type, sel *types.Ident) (*types.Field, types.Named) {
+	t := base
+	if p, ok := types.Unwrap(t).(types.PtrType); ok && p.Elem() != nil {
+		t = p.Elem()
+	}
+	s, ok := types.Unwrap(t).(*types.StructType)
+	if !ok || !s.HasBitFields() {
+		return nil, nil
+	}
+	for _, f := range s.Fields() {
+		if f.Name == sel && f.BitField != nil {
+			nt, _ := t.(types.Named)
+			return f, nt
+		}
+	}
+	return nil, nil
+}
+
+// bitFieldMethods returns names of the getter and setter methods for the bit-field.
+func bitFieldMethods(f *types.Field) (get, set string) {
+	get = f.Name.GoIdent().Name
+	if ast.IsExported(get) {
+		return get, "Set" + get
+	}
+	return get, "set" + asExportedName(get)
+}
+
+// bitFieldLoad returns an expression that loads the storage of the bit-field, and the size of the loaded value.
+func bitFieldLoad(x GoExpr, b *types.BitField) (GoExpr, int) {
+	v := GoExpr(&ast.SelectorExpr{X: x, Sel: b.Storage.GoIdent()})
+	st := b.Storage.CType(nil)
+	arr, ok := st.(types.ArrayType)
+	if !ok {
+		return v, st.Sizeof()
+	}
+	// unaligned storage is loaded byte by byte, in the byte order of the target
+	var out GoExpr
+	for i := 0; i < arr.Len(); i++ {
+		e := shiftLeft(call(ident("uint64"), index(v, intLit(i))), 8*storageByte(b, i, arr.Len()))
+		if out == nil {
+			out = e
+		} else {
+			out = &ast.BinaryExpr{X: out, Op: token2.OR, Y: e}
+		}
+	}
+	return out, 8
+}
+
+// storageByte returns the position of the i-th byte of the byte array storage in the loaded value,
+// counting from the least significant byte.
+func storageByte(b *types.BitField, i, n int) int {
+	if b.BigEndian {
+		return n - 1 - i
+	}
+	return i
+}
+
+// bitFieldGet returns an expression that reads the bit-field from the struct.
+func bitFieldGet(x GoExpr, f *types.Field) GoExpr {
+	b := f.BitField
+	v, size := bitFieldLoad(x, b)
+	bits := size * 8
+	ft := f.Type()
+	switch {
+	case ft.Kind().IsBool():
+		return &ast.BinaryExpr{
+			X:  &ast.BinaryExpr{X: shiftRight(v, b.Offset), Op: token2.AND, Y: intLit(1)},
+			Op: token2.NEQ,
+			Y:  intLit(0),
+		}
+	case ft.Kind().IsSigned():
+		// move the sign bit to the top of the storage and shift back with sign extension
+		v = shiftLeft(v, bits-b.Offset-b.Width)
+		v = call(types.IntT(size).GoType(), v)
+		v = shiftRight(v, bits-b.Width)
+	default:
+		v = &ast.BinaryExpr{X: shiftRight(v, b.Offset), Op: token2.AND, Y: uintLit64(uint64(1)<<b.Width-1, 16)}
+	}
+	return call(ft.GoType(), v)
+}
+
+// bitFieldSet returns statements that write the bit-field of the struct.
+func bitFieldSet(x GoExpr, f *types.Field, val GoExpr) []GoStmt {
+	b := f.BitField
+	cur, size := bitFieldLoad(x, b)
+	mask := uintLit64(b.Mask(), 16)
+	if f.Type().Kind().IsBool() {
+		val = call(ident("libc.BoolToInt"), val)
+	}
+	val = shiftLeft(call(types.UintT(size).GoType(), val), b.Offset)
+	v := &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: cur, Op: token2.AND_NOT, Y: mask},
+		Op: token2.OR,
+		Y:  &ast.BinaryExpr{X: val, Op: token2.AND, Y: mask},
+	}
+	storage := &ast.SelectorExpr{X: x, Sel: b.Storage.GoIdent()}
+	arr, ok := b.Storage.CType(nil).(types.ArrayType)
+	if !ok {
+		return []GoStmt{assign(storage, v)}
+	}
+	tmp := ident("bits")
+	stmts := []GoStmt{define(tmp, v)}
+	for i := 0; i < arr.Len(); i++ {
+		stmts = append(stmts, assign(index(storage, intLit(i)), call(ident("uint8"), shiftRight(tmp, 8*storageByte(b, i, arr.Len())))))
+	}
+	return stmts
+}
+
+func shiftLeft(x GoExpr, n int) GoExpr {
+	if n == 0 {
+		return x
+	}
+	return &ast.BinaryExpr{X: x, Op: token2.SHL, Y: intLit(n)}
+}
+
+func shiftRight(x GoExpr, n int) GoExpr {
+	if n == 0 {
+		return x
+	}
+	return &ast.BinaryExpr{X: x, Op: token2.SHR, Y: intLit(n)}
+}
+
+// bitFieldAccessors generates getter and setter methods for all bit-fields of a named struct.
+func bitFieldAccessors(t types.Named) []GoDecl {
+	s, ok := types.Unwrap(t).(*types.StructType)
+	if !ok || !s.HasBitFields() {
+		return nil
+	}
+	recv := ident("s")
+	typ := t.GoType()
+	var decls []GoDecl
+	for _, f := range s.Fields() {
+		if f.BitField == nil {
+			continue
+		}
+		get, set := bitFieldMethods(f)
+		ft := f.Type().GoType()
+		v := ident("v")
+		decls = append(decls,
+			&ast.FuncDecl{
+				Recv: fields([]*GoField{{Names: []*ast.Ident{recv}, Type: typ}}),
+				Name: ident(get),
+				Type: funcTypeRet(ft),
+				Body: block(returnStmt(bitFieldGet(recv, f))),
+			},
+			&ast.FuncDecl{
+				Recv: fields([]*GoField{{Names: []*ast.Ident{recv}, Type: &ast.StarExpr{X: typ}}}),
+				Name: ident(set),
+				Type: &ast.FuncType{Params: fields([]*GoField{{Names: []*ast.Ident{v}, Type: ft}})},
+				Body: block(bitFieldSet(recv, f, v)...),
+			},
+		)
+	}
+	return decls
+}
+
+// bitField returns the bit-field selected by the expression, if any.
+// See bitFieldOf for the second value.
+func (e *CSelectExpr) bitField() (*types.Field, types.Named) {
+	return bitFieldOf(e.Expr.CType(nil), e.Sel)
+}
+
+// bitFieldAsExpr returns an expression that reads the bit-field, preferably with the accessor method.
+func (e *CSelectExpr) bitFieldAsExpr(f *types.Field, named types.Named) GoExpr {
+	x := e.Expr.AsExpr()
+	if named == nil {
+		return bitFieldGet(x, f)
+	}
+	get, _ := bitFieldMethods(f)
+	return call(&ast.SelectorExpr{X: x, Sel: ident(get)})
+}
+
+// bitFieldBase makes sure that the base of the bit-field selector is evaluated only once.
+// It returns the statements that must be executed first and a new selector expression.
+func (g *translator) bitFieldBase(x *CSelectExpr) ([]GoStmt, *CSelectExpr) {
+	if !x.Expr.HasSideEffects() {
+		return nil, x
+	}
+	var (
+		p    *types.Ident
+		init GoExpr
+	)
+	if t := x.Expr.CType(nil); t.Kind().IsPtr() {
+		p = types.NewIdent("p_", t)
+		init = x.Expr.AsExpr()
+	} else {
+		p = types.NewIdent("p_", g.env.PtrT(t))
+		init = g.cAddr(x.Expr).AsExpr()
+	}
+	return []GoStmt{define(p.GoIdent(), init)}, &CSelectExpr{Expr: PtrIdent{p}, Sel: x.Sel}
+}
+
+// assignBitField returns statements that assign a value to the bit-field.
+// For compound assignments, the operator is applied to the current value of the field.
+func (g *translator) assignBitField(x *CSelectExpr, f *types.Field, named types.Named, op BinaryOp, y Expr) []GoStmt {
+	v := y
+	if op != "" {
+		v = g.cCast(f.Type(), g.NewCBinaryExpr(x, op, y))
+	}
+	base := x.Expr.AsExpr()
+	if named == nil {
+		return bitFieldSet(base, f, v.AsExpr())
+	}
+	_, set := bitFieldMethods(f)
+	return []GoStmt{exprStmt(call(&ast.SelectorExpr{X: base, Sel: ident(set)}, v.AsExpr()))}
+}
+
+// bitFieldAssignStmt is similar to assignBitField, but it also evaluates the base of the selector only once.
+func (g *translator) bitFieldAssignStmt(x *CSelectExpr, f *types.Field, named types.Named, op BinaryOp, y Expr) []GoStmt {
+	stmts, x := g.bitFieldBase(x)
+	stmts = append(stmts, g.assignBitField(x, f, named, op, y)...)
+	if len(stmts) > 1 {
+		// temporary variables must not leak to the outer scope
+		return []GoStmt{block(stmts...)}
+	}
+	return stmts
+}
+
+// bitFieldAssignExpr returns an expression that assigns a value to the bit-field and returns a new value of it.
+// If post is set, the expression returns the old value instead.
+func (g *translator) bitFieldAssignExpr(x *CSelectExpr, f *types.Field, named types.Named, op BinaryOp, y Expr, post bool) GoExpr {
+	stmts, x := g.bitFieldBase(x)
+	if post {
+		old := ident("x")
+		stmts = append(stmts, define(old, x.AsExpr()))
+		stmts = append(stmts, g.assignBitField(x, f, named, op, y)...)
+		stmts = append(stmts, returnStmt(old))
+	} else {
+		stmts = append(stmts, g.assignBitField(x, f, named, op, y)...)
+		stmts = append(stmts, returnStmt(x.AsExpr()))
+	}
+	return callLambda(f.Type().GoType(), stmts...)
+}
+
+// bitFieldsAsExpr returns a composite literal for a struct with bit-fields.
+// Bit-fields cannot be set in Go composite literal, thus they are assigned with a separate statements.
+// It returns nil, if no bit-fields are initialized.
+func (e *CCompLitExpr) bitFieldsAsExpr() GoExpr {
+	var (
+		regular []*CompLitField
+		bits    []*CompLitField
+	)
+	for _, it := range e.Fields {
+		if f, _ := bitFieldOf(e.Type, it.Field); f != nil {
+			bits = append(bits, it)
+		} else {
+			regular = append(regular, it)
+		}
+	}
+	if len(bits) == 0 {
+		return nil
+	}
+	named, _ := e.Type.(types.Named)
+	typ := e.Type.GoType()
+	tmp := ident("tmp")
+	stmts := []GoStmt{
+		define(tmp, (&CCompLitExpr{Type: e.Type, Fields: regular}).AsExpr()),
+	}
+	for _, it := range bits {
+		f, _ := bitFieldOf(e.Type, it.Field)
+		if named == nil {
+			stmts = append(stmts, bitFieldSet(tmp, f, it.Value.AsExpr())...)
+			continue
+		}
+		_, set := bitFieldMethods(f)
+		stmts = append(stmts, exprStmt(call(&ast.SelectorExpr{X: tmp, Sel: ident(set)}, it.Value.AsExpr())))
+	}
+	stmts = append(stmts, returnStmt(tmp))
+	return callLambda(typ, stmts...)
+}
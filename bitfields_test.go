@@ -0,0 +1,291 @@
+package cxgo
+
+import (
+	"testing"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+var casesTranslateBitFields = []parseCase{
+	{
+		name: "bitfields",
+		src: `
+typedef struct {
+	unsigned int a:3;
+	int b:5;
+	unsigned int :4;
+	unsigned int c:20;
+	int d;
+} R;
+
+int foo(R* r) {
+	r->a = 1;
+	r->b -= 2;
+	r->c++;
+	return r->b + r->d;
+}
+`,
+		exp: `
+type R struct {
+	bitfield0 uint32
+	D         int32
+}
+
+func (s R) A() uint32 {
+	return uint32(s.bitfield0 & 0x7)
+}
+func (s *R) SetA(v uint32) {
+	s.bitfield0 = s.bitfield0&^0x7 | uint32(v)&0x7
+}
+func (s R) B() int32 {
+	return int32(int32(s.bitfield0<<24) >> 27)
+}
+func (s *R) SetB(v int32) {
+	s.bitfield0 = s.bitfield0&^0xF8 | uint32(v)<<3&0xF8
+}
+func (s R) C() uint32 {
+	return uint32(s.bitfield0 >> 12 & 0xFFFFF)
+}
+func (s *R) SetC(v uint32) {
+	s.bitfield0 = s.bitfield0&^0xFFFFF000 | uint32(v)<<12&0xFFFFF000
+}
+func foo(r *R) int32 {
+	r.SetA(1)
+	r.SetB(r.B() - 2)
+	r.SetC(r.C() + 1)
+	return r.B() + r.D
+}
+`,
+	},
+	{
+		name: "bitfields layout",
+		src: `
+#include <stddef.h>
+
+struct S {
+	unsigned int a:3;
+	char c;
+	unsigned int b:2;
+};
+
+int foo() {
+	return sizeof(struct S);
+}
+`,
+		exp: `
+type S struct {
+	_         [0]uint32
+	bitfield0 uint16
+	C         int8
+	bitfield1 uint8
+}
+
+func (s S) A() uint32 {
+	return uint32(s.bitfield0 & 0x7)
+}
+func (s *S) SetA(v uint32) {
+	s.bitfield0 = s.bitfield0&^0x7 | uint16(v)&0x7
+}
+func (s S) B() uint32 {
+	return uint32(s.bitfield1 & 0x3)
+}
+func (s *S) SetB(v uint32) {
+	s.bitfield1 = s.bitfield1&^0x3 | uint8(v)&0x3
+}
+func foo() int32 {
+	return int32(unsafe.Sizeof(S{}))
+}
+`,
+	},
+	{
+		name: "bitfields init",
+		src: `
+typedef struct {
+	unsigned int a:3;
+	int b:5;
+	int c;
+} R;
+
+R r1 = {1, -2, 3};
+R r2 = {.b = 2};
+`,
+		exp: `
+type R struct {
+	bitfield0 uint32
+	C         int32
+}
+
+func (s R) A() uint32 {
+	return uint32(s.bitfield0 & 0x7)
+}
+func (s *R) SetA(v uint32) {
+	s.bitfield0 = s.bitfield0&^0x7 | uint32(v)&0x7
+}
+func (s R) B() int32 {
+	return int32(int32(s.bitfield0<<24) >> 27)
+}
+func (s *R) SetB(v int32) {
+	s.bitfield0 = s.bitfield0&^0xF8 | uint32(v)<<3&0xF8
+}
+
+var r1 R = func() R {
+	tmp := R{C: 3}
+	tmp.SetA(1)
+	tmp.SetB(-2)
+	return tmp
+}()
+var r2 R = func() R {
+	tmp := R{}
+	tmp.SetB(2)
+	return tmp
+}()
+`,
+	},
+	{
+		name: "bitfields big endian",
+		src: `
+typedef struct {
+	unsigned int a:3;
+	int b:5;
+	unsigned int :4;
+	unsigned int c:20;
+} R;
+
+struct S {
+	char c;
+	unsigned int a:12;
+	char d;
+};
+
+int foo(R* r, struct S* s) {
+	r->a = 1;
+	s->a++;
+	return r->b + r->c;
+}
+`,
+		envFuncs: []envFunc{func(c *types.Config) {
+			c.BigEndian = true
+		}},
+		exp: `
+type R struct {
+	bitfield0 uint32
+}
+
+func (s R) A() uint32 {
+	return uint32(s.bitfield0 >> 29 & 0x7)
+}
+func (s *R) SetA(v uint32) {
+	s.bitfield0 = s.bitfield0&^0xE0000000 | uint32(v)<<29&0xE0000000
+}
+func (s R) B() int32 {
+	return int32(int32(s.bitfield0<<3) >> 27)
+}
+func (s *R) SetB(v int32) {
+	s.bitfield0 = s.bitfield0&^0x1F000000 | uint32(v)<<24&0x1F000000
+}
+func (s R) C() uint32 {
+	return uint32(s.bitfield0 & 0xFFFFF)
+}
+func (s *R) SetC(v uint32) {
+	s.bitfield0 = s.bitfield0&^0xFFFFF | uint32(v)&0xFFFFF
+}
+
+type S struct {
+	_         [0]uint32
+	C         int8
+	bitfield0 [2]uint8
+	D         int8
+}
+
+func (s S) A() uint32 {
+	return uint32((uint64(s.bitfield0[0])<<8 | uint64(s.bitfield0[1])) >> 4 & 0xFFF)
+}
+func (s *S) SetA(v uint32) {
+	bits := (uint64(s.bitfield0[0])<<8|uint64(s.bitfield0[1]))&^0xFFF0 | uint64(v)<<4&0xFFF0
+	s.bitfield0[0] = uint8(bits >> 8)
+	s.bitfield0[1] = uint8(bits)
+}
+func foo(r *R, s *S) int32 {
+	r.SetA(1)
+	s.SetA(s.A() + 1)
+	return int32(uint32(r.B()) + r.C())
+}
+`,
+	},
+	{
+		name: "bitfields anonymous struct",
+		src: `
+struct {
+	int v:4;
+} g;
+
+int foo() {
+	g.v = 3;
+	return g.v++;
+}
+`,
+		exp: `
+var g struct {
+	bitfield0 uint32
+}
+
+func foo() int32 {
+	g.bitfield0 = g.bitfield0&^0xF | uint32(3)&0xF
+	return func() int32 {
+		x := int32(int32(g.bitfield0<<28) >> 28)
+		g.bitfield0 = g.bitfield0&^0xF | uint32(int32(int32(g.bitfield0<<28)>>28)+1)&0xF
+		return x
+	}()
+}
+`,
+	},
+}
+
+func TestTranslateBitFields(t *testing.T) {
+	runTestTranslate(t, casesTranslateBitFields)
+}
+
+const runBitFieldsSrc = `
+#include <stdio.h>
+
+struct Header {
+	unsigned int version:4;
+	unsigned int ihl:4;
+	int delta:6;
+	unsigned int flags:3;
+	char tag;
+	unsigned int :0;
+	unsigned int len:16;
+	_Bool ok:1;
+};
+
+struct Header next(struct Header h) {
+	h.version++;
+	h.delta -= 40;
+	h.flags |= 5;
+	h.len += 1000;
+	h.ok = !h.ok;
+	return h;
+}
+
+void print(struct Header* h) {
+	printf("%u %u %d %u %c %u %d\n", h->version, h->ihl, h->delta, h->flags, h->tag, h->len, h->ok);
+}
+
+int main() {
+	struct Header h = {15, 5, -3, 2, 'x', 65000, 0};
+	print(&h);
+	h = next(h);
+	print(&h);
+	struct Header* p = &h;
+	int v = p->ihl++;
+	int d = (p->delta = 31);
+	printf("%d %d\n", v, d);
+	print(p);
+	return 0;
+}
+`
+
+func TestRunBitFields(t *testing.T) {
+	testTranspileOut(t, runBitFieldsSrc)
+}
@@ -152,6 +152,12 @@ func (d *CVarDecl) AsDecl() []GoDecl {
 			}
 		}
 	}
+	var docs []*ast.CommentGroup
+	for _, name := range d.Names {
+		if name.Name != "__func__" {
+			docs = append(docs, name.GoDoc())
+		}
+	}
 	var specs []ast.Spec
 	if single {
 		specs = []ast.Spec{sp}
@@ -162,16 +168,25 @@ func (d *CVarDecl) AsDecl() []GoDecl {
 				vals = []ast.Expr{sp.Values[i]}
 			}
 			specs = append(specs, &ast.ValueSpec{
+				Doc:    docs[i],
 				Names:  []*ast.Ident{name},
 				Type:   sp.Type,
 				Values: vals,
 			})
 		}
 	}
-	return []GoDecl{&ast.GenDecl{
+	decl := &ast.GenDecl{
 		Tok:   tok,
 		Specs: specs,
-	}}
+	}
+	if len(specs) == 1 {
+		// doc comment of a single declaration is attached to the whole decl
+		decl.Doc = docs[0]
+		if vs, ok := specs[0].(*ast.ValueSpec); ok {
+			vs.Doc = nil
+		}
+	}
+	return []GoDecl{decl}
 }
 
 type CTypeDef struct {
@@ -196,6 +211,7 @@ func (d *CTypeDef) AsDecl() []GoDecl {
 		})
 	}
 	decls = append(decls, &ast.GenDecl{
+		Doc: d.Name().GoDoc(),
 		Tok: token.TYPE,
 		Specs: []ast.Spec{
 			&ast.TypeSpec{
@@ -204,6 +220,7 @@ func (d *CTypeDef) AsDecl() []GoDecl {
 			},
 		},
 	})
+	decls = append(decls, bitFieldAccessors(d.Named)...)
 	return decls
 }
 
@@ -217,6 +234,8 @@ type CFuncDecl struct {
 	Type  *types.FuncType
 	Body  *BlockStmt
 	Range *Range
+	// TypeParam is set for generic functions generated from function-like macros. See macroFuncSource.
+	TypeParam types.Named
 }
 
 func (d *CFuncDecl) Visit(v Visitor) {
@@ -225,11 +244,20 @@ func (d *CFuncDecl) Visit(v Visitor) {
 }
 
 func (d *CFuncDecl) AsDecl() []GoDecl {
+	ft := d.Type.GoFuncType()
+	body := d.Body.GoBlockStmt()
+	if d.TypeParam != nil {
+		ft.TypeParams = fields([]*GoField{{
+			Names: []*ast.Ident{d.TypeParam.Name().GoIdent()},
+			Type:  macroTypeConstraint(body),
+		}})
+	}
 	return []GoDecl{
 		&ast.FuncDecl{
+			Doc:  d.Name.GoDoc(),
 			Name: d.Name.GoIdent(),
-			Type: d.Type.GoFuncType(),
-			Body: d.Body.GoBlockStmt(),
+			Type: ft,
+			Body: body,
 		},
 	}
 }
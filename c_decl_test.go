@@ -1,8 +1,13 @@
 package cxgo
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
 	"github.com/gotranspile/cxgo/types"
 )
 
@@ -68,6 +73,42 @@ char byte;
 		exp: `
 var foo int32
 var byte_ int8
+`,
+	},
+	{
+		name: "static local",
+		src: `
+int counter() {
+	static int n = 10;
+	{
+		static int n;
+		n++;
+	}
+	return n;
+}
+int counter_n;
+int total() {
+	static int n;
+	return n + counter_n;
+}
+`,
+		exp: `
+var counter_n2 int32 = 10
+var counter_n3 int32
+
+func counter() int32 {
+	{
+		counter_n3++
+	}
+	return counter_n2
+}
+
+var counter_n int32
+var total_n int32
+
+func total() int32 {
+	return total_n + counter_n
+}
 `,
 	},
 	{
@@ -947,6 +988,152 @@ const MY_CONST_2 = 2
 var a int32 = MY_CONST
 `,
 	},
+	{
+		name: "macro expr",
+		src: `
+#define BUF_SIZE 512
+#define MASK (1 << 4)
+#define FLAGS 1 | 2
+#define NEG -1
+#define TWICE (BUF_SIZE * 2)
+#define SAME (1 << 4)
+#define BIG (MASK > 8)
+
+int foo(int x) {
+	if (x & FLAGS) {
+		x = FLAGS;
+		return NEG;
+	}
+	switch (x) {
+	case TWICE:
+		return BIG;
+	case BUF_SIZE + 1:
+		return x - (1 << 4);
+	}
+	return x + TWICE * 2 + MASK;
+}
+`,
+		exp: `
+const BUF_SIZE = 512
+const MASK = 16
+const FLAGS = 3
+const NEG = -1
+const TWICE = 1024
+const SAME = 16
+const BIG = 1
+
+func foo(x int32) int32 {
+	if x&1|2 != 0 {
+		x = FLAGS
+		return NEG
+	}
+	switch x {
+	case TWICE:
+		return libc.BoolToInt((1 << 4) > 8)
+	case int32(BUF_SIZE + 1):
+		return x - (1 << 4)
+	}
+	return x + TWICE*2 + (1 << 4)
+}
+`,
+	},
+	{
+		name: "macro array len",
+		src: `
+#define BUF 16
+#define ROWS (2 + 2)
+#define COLS 3
+
+typedef char name_t[BUF];
+
+struct S {
+	int vals[COLS];
+};
+
+int grid[ROWS][COLS];
+int fixed[5];
+
+void foo() {
+	char buf[BUF];
+	buf[0] = 1;
+}
+`,
+		exp: `
+const BUF = 16
+const ROWS = 4
+const COLS = 3
+
+type name_t [BUF]byte
+type S struct {
+	Vals [COLS]int32
+}
+
+var grid [ROWS][COLS]int32
+var fixed [5]int32
+
+func foo() {
+	var buf [BUF]byte
+	_ = buf
+	buf[0] = 1
+}
+`,
+	},
+	{
+		name: "macro func generic",
+		src: `
+#define MIN(a, b) ((a) < (b) ? (a) : (b))
+#define IS_ODD(x) ((x) % 2 != 0)
+#define ZERO() 0
+
+int foo(int a, int b) {
+	return MIN(a, b);
+}
+`,
+		exp: `
+func MIN[T libc.Number](a T, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+func IS_ODD[T libc.Integer](x T) int32 {
+	return libc.BoolToInt(x%2 != 0)
+}
+func ZERO() int32 {
+	return 0
+}
+func foo(a int32, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+`,
+		configFuncs: []configFunc{withMacroFuncs},
+	},
+	{
+		name: "macro func skip",
+		src: `
+#define STR(x) #x
+#define LOG(...) foo(__VA_ARGS__)
+#define DO(x) do { x; } while (0)
+#define DEREF(p) (*(p))
+#define TWICE(x) (2*(x))
+
+int foo(int a) {
+	return TWICE(a);
+}
+`,
+		exp: `
+func TWICE[T libc.Number](x T) T {
+	return x * 2
+}
+func foo(a int32) int32 {
+	return a * 2
+}
+`,
+		configFuncs: []configFunc{withMacroFuncs},
+	},
 	{
 		name: "tmp var names",
 		src: `
@@ -985,3 +1172,80 @@ func foo(p *vec_t) {
 func TestDecls(t *testing.T) {
 	runTestTranslate(t, casesTranslateDecls)
 }
+
+func TestMacroFuncsSkipped(t *testing.T) {
+	const src = `
+#define STR(x) #x
+#define DO(x) do { x; } while (0)
+#define DEREF(p) (*(p))
+#define TWICE(x) (2*(x))
+#define CALL(f) (f)()
+
+int foo(int a) {
+	return TWICE(a);
+}
+`
+	env := libs.NewEnv(types.Config32())
+	m := NewMacroFuncs()
+	ast, err := ParseSource(env, ParseConfig{
+		Sources:    []cc.Source{{Name: "main.c", Value: src}},
+		MacroFuncs: m,
+	})
+	require.NoError(t, err)
+	_, err = TranslateAST("main.c", ast, env, Config{MacroFuncs: m})
+	require.NoError(t, err)
+	var skipped []string
+	for _, s := range m.Skipped() {
+		skipped = append(skipped, s.String())
+	}
+	require.Equal(t, []string{
+		"main.c:2: STR: stringification and token pasting are not supported",
+		"main.c:3: DO: cannot type-check the body",
+		// only one retry is made, thus all the other macros are skipped as well
+		"main.c:4: DEREF: the file cannot be parsed with wrapper functions",
+		"main.c:5: TWICE: the file cannot be parsed with wrapper functions",
+		"main.c:6: CALL: the file cannot be parsed with wrapper functions",
+	}, skipped)
+}
+
+func TestMacroFuncsHeaders(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"inc/util.h": `
+#include "more.h"
+#define SQR(x) ((x)*(x))
+#define TWICE(x) (x)
+`,
+		"inc/more.h": `
+#define CUBE(x) ((x)*(x)*(x))
+`,
+		"main.c": `
+#include <stdlib.h>
+#include "util.h"
+#undef TWICE
+#define TWICE(x) (2*(x))
+
+int foo(int a) {
+	return SQR(a) + CUBE(a) + TWICE(a);
+}
+`,
+	})
+	env := libs.NewEnv(types.Config32())
+	m := NewMacroFuncs()
+	ast, err := ParseSource(env, ParseConfig{
+		Sources:    []cc.Source{{Name: filepath.Join(dir, "main.c")}},
+		Includes:   []string{filepath.Join(dir, "inc")},
+		MacroFuncs: m,
+	})
+	require.NoError(t, err)
+	_, err = TranslateAST(filepath.Join(dir, "main.c"), ast, env, Config{MacroFuncs: m})
+	require.NoError(t, err)
+	var skipped []string
+	for _, s := range m.Skipped() {
+		skipped = append(skipped, s.String())
+	}
+	require.Equal(t, []string{
+		filepath.Join(dir, "inc/util.h") + ":2: SQR: macros from headers are not supported",
+		filepath.Join(dir, "inc/more.h") + ":1: CUBE: macros from headers are not supported",
+	}, skipped)
+}
@@ -27,6 +27,22 @@ func canAssignTo(x Expr) bool {
 	return false
 }
 
+// isAddressable reports whether the expression denotes a value with an address, as opposed to a temporary
+// value such as a result of a function call.
+func isAddressable(x Expr) bool {
+	x = cUnwrap(x)
+	switch x := x.(type) {
+	case Ident, *Deref, *CIndexExpr:
+		return true
+	case *CSelectExpr:
+		if _, ok := types.Unwrap(x.Expr.CType(nil)).(types.PtrType); ok {
+			return true
+		}
+		return isAddressable(x.Expr)
+	}
+	return false
+}
+
 type Ident interface {
 	Expr
 	Identifier() *types.Ident
@@ -720,7 +736,42 @@ func (e *CSelectExpr) HasSideEffects() bool {
 	return e.Expr.HasSideEffects()
 }
 
+// unionBase returns the union type of the selector base, if any.
+// The second value reports whether the base is a pointer to the union.
+func (e *CSelectExpr) unionBase() (*types.StructType, bool) {
+	t := types.Unwrap(e.Expr.CType(nil))
+	ptr := false
+	if p, ok := t.(types.PtrType); ok && p.Elem() != nil {
+		t = types.Unwrap(p.Elem())
+		ptr = true
+	}
+	if s, ok := t.(*types.StructType); ok && s.IsUnion() {
+		return s, ptr
+	}
+	return nil, false
+}
+
 func (e *CSelectExpr) AsExpr() GoExpr {
+	if f, named := e.bitField(); f != nil {
+		return e.bitFieldAsExpr(f, named)
+	}
+	if u, ptr := e.unionBase(); u != nil {
+		// all union members share the same storage, so we access them through an unsafe view
+		p := e.Expr.AsExpr()
+		if !ptr && !isAddressable(e.Expr) {
+			// cannot take an address of a temporary value, copy it first
+			t := e.Sel.CType(nil).GoType()
+			tmp := ident("tmp")
+			return callLambda(t,
+				define(tmp, p),
+				returnStmt(unionView(t, addr(tmp))),
+			)
+		}
+		if !ptr {
+			p = addr(p)
+		}
+		return unionView(e.Sel.CType(nil).GoType(), p)
+	}
 	return &ast.SelectorExpr{
 		X:   e.Expr.AsExpr(),
 		Sel: e.Sel.GoIdent(),
@@ -782,6 +833,15 @@ func (e *CIncrExpr) ToStmt(inline bool) []CStmt {
 }
 
 func (e *CIncrExpr) AsExpr() GoExpr {
+	if x, ok := e.Expr.(*CSelectExpr); ok {
+		if f, named := x.bitField(); f != nil {
+			op := BinOpAdd
+			if e.Decr {
+				op = BinOpSub
+			}
+			return e.g.bitFieldAssignExpr(x, f, named, op, cIntLit(1, 10), !e.Prefix)
+		}
+	}
 	pi := types.NewIdent("p_", e.g.env.PtrT(e.Expr.CType(nil)))
 	p := pi.GoIdent()
 	y := e.g.cAddr(e.Expr).AsExpr()
@@ -1181,6 +1241,12 @@ func (e *CAssignExpr) ToStmt(inline bool) []CStmt {
 
 func (e *CAssignExpr) AsExpr() GoExpr {
 	ret := e.CType(nil)
+	if x, ok := e.Stmt.Left.(*CSelectExpr); ok {
+		if f, named := x.bitField(); f != nil {
+			// bit-fields are not addressable
+			return e.Stmt.g.bitFieldAssignExpr(x, f, named, e.Stmt.Op, e.Stmt.Right, false)
+		}
+	}
 	var stmts []GoStmt
 	if id, ok := e.Stmt.Left.(IdentExpr); ok {
 		stmts = append(stmts,
@@ -1233,9 +1299,14 @@ func mergeStructInitializers(items []*CompLitField) []*CompLitField {
 			out = append(out, it)
 			continue
 		}
-		it2 := byField[it.Field.Name]
+		key := it.Field.Name
+		if key == "" {
+			// anonymous struct or union
+			key = it.Field.GoIdent().Name
+		}
+		it2 := byField[key]
 		if it2 == nil {
-			byField[it.Field.Name] = it
+			byField[key] = it
 			out = append(out, it)
 			continue
 		}
@@ -1259,19 +1330,26 @@ func mergeStructInitializers(items []*CompLitField) []*CompLitField {
 func (g *translator) NewCCompLitExpr(typ types.Type, items []*CompLitField) Expr {
 	if k := typ.Kind(); k.Is(types.Struct) {
 		st := types.Unwrap(typ).(*types.StructType)
+		// hidden fields cannot be initialized in C
+		var fields []*types.Field
+		for _, f := range st.Fields() {
+			if !f.Hidden {
+				fields = append(fields, f)
+			}
+		}
 		next := 0
 		for _, it := range items {
 			var ft types.Type
 			if it.Field != nil {
 				ft = it.Field.CType(nil)
 			} else if it.Index == nil {
-				f := st.Fields()[next]
+				f := fields[next]
 				next++
 				it.Field = f.Name
 				ft = f.Type()
 			} else if l, ok := cUnwrap(it.Index).(IntLit); ok {
 				next = int(l.Uint())
-				f := st.Fields()[next]
+				f := fields[next]
 				next++
 				it.Field = f.Name
 				ft = f.Type()
@@ -1363,6 +1441,13 @@ func (e *CCompLitExpr) AsExpr() GoExpr {
 	if len(e.Fields) == 1 && e.Fields[0].Value != nil && (kind.IsInt() || kind.IsFloat() || kind.IsBool()) {
 		return tmpVar(e.Type.GoType(), e.Fields[0].Value.AsExpr(), false)
 	}
+	if s, ok := types.Unwrap(e.Type).(*types.StructType); ok && s.IsUnion() {
+		return e.unionAsExpr()
+	} else if ok && s.HasBitFields() {
+		if x := e.bitFieldsAsExpr(); x != nil {
+			return x
+		}
+	}
 	var items []GoExpr
 	isArr := kind.Is(types.Array)
 	ordered := false
@@ -1412,6 +1497,27 @@ func (e *CCompLitExpr) AsExpr() GoExpr {
 	}
 }
 
+// unionAsExpr initializes union members one by one through unsafe views in a function literal.
+func (e *CCompLitExpr) unionAsExpr() GoExpr {
+	typ := e.Type.GoType()
+	tmp := ident("tmp")
+	stmts := []GoStmt{
+		decl(&ast.ValueSpec{
+			Names: []*ast.Ident{tmp},
+			Type:  typ,
+		}),
+	}
+	for _, f := range e.Fields {
+		if f.Field == nil {
+			continue
+		}
+		view := unionView(f.Field.CType(nil).GoType(), addr(tmp))
+		stmts = append(stmts, assign(view, f.Value.AsExpr()))
+	}
+	stmts = append(stmts, returnStmt(tmp))
+	return callLambda(typ, stmts...)
+}
+
 func (e *CCompLitExpr) Uses() []types.Usage {
 	var list []types.Usage
 	// TODO: use the type
@@ -391,7 +391,7 @@ func (s *CSwitchStmt) AsStmt() []GoStmt {
 		} else {
 			switch b := sub[len(sub)-1].(type) {
 			case *ast.BranchStmt:
-				if b.Tok == token.BREAK {
+				if b.Tok == token.BREAK && b.Label == nil {
 					sub = sub[:len(sub)-1]
 				}
 			case *ast.ReturnStmt:
@@ -452,10 +452,11 @@ func (g *translator) NewCForStmt(init Expr, cond BoolExpr, iter Expr, stmts []CS
 var _ CCompStmt = &CForStmt{}
 
 type CForStmt struct {
-	Init CStmt
-	Cond Expr
-	Iter CStmt
-	Body BlockStmt
+	Label string // optional, for labeled break and continue
+	Init  CStmt
+	Cond  Expr
+	Iter  CStmt
+	Body  BlockStmt
 }
 
 func (s *CForStmt) Visit(v Visitor) {
@@ -502,12 +503,16 @@ func (s *CForStmt) AsStmt() []GoStmt {
 	if s.Cond != nil {
 		cond = s.Cond.AsExpr()
 	}
-	return []GoStmt{&ast.ForStmt{
+	var st GoStmt = &ast.ForStmt{
 		Init: init,
 		Cond: cond,
 		Post: iter,
 		Body: s.Body.GoBlockStmt(),
-	}}
+	}
+	if s.Label != "" {
+		st = &ast.LabeledStmt{Label: ident(s.Label), Stmt: st}
+	}
+	return []GoStmt{st}
 }
 
 func (s *CForStmt) Uses() []types.Usage {
@@ -614,30 +619,42 @@ func countBreak(stmts ...CStmt) int {
 	return cnt
 }
 
-type CContinueStmt struct{}
+type CContinueStmt struct {
+	Label string // optional
+}
 
 func (s *CContinueStmt) Visit(v Visitor) {}
 
 func (s *CContinueStmt) AsStmt() []GoStmt {
-	return []GoStmt{&ast.BranchStmt{Tok: token.CONTINUE}}
+	return []GoStmt{branchStmt(token.CONTINUE, s.Label)}
 }
 
 func (s *CContinueStmt) Uses() []types.Usage {
 	return nil
 }
 
-type CBreakStmt struct{}
+type CBreakStmt struct {
+	Label string // optional
+}
 
 func (s *CBreakStmt) Visit(v Visitor) {}
 
 func (s *CBreakStmt) AsStmt() []GoStmt {
-	return []GoStmt{&ast.BranchStmt{Tok: token.BREAK}}
+	return []GoStmt{branchStmt(token.BREAK, s.Label)}
 }
 
 func (s *CBreakStmt) Uses() []types.Usage {
 	return nil
 }
 
+func branchStmt(tok token.Token, label string) *ast.BranchStmt {
+	b := &ast.BranchStmt{Tok: tok}
+	if label != "" {
+		b.Label = ident(label)
+	}
+	return b
+}
+
 func (g *translator) NewReturnStmt(x Expr, rtyp types.Type) []CStmt {
 	switch x := cUnwrap(x).(type) {
 	case *CTernaryExpr:
@@ -1410,6 +1427,15 @@ func (s *CIncrStmt) Visit(v Visitor) {
 }
 
 func (s *CIncrStmt) AsStmt() []GoStmt {
+	if x, ok := s.Expr.(*CSelectExpr); ok {
+		if f, named := x.bitField(); f != nil {
+			op := BinOpAdd
+			if s.Decr {
+				op = BinOpSub
+			}
+			return s.g.bitFieldAssignStmt(x, f, named, op, cIntLit(1, 10))
+		}
+	}
 	if s.Expr.CType(nil).Kind().IsPtr() {
 		var arg Expr
 		if s.Decr {
@@ -1519,6 +1545,11 @@ func (s *CAssignStmt) Visit(v Visitor) {
 }
 
 func (s *CAssignStmt) AsStmt() []GoStmt {
+	if x, ok := s.Left.(*CSelectExpr); ok {
+		if f, named := x.bitField(); f != nil {
+			return s.g.bitFieldAssignStmt(x, f, named, s.Op, s.Right)
+		}
+	}
 	x := s.Left.AsExpr()
 	y := s.Right.AsExpr()
 	return []GoStmt{
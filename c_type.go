@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"fmt"
+	"strconv"
 	"unicode"
 	"unicode/utf8"
 
@@ -25,8 +26,9 @@ func (g *translator) convertTypeOper(p cc.Operand, where token.Position) types.T
 		where = d.Position()
 	}
 	var conf IdentConfig
-	if d := p.Declarator(); d != nil {
-		conf = g.idents[d.Name().String()]
+	if d := p.Declarator(); d != nil && d.Type().Kind() == p.Type().Kind() {
+		// operands of some expressions refer to a declarator of a different type (e.g. pointer index)
+		conf = g.declConf(d)
 	}
 	return g.convertTypeRoot(conf, p.Type(), where)
 }
@@ -236,6 +238,7 @@ func (g *translator) newTypeCC(conf IdentConfig, t cc.Type, where token.Position
 	// it's handled separately because it's the only type that is allowed to be incomplete
 	if t != t.Alias() {
 		if t.IsIncomplete() {
+			g.checkPartial(sname, t)
 			if nt, ok := g.named[sname]; ok {
 				return nt
 			}
@@ -330,13 +333,16 @@ func (g *translator) newTypeCC(conf IdentConfig, t cc.Type, where token.Position
 		}
 		return g.env.PtrT(elem)
 	case cc.Array:
+		var elem types.Type
 		if t.Elem().Kind() == cc.Char {
-			return types.ArrayT(g.env.Go().Byte(), int(t.Len()))
+			elem = g.env.Go().Byte()
+		} else {
+			elem = g.convertType(IdentConfig{}, t.Elem(), where)
 		}
-		elem := g.convertType(IdentConfig{}, t.Elem(), where)
-		return types.ArrayT(
+		return types.ArrayTLen(
 			elem,
 			int(t.Len()),
+			g.arrayLenMacro(t),
 		)
 	case cc.Union:
 		if name := t.Name(); name != 0 {
@@ -360,7 +366,7 @@ func (g *translator) newTypeCC(conf IdentConfig, t cc.Type, where token.Position
 				Name: g.newIdent(name, ft),
 			})
 		}
-		return types.UnionT(fields)
+		return types.UnionTAligned(fields, int(t.Align()))
 	case cc.Enum:
 		return g.newTypeCC(IdentConfig{}, t.EnumType(), where)
 	default:
@@ -373,39 +379,80 @@ func (g *translator) convertStructType(conf IdentConfig, t cc.Type, where token.
 	if c, ok := g.idents[sname]; ok {
 		conf = c
 	}
+	g.checkPartial(sname, t)
 	fconf := make(map[string]IdentConfig)
 	for _, f := range conf.Fields {
 		fconf[f.Name] = f
 	}
 	buildType := func() types.Type {
-		var fields []*types.Field
+		var (
+			fields []*types.Field
+			anon   int
+			bits   *bitFieldLayout
+		)
+		if t.Kind() != cc.Union && g.hasBitFields(t) {
+			var err error
+			if bits, err = g.layoutBitFields(t, where); err != nil {
+				// bit-fields will be declared as regular fields; the layout is wrong, but the error is reported
+				g.addError(err)
+			} else {
+				fields = append(fields, bits.head...)
+			}
+		}
 		for i := 0; i < t.NumField(); i++ {
 			f := t.FieldByIndex([]int{i})
+			if bits != nil {
+				fields = append(fields, bits.before[i]...)
+			}
+			if f.IsBitField() && f.Name() == 0 {
+				continue // padding bits
+			}
 			fc := fconf[f.Name().String()]
 			ft := g.convertTypeRoot(fc, f.Type(), where)
 			if f.Name() == 0 {
 				st := types.Unwrap(ft).(*types.StructType)
-				fields = append(fields, st.Fields()...)
+				if t.Kind() != cc.Union && !st.IsUnion() {
+					// anonymous struct in a struct has the same layout as its fields
+					fields = append(fields, st.Fields()...)
+					continue
+				}
+				// anonymous unions (and anonymous structs inside unions) need their own storage,
+				// so we keep them as a field with a generated Go name; see tryConvertFieldPath
+				fname := types.NewIdentGo("", "anon"+strconv.Itoa(anon), ft)
+				anon++
+				if !g.conf.UnexportedFields {
+					fname.GoName = asExportedName(fname.GoName)
+				}
+				fields = append(fields, &types.Field{Name: fname})
 				continue
 			}
 			fname := g.newIdent(f.Name().String(), ft)
+			g.setDoc(fname, g.fieldDecl(f))
 			if fc.Rename != "" {
 				fname.GoName = fc.Rename
 			} else if !g.conf.UnexportedFields {
 				fname.GoName = asExportedName(fname.Name)
 			}
+			var bf *types.BitField
+			if bits != nil {
+				bf = bits.fields[i]
+			}
 			fields = append(fields, &types.Field{
-				Name: fname,
+				Name:     fname,
+				BitField: bf,
 			})
 		}
+		if bits != nil {
+			fields = append(fields, bits.tail...)
+		}
 		if !where.IsValid() {
 			panic(where)
 		}
 		var s *types.StructType
 		if t.Kind() == cc.Union {
-			s = types.UnionT(fields)
+			s = types.UnionTAligned(fields, int(t.Align()))
 		} else {
-			s = types.StructT(fields)
+			s = types.StructTAligned(fields, int(t.Align()))
 		}
 		s.Where = where.String()
 		if t.Name() == 0 {
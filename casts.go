@@ -37,6 +37,10 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 				}
 			}
 		}
+		if m, ok := unwrapCasts(x).(*NewArrayExpr); ok && types.Same(m.Elem, at.Elem()) {
+			// libc.NewArray[T](n) -> make([]T, n)
+			return &MakeExpr{e: g.env.Env, Elem: m.Elem, Size: m.Size}
+		}
 		if fc, ok := x.(*CallExpr); ok && len(fc.Args) >= 1 {
 			if f, ok := fc.Fun.(Ident); ok {
 				gg := g.env.Go()
@@ -50,6 +54,10 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 			}
 		}
 	}
+	if m, ok := cUnwrap(x).(*MakeExpr); ok && m.Cap == nil && toKind.IsPtr() {
+		// make([]T, n) -> libc.NewArray[T](n)
+		return g.cCast(toType, &NewArrayExpr{e: g.env.Env, Elem: m.Elem, Size: m.Size})
+	}
 	if xKind.Is(types.Array) && !toKind.Is(types.Array) {
 		x = g.cAddr(x)
 		return g.cCast(toType, x)
@@ -251,6 +259,10 @@ func (g *translator) cCast(toType types.Type, x Expr) Expr {
 			return g.cCast(toType, x)
 		}
 		ft, fx := types.Unwrap(toType).(*types.FuncType), types.Unwrap(xType).(*types.FuncType)
+		if _, unnamed := xType.(*types.FuncType); unnamed && ft.Variadic() == fx.Variadic() && types.Same(ft, fx) {
+			// unnamed function type can be assigned to a named one with the same signature
+			return x
+		}
 		if (ft.Variadic() == fx.Variadic() || !ft.Variadic()) && ft.ArgN() >= fx.ArgN() && ((ft.Return() != nil) == (fx.Return() != nil) || (ft.Return() == nil && fx.Return() != nil)) {
 			// cannot cast directly, but can return lambda instead
 			callArgs := make([]Expr, 0, ft.ArgN())
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gotranspile/cxgo"
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func init() {
+	cmdCFG := &cobra.Command{
+		Use:   "cfg <file.c> <func>",
+		Short: "export control flow graphs of a C function",
+		Long: `Export control flow graphs of a C function.
+
+Writes the following files to the output directory:
+  <func>.dot       control flow graph of the function
+  <func>_dom.dot   dominator tree of the control flow graph
+  <func>_flat.dot  control flow graph after flattening (see idents.flatten)
+
+With "svg" format, the graphs are rendered with Graphviz "dot" command.
+With "json" format, all graphs are written to <func>.json.`,
+	}
+	Root.AddCommand(cmdCFG)
+
+	fOut := cmdCFG.Flags().StringP("out", "o", ".", "output directory")
+	fFormat := cmdCFG.Flags().StringSliceP("format", "f", []string{"dot"}, "output formats (dot, svg, json)")
+	fInclude := cmdCFG.Flags().StringSliceP("include", "I", nil, "include directories")
+	fDefine := cmdCFG.Flags().StringSliceP("define", "D", nil, "preprocessor defines (NAME or NAME=VALUE)")
+	fTarget := cmdCFG.Flags().String("target", "", "target platform ("+strings.Join(types.Targets(), ", ")+")")
+	cmdCFG.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("a file and a function name must be specified")
+		}
+		in, fnc := args[0], args[1]
+		for _, f := range *fFormat {
+			switch f {
+			case "dot", "svg", "json":
+			default:
+				return fmt.Errorf("unsupported format: %q", f)
+			}
+		}
+		tconf := types.Config{
+			UseGoInt: true,
+		}
+		if *fTarget != "" {
+			var err error
+			tconf, err = types.ConfigForTarget(*fTarget)
+			if err != nil {
+				return err
+			}
+		}
+		env := libs.NewEnv(tconf)
+		fc := cxgo.Config{
+			Include: *fInclude,
+		}
+		for _, d := range *fDefine {
+			name, val, _ := strings.Cut(d, "=")
+			fc.Define = append(fc.Define, cxgo.Define{Name: name, Value: val})
+		}
+		ff, err := cxgo.TranslateFlow("", in, fnc, env, fc)
+		if err != nil {
+			return err
+		}
+		if ff.Structured {
+			log.Printf("%s: control flow is reducible, flattening rebuilds loops and branches", fnc)
+		} else {
+			log.Printf("%s: control flow is irreducible, flattening uses numbered labels", fnc)
+		}
+		return writeFlow(*fOut, ff, *fFormat)
+	}
+}
+
+// writeFlow writes control flow graphs of a function in given formats.
+func writeFlow(out string, ff *cxgo.FuncFlow, formats []string) error {
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	graphs := []struct {
+		suffix string
+		dot    string
+	}{
+		{"", ff.Flow.Dot()},
+		{"_dom", ff.Flow.DomDot()},
+		{"_flat", ff.Flat.Dot()},
+	}
+	for _, f := range formats {
+		switch f {
+		case "dot":
+			for _, g := range graphs {
+				if err := writeOut(out, ff.Name+g.suffix+".dot", []byte(g.dot)); err != nil {
+					return err
+				}
+			}
+		case "svg":
+			for _, g := range graphs {
+				svg, err := renderSVG(g.dot)
+				if err != nil {
+					return err
+				}
+				if err = writeOut(out, ff.Name+g.suffix+".svg", svg); err != nil {
+					return err
+				}
+			}
+		case "json":
+			data, err := json.MarshalIndent(struct {
+				Name       string          `json:"name"`
+				Structured bool            `json:"structured"`
+				Flow       *cxgo.FlowGraph `json:"flow"`
+				Flat       *cxgo.FlowGraph `json:"flat"`
+			}{
+				Name:       ff.Name,
+				Structured: ff.Structured,
+				Flow:       ff.Flow.Graph(),
+				Flat:       ff.Flat.Graph(),
+			}, "", "\t")
+			if err != nil {
+				return err
+			}
+			if err = writeOut(out, ff.Name+".json", data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeOut(dir, name string, data []byte) error {
+	path := filepath.Join(dir, name)
+	log.Println(path)
+	return os.WriteFile(path, data, 0644)
+}
+
+// renderSVG renders a DOT graph with Graphviz.
+func renderSVG(dot string) ([]byte, error) {
+	cmd := exec.Command("dot", "-Tsvg")
+	cmd.Stdin = strings.NewReader(dot)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	svg, err := cmd.Output()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		return nil, fmt.Errorf("graphviz failed: %w: %s", err, msg)
+	} else if err != nil {
+		return nil, fmt.Errorf("graphviz failed: %w", err)
+	}
+	return svg, nil
+}
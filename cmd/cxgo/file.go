@@ -23,6 +23,7 @@ func init() {
 	fPkg := cmdFile.Flags().StringP("pkg", "p", "main", "package name for a Go file")
 	fExportFields := cmdFile.Flags().Bool("export-fields", false, "export struct fields")
 	fDoNotEdit := cmdFile.Flags().Bool("donotedit", false, "add DO NOT EDIT comment header")
+	fTarget := cmdFile.Flags().String("target", "", "target platform ("+strings.Join(types.Targets(), ", ")+")")
 	cmdFile.RunE = func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			return errors.New("exactly one file must be specified")
@@ -32,9 +33,17 @@ func init() {
 		if out == "" {
 			out = strings.TrimSuffix(in, filepath.Ext(in)) + ".go"
 		}
-		env := libs.NewEnv(types.Config{
+		tconf := types.Config{
 			UseGoInt: true,
-		})
+		}
+		if *fTarget != "" {
+			var err error
+			tconf, err = types.ConfigForTarget(*fTarget)
+			if err != nil {
+				return err
+			}
+		}
+		env := libs.NewEnv(tconf)
 		fc := cxgo.Config{
 			Package:          *fPkg,
 			GoFile:           filepath.Base(out),
@@ -110,6 +110,14 @@ type File struct {
 	Replace     []Replacement      `yaml:"replace"`
 }
 
+type Platform struct {
+	Name   string        `yaml:"name"`
+	Build  string        `yaml:"build"`
+	Target string        `yaml:"target"`
+	Define []cxgo.Define `yaml:"define"`
+	Undef  []string      `yaml:"undef"`
+}
+
 type Config struct {
 	VCS        string            `yaml:"vcs"`
 	Branch     string            `yaml:"branch"`
@@ -123,11 +131,23 @@ type Config struct {
 	Define     []cxgo.Define     `yaml:"define"`
 	Predef     string            `yaml:"predef"`
 	SubPackage bool              `yaml:"subpackage"`
+	Packages   bool              `yaml:"packages"`
+	Module     string            `yaml:"module"`
+	Platforms  []Platform        `yaml:"platforms"`
+	Switches   []cxgo.Switch     `yaml:"switches"`
 
-	IntSize   int  `yaml:"int_size"`
-	PtrSize   int  `yaml:"ptr_size"`
-	WcharSize int  `yaml:"wchar_size"`
-	UseGoInt  bool `yaml:"use_go_int"`
+	MergeStructs        bool `yaml:"merge_structs"`
+	ExportPrivateFields bool `yaml:"export_private_fields"`
+	InferSlices         bool `yaml:"infer_slices"`
+	MacroFuncs          bool `yaml:"macro_funcs"`
+	RenameStatics       bool `yaml:"rename_statics"`
+
+	Target    string `yaml:"target"`
+	IntSize   int    `yaml:"int_size"`
+	PtrSize   int    `yaml:"ptr_size"`
+	LongSize  int    `yaml:"long_size"`
+	WcharSize int    `yaml:"wchar_size"`
+	UseGoInt  bool   `yaml:"use_go_int"`
 
 	ForwardDecl      bool               `yaml:"forward_decl"`
 	FlattenAll       bool               `yaml:"flatten_all"`
@@ -218,18 +238,59 @@ func Run(root string, c *Config) error {
 	if err := os.MkdirAll(c.Out, 0755); err != nil {
 		return err
 	}
-	tconf := types.Default()
-	if c.UseGoInt {
-		tconf.UseGoInt = c.UseGoInt
+	typesConfig := func(target string) (types.Config, error) {
+		tconf := types.Default()
+		if target != "" {
+			var err error
+			tconf, err = types.ConfigForTarget(target)
+			if err != nil {
+				return tconf, err
+			}
+		}
+		if c.UseGoInt {
+			tconf.UseGoInt = c.UseGoInt
+		}
+		if c.IntSize != 0 {
+			tconf.IntSize = c.IntSize
+		}
+		if c.PtrSize != 0 {
+			tconf.PtrSize = c.PtrSize
+		}
+		if c.LongSize != 0 {
+			tconf.LongSize = c.LongSize
+		}
+		if c.WcharSize != 0 {
+			tconf.WCharSize = c.WcharSize
+		}
+		return tconf, nil
 	}
-	if c.IntSize != 0 {
-		tconf.IntSize = c.IntSize
+	tconf, err := typesConfig(c.Target)
+	if err != nil {
+		return err
 	}
-	if c.PtrSize != 0 {
-		tconf.PtrSize = c.PtrSize
+	newEnv := func(tconf types.Config) *libs.Env {
+		env := libs.NewEnv(tconf)
+		env.NoLibs = c.NoLibs
+		env.Map = c.IncludeMap
+		return env
 	}
-	if c.WcharSize != 0 {
-		tconf.WCharSize = c.WcharSize
+	var plats []cxgo.Platform
+	for _, p := range c.Platforms {
+		target := p.Target
+		if target == "" {
+			target = c.Target
+		}
+		ptconf, err := typesConfig(target)
+		if err != nil {
+			return fmt.Errorf("platform %q: %w", p.Name, err)
+		}
+		plats = append(plats, cxgo.Platform{
+			Name:   p.Name,
+			Build:  p.Build,
+			Env:    newEnv(ptconf),
+			Define: p.Define,
+			Undef:  p.Undef,
+		})
 	}
 	for i := range c.Include {
 		if filepath.IsAbs(c.Include[i]) {
@@ -243,25 +304,40 @@ func Run(root string, c *Config) error {
 		}
 		c.SysInclude[i] = filepath.Join(c.Root, c.SysInclude[i])
 	}
-	seen := make(map[string]struct{})
-	processFile := func(f *File) error {
-		if _, ok := seen[f.Name]; ok {
-			return fmt.Errorf("duplicate entry for file: %q", f.Name)
-		}
-		seen[f.Name] = struct{}{}
-		if base, ok := strings.CutSuffix(f.Name, ".h"); ok {
-			if _, ok := seen[base+".c"]; ok {
-				log.Println("skipping", f.Name) // Already included declarations from it.
-				return nil
-			}
+	module := c.Module
+	if module == "" {
+		module = c.Package
+	}
+	var pkgs *cxgo.Packages
+	if c.Packages {
+		var err error
+		pkgs, err = cxgo.NewPackages(c.Root, module, c.Package)
+		if err != nil {
+			return err
 		}
-		if f.Content != "" {
-			data := []byte(f.Content)
-			if fdata, err := format.Source(data); err == nil {
-				data = fdata
-			}
-			return os.WriteFile(filepath.Join(c.Out, f.Name), data, 0644)
+	}
+	var structs *cxgo.Structs
+	if c.MergeStructs {
+		structs = cxgo.NewStructs()
+		structs.ExportPrivate = c.ExportPrivateFields
+	}
+	var slices *cxgo.Slices
+	if c.InferSlices {
+		slices = cxgo.NewSlices()
+	}
+	var macroFuncs *cxgo.MacroFuncs
+	if c.MacroFuncs {
+		macroFuncs = cxgo.NewMacroFuncs()
+	}
+	var statics *cxgo.Statics
+	if c.RenameStatics {
+		var err error
+		statics, err = cxgo.NewStatics(c.Root)
+		if err != nil {
+			return err
 		}
+	}
+	fileConfig := func(f *File) (*libs.Env, cxgo.Config, error) {
 		idents := make(map[string]cxgo.IdentConfig)
 		for _, v := range c.Idents {
 			idents[v.Name] = v
@@ -274,7 +350,7 @@ func Run(root string, c *Config) error {
 			ilist = append(ilist, v)
 		}
 
-		env := libs.NewEnv(tconf)
+		env := newEnv(tconf)
 		fc := cxgo.Config{
 			Root:               c.Root,
 			Package:            c.Package,
@@ -285,6 +361,7 @@ func Run(root string, c *Config) error {
 			MaxDecls:           -1,
 			Hooks:              c.Hooks,
 			Define:             c.Define,
+			Switches:           c.Switches,
 			Predef:             f.Predef,
 			Idents:             ilist,
 			Include:            c.Include,
@@ -296,9 +373,12 @@ func Run(root string, c *Config) error {
 			IntReformat:        c.IntReformat,
 			KeepFree:           c.KeepFree,
 			DoNotEdit:          c.DoNotEdit,
+			Packages:           pkgs,
+			Structs:            structs,
+			Slices:             slices,
+			Statics:            statics,
+			MacroFuncs:         macroFuncs,
 		}
-		env.NoLibs = c.NoLibs
-		env.Map = c.IncludeMap
 		if f.MaxDecls > 0 {
 			fc.MaxDecls = f.MaxDecls
 		}
@@ -308,14 +388,14 @@ func Run(root string, c *Config) error {
 		for _, r := range f.Replace {
 			rp, err := r.Build()
 			if err != nil {
-				return err
+				return nil, fc, err
 			}
 			fc.Replace = append(fc.Replace, *rp)
 		}
 		for _, r := range c.Replace {
 			rp, err := r.Build()
 			if err != nil {
-				return err
+				return nil, fc, err
 			}
 			fc.Replace = append(fc.Replace, *rp)
 		}
@@ -325,7 +405,35 @@ func Run(root string, c *Config) error {
 				fc.SkipDecl[s] = true
 			}
 		}
+		return env, fc, nil
+	}
+	seen := make(map[string]struct{})
+	processFile := func(f *File) error {
+		if _, ok := seen[f.Name]; ok {
+			return fmt.Errorf("duplicate entry for file: %q", f.Name)
+		}
+		seen[f.Name] = struct{}{}
+		if base, ok := strings.CutSuffix(f.Name, ".h"); ok {
+			if _, ok := seen[base+".c"]; ok {
+				log.Println("skipping", f.Name) // Already included declarations from it.
+				return nil
+			}
+		}
+		if f.Content != "" {
+			data := []byte(f.Content)
+			if fdata, err := format.Source(data); err == nil {
+				data = fdata
+			}
+			return os.WriteFile(filepath.Join(c.Out, f.Name), data, 0644)
+		}
+		env, fc, err := fileConfig(f)
+		if err != nil {
+			return err
+		}
 		log.Println(f.Name)
+		if len(plats) != 0 {
+			return cxgo.TranslatePlatforms(c.Root, filepath.Join(c.Root, f.Name), c.Out, plats, fc)
+		}
 		if err := cxgo.Translate(c.Root, filepath.Join(c.Root, f.Name), c.Out, env, fc); err != nil {
 			return err
 		}
@@ -334,37 +442,84 @@ func Run(root string, c *Config) error {
 	if err := runCmd(c.Root, c.ExecBefore); err != nil {
 		return err
 	}
+	// expand globs, so the list can be indexed before the translation
+	var (
+		files  []*File
+		listed = make(map[string]struct{})
+	)
 	for _, f := range c.Files {
 		if f.Disabled {
 			seen[f.Name] = struct{}{}
+			listed[f.Name] = struct{}{}
 			continue
 		}
-		if strings.Contains(f.Name, "*") {
-			paths, err := doublestar.Glob(filepath.Join(c.Root, f.Name))
+		if !strings.Contains(f.Name, "*") {
+			files = append(files, f)
+			listed[f.Name] = struct{}{}
+			continue
+		}
+		paths, err := doublestar.Glob(filepath.Join(c.Root, f.Name))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			rel, err := filepath.Rel(c.Root, path)
 			if err != nil {
-				return err
+				return fmt.Errorf("%s: %w", path, err)
 			}
-			for _, path := range paths {
-				rel, err := filepath.Rel(c.Root, path)
-				if err != nil {
-					return fmt.Errorf("%s: %w", path, err)
-				}
-				if _, ok := seen[rel]; ok {
-					continue
-				} else if _, ok = seen["./"+rel]; ok {
-					continue
-				}
-				f2 := *f
-				f2.Name = rel
-				if err := processFile(&f2); err != nil {
-					return fmt.Errorf("%s: %w", path, err)
-				}
+			if _, ok := listed[rel]; ok {
+				continue
+			} else if _, ok = listed["./"+rel]; ok {
+				continue
 			}
-		} else {
-			if err := processFile(f); err != nil {
+			f2 := *f
+			f2.Name = rel
+			files = append(files, &f2)
+			listed[rel] = struct{}{}
+		}
+	}
+	if pkgs != nil || structs != nil || slices != nil || statics != nil {
+		for _, f := range files {
+			if f.Content != "" {
+				continue
+			}
+			env, fc, err := fileConfig(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			if err = cxgo.IndexFile(filepath.Join(c.Root, f.Name), env, fc); err != nil {
 				return fmt.Errorf("%s: %w", f.Name, err)
 			}
 		}
+		if slices != nil {
+			for _, fb := range slices.Fallbacks() {
+				log.Printf("cannot convert to slice: %s", fb)
+			}
+		}
+	}
+	if pkgs != nil {
+		if err := pkgs.Check(); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := processFile(f); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	if macroFuncs != nil {
+		for _, s := range macroFuncs.Skipped() {
+			log.Printf("skipping function-like macro: %s", s)
+		}
+	}
+	if len(c.Switches) != 0 {
+		env, fc, err := fileConfig(&File{})
+		if err != nil {
+			return err
+		}
+		if err = cxgo.WriteSwitches(c.Out, env, fc); err != nil {
+			return err
+		}
 	}
 	if !c.SubPackage {
 		if _, err := os.Stat(filepath.Join(c.Out, "go.mod")); os.IsNotExist(err) {
@@ -376,7 +531,7 @@ go 1.20
 require (
 	%s %s
 )
-`, c.Package, libs.RuntimePackage, libs.RuntimePackageVers)
+`, module, libs.RuntimePackage, libs.RuntimePackageVers)
 			if err := os.WriteFile(filepath.Join(c.Out, "go.mod"), buf.Bytes(), 0644); err != nil {
 				return err
 			}
@@ -0,0 +1,147 @@
+package cxgo
+
+import (
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// firstToken returns the first token of the node in the source order.
+func firstToken(n cc.Node) *cc.Token {
+	var first *cc.Token
+	cc.Inspect(n, func(n cc.Node, _ bool) bool {
+		if t, ok := n.(*cc.Token); ok && t.Rune != 0 {
+			if first == nil || t.Seq() < first.Seq() {
+				first = t
+			}
+		}
+		return true
+	})
+	return first
+}
+
+// docComment returns a doc comment preceding the node, converted to Go comment lines.
+func docComment(n cc.Node) []string {
+	t := firstToken(n)
+	if t == nil {
+		return nil
+	}
+	return parseDocComment(t.Sep.String())
+}
+
+// setDoc sets a doc comment for the declaration of the identifier.
+func (g *translator) setDoc(id *types.Ident, n cc.Node) {
+	if id == nil || n == nil {
+		return
+	}
+	if eid, ok := g.env.IdentByName(id.Name); ok && eid == id {
+		return // do not change library identifiers
+	}
+	if doc := docComment(n); len(doc) != 0 {
+		id.Doc = doc
+	}
+}
+
+// fieldDecl returns the struct field declaration for the first declarator in it.
+// Other declarators in the same declaration (int a, b;) are not documented.
+func (g *translator) fieldDecl(f cc.Field) cc.Node {
+	sd := f.Declarator()
+	if sd == nil || g.file == nil {
+		return nil
+	}
+	if g.fieldDecls == nil {
+		g.fieldDecls = make(map[*cc.StructDeclarator]*cc.StructDeclaration)
+		cc.Inspect(g.file.TranslationUnit, func(n cc.Node, pre bool) bool {
+			if d, ok := n.(*cc.StructDeclaration); ok && pre && d.StructDeclaratorList != nil {
+				g.fieldDecls[d.StructDeclaratorList.StructDeclarator] = d
+			}
+			return true
+		})
+	}
+	if d, ok := g.fieldDecls[sd]; ok {
+		return d
+	}
+	return nil
+}
+
+// parseDocComment extracts the doc comment from the white space preceding a token.
+//
+// Only the last group of comments is considered a doc comment, and only if there are no blank lines between it
+// and the token. Comments on the same line as the previous token are skipped, since they belong to it.
+func parseDocComment(sep string) []string {
+	var (
+		doc     []string
+		newline = false // seen a newline since the previous token
+		blank   = true  // current line has only white space
+	)
+	for len(sep) != 0 {
+		switch {
+		case strings.HasPrefix(sep, "//"):
+			i := strings.IndexByte(sep, '\n')
+			if i < 0 {
+				i = len(sep)
+			}
+			if newline {
+				doc = append(doc, strings.TrimRight(sep[:i], " \t\r"))
+			}
+			sep = sep[i:]
+			blank = false
+		case strings.HasPrefix(sep, "/*"):
+			i := strings.Index(sep, "*/")
+			if i < 0 {
+				i = len(sep)
+			} else {
+				i += 2
+			}
+			if newline {
+				doc = append(doc, blockCommentLines(sep[:i])...)
+			}
+			sep = sep[i:]
+			blank = false
+		case sep[0] == '\n':
+			if newline && blank {
+				// blank line separates comments from the declaration
+				doc = nil
+			}
+			newline, blank = true, true
+			sep = sep[1:]
+		default:
+			sep = sep[1:]
+		}
+	}
+	return doc
+}
+
+// blockCommentLines converts C block comment to Go line comments.
+func blockCommentLines(c string) []string {
+	first := strings.HasPrefix(c, "/**") // doc comment style: the first line is decorated as well
+	c = strings.TrimPrefix(c, "/*")
+	c = strings.TrimSuffix(c, "*/")
+	lines := strings.Split(c, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i != 0 || first {
+			// strip the decoration of multi-line comments
+			line = strings.TrimPrefix(line, "*")
+		}
+		lines[i] = strings.TrimRight(line, " \t\r*")
+	}
+	for len(lines) != 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) != 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			out = append(out, "//")
+		} else {
+			out = append(out, "// "+line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package cxgo
+
+import "testing"
+
+var casesTranslateComments = []parseCase{
+	{
+		name: "comment func",
+		src: `
+// add adds two numbers.
+// It never overflows.
+int add(int a, int b) {
+	// not a doc comment
+	return a + b;
+}
+`,
+		exp: `
+// add adds two numbers.
+// It never overflows.
+func add(a int32, b int32) int32 {
+	return a + b
+}
+`,
+	},
+	{
+		name: "comment struct",
+		src: `
+/* Point is a point.
+ * It has coordinates. */
+typedef struct {
+	// X coordinate.
+	int x;
+	int y; // not a doc comment
+} Point;
+`,
+		exp: `
+// Point is a point.
+// It has coordinates.
+type Point struct {
+	// X coordinate.
+	X int32
+	Y int32
+}
+`,
+	},
+	{
+		name: "comment enum",
+		src: `
+// Color is a color.
+enum Color {
+	// Red color.
+	RED,
+	GREEN,
+};
+`,
+		exp: `
+// Color is a color.
+type Color int32
+
+const (
+	// Red color.
+	RED = Color(iota)
+	GREEN
+)
+`,
+	},
+	{
+		name: "comment var",
+		src: `
+int a; // not a doc comment
+
+// not a doc comment, since separated by a blank line
+
+/* Counter is a global. */
+int counter = 1;
+`,
+		exp: `
+var a int32
+
+// Counter is a global.
+var counter int32 = 1
+`,
+	},
+	{
+		name: "comment tagged struct",
+		src: `
+// Node is a list node.
+struct Node {
+	// Next node in the list.
+	struct Node* next;
+};
+
+// Pair is a pair.
+struct Pair {
+	int a;
+} pair;
+`,
+		exp: `
+// Node is a list node.
+type Node struct {
+	// Next node in the list.
+	Next *Node
+}
+
+// Pair is a pair.
+type Pair struct {
+	A int32
+}
+
+var pair Pair
+`,
+	},
+	{
+		name: "comment doc block",
+		src: `
+/** Color is a color. */
+enum Color {
+	/**
+	 * Red color.
+	 */
+	RED,
+	GREEN,
+};
+`,
+		exp: `
+// Color is a color.
+type Color int32
+
+const (
+	// Red color.
+	RED = Color(iota)
+	GREEN
+)
+`,
+	},
+	{
+		name: "comment multi-line block",
+		src: `
+/*
+ * scale multiplies a value.
+ *
+ * The factor must be positive.
+ */
+int scale(int v, int f) {
+	return v * f;
+}
+`,
+		exp: `
+// scale multiplies a value.
+//
+// The factor must be positive.
+func scale(v int32, f int32) int32 {
+	return v * f
+}
+`,
+	},
+}
+
+func TestTranslateComments(t *testing.T) {
+	runTestTranslate(t, casesTranslateComments)
+}
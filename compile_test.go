@@ -58,6 +58,7 @@ func goTranspileAndExec(t testing.TB, cxgo, dir string, cfile string) progOut {
 		GoFile:      gofile,
 		MaxDecls:    -1,
 		ForwardDecl: false,
+		MacroFuncs:  NewMacroFuncs(),
 	})
 	require.NoError(t, err)
 	goProjectMod(t, dir)
@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"modernc.org/cc/v3"
@@ -29,11 +30,23 @@ func (g *translator) convMacro(name string, fnc func() Expr) Expr {
 	}
 	x := fnc()
 	typ := x.CType(nil)
-	id = g.newIdent(name, typ)
+	id = g.newMacroIdent(name, typ)
 	g.macros[name] = id
 	return IdentExpr{id}
 }
 
+// newMacroIdent creates an identifier for a constant generated from a macro.
+func (g *translator) newMacroIdent(name string, t types.Type) *types.Ident {
+	id := g.newIdent(name, t)
+	if lib, ok := g.env.IdentByName(name); ok && lib == id {
+		return id
+	}
+	if c, ok := g.idents[name]; ok && c.Rename != "" {
+		id.GoName = c.Rename
+	}
+	return id
+}
+
 func (g *translator) convertIdent(scope cc.Scope, tok cc.Token, t types.Type) IdentExpr {
 	var decl []cc.Node
 	for len(scope) != 0 {
@@ -72,6 +85,16 @@ func (g *translator) replaceIdentWith(id *types.Ident, decls ...cc.Node) {
 }
 
 func (g *translator) tryConvertIdentOn(t types.Type, tok cc.Token) (*types.Ident, bool) {
+	path, ok := g.tryConvertFieldPath(t, tok)
+	if !ok {
+		return nil, false
+	}
+	return path[len(path)-1], true
+}
+
+// tryConvertFieldPath finds a field by name and returns a path to it.
+// The path contains more than one element if the field is a member of an anonymous struct or union.
+func (g *translator) tryConvertFieldPath(t types.Type, tok cc.Token) ([]*types.Ident, bool) {
 loop:
 	for {
 		switch s := t.(type) {
@@ -90,11 +113,14 @@ loop:
 		name := tok.Value.String()
 		for _, f := range t.Fields() {
 			if name == f.Name.Name {
-				return f.Name, true
+				return []*types.Ident{f.Name}, true
 			}
 			if f.Name.Name == "" {
-				if id, ok := g.tryConvertIdentOn(f.Type(), tok); ok {
-					return id, true
+				if path, ok := g.tryConvertFieldPath(f.Type(), tok); ok {
+					if f.Name.IsUnnamed() {
+						return path, true
+					}
+					return append([]*types.Ident{f.Name}, path...), true
 				}
 			}
 		}
@@ -110,6 +136,22 @@ func (g *translator) convertIdentOn(t types.Type, tok cc.Token) *types.Ident {
 	panic(fmt.Errorf("%#v.%q (%s)", t, tok.Value.String(), tok.Position()))
 }
 
+func (g *translator) convertFieldPath(t types.Type, tok cc.Token) []*types.Ident {
+	path, ok := g.tryConvertFieldPath(t, tok)
+	if ok {
+		return path
+	}
+	panic(fmt.Errorf("%#v.%q (%s)", t, tok.Value.String(), tok.Position()))
+}
+
+// newCSelectPath selects a field by following the path returned by convertFieldPath.
+func newCSelectPath(x Expr, path []*types.Ident) Expr {
+	for _, f := range path {
+		x = NewCSelectExpr(x, f)
+	}
+	return x
+}
+
 func (g *translator) convertFuncDef(d *cc.FunctionDefinition) []CDecl {
 	decl := d.Declarator
 	switch dd := decl.DirectDeclarator; dd.Case {
@@ -117,15 +159,23 @@ func (g *translator) convertFuncDef(d *cc.FunctionDefinition) []CDecl {
 		sname := decl.Name().String()
 		conf := g.idents[sname]
 		ft := g.convertFuncType(conf, decl, decl.Type(), decl.Position())
+		if name, ok := strings.CutPrefix(sname, macroFuncPrefix); ok {
+			return g.convertMacroFunc(name, d, ft)
+		}
 		if !g.inCurFile(d) {
 			return nil
 		}
 		name := g.convertIdentWith(sname, ft, decl)
+		g.setDoc(name.Ident, d)
+		prev := g.curFunc
+		g.curFunc = name.Ident
+		body := g.convertCompBlockStmt(d.CompoundStatement).In(ft)
+		g.curFunc = prev
 		return []CDecl{
 			&CFuncDecl{
 				Name: name.Ident,
 				Type: ft,
-				Body: g.convertCompBlockStmt(d.CompoundStatement).In(ft),
+				Body: body,
 				Range: &Range{
 					Start:     d.Position().Offset,
 					StartLine: d.Position().Line,
@@ -286,7 +336,9 @@ func (g *translator) convertEnum(b *cc.Declaration, typ types.Type, d *cc.EnumSp
 				next = l.Int() + 1
 			}
 		}
-		vd.Names = append(vd.Names, g.convertIdentWith(e.Token.Value.String(), typ, e).Ident)
+		name := g.convertIdentWith(e.Token.Value.String(), typ, e).Ident
+		g.setDoc(name, e)
+		vd.Names = append(vd.Names, name)
 	}
 	if len(vd.Names) == 0 {
 		return nil
@@ -416,7 +468,6 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 			panic(sp.Case.String() + " " + sp.Position().String())
 		}
 	}
-	_ = isStatic // FIXME: static
 	_ = isVolatile
 	_ = isAuto // FIXME: auto
 	var decls []CDecl
@@ -444,6 +495,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				return und
 			})
 			typ = nt
+			g.setDoc(nt.Name(), d)
 			decls = append(decls, &CTypeDef{nt})
 		} else if d.InitDeclaratorList != nil {
 			hasOtherDecls = true
@@ -452,6 +504,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				return g.env.DefIntT()
 			})
 			typ = nt
+			g.setDoc(nt.Name(), d)
 			decls = append(decls, &CTypeDef{nt})
 		}
 		if !hasOtherDecls {
@@ -491,6 +544,9 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 				panic(fmt.Errorf("declaration of unnamed type: %T", typeSpec))
 			}
 		}
+		if !isForward {
+			g.setDoc(nt.Name(), d)
+		}
 		decls = append(decls, &CTypeDef{nt})
 		return decls
 	}
@@ -503,8 +559,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 		switch id.Case {
 		case cc.InitDeclaratorDecl, cc.InitDeclaratorInit:
 			dd := id.Declarator
-			dname := dd.Name().String()
-			conf := g.idents[dname]
+			conf := g.declConf(dd)
 			vt := g.convertTypeRootOpt(conf, dd.Type(), id.Position())
 			if isTypedef && vt == nil {
 				vt = types.StructT(nil)
@@ -543,10 +598,21 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 						continue
 					}
 				}
+				if il == d.InitDeclaratorList {
+					g.setDoc(nt.Name(), d)
+				}
 				decls = append(decls, &CTypeDef{nt})
 				continue
 			}
 			name := g.convertIdentWith(dd.NameTok().String(), vt, dd)
+			if il == d.InitDeclaratorList {
+				if nt, ok := typeSpec.(types.Named); ok && !isForward {
+					// struct Foo {...} foo; the comment documents the struct
+					g.setDoc(nt.Name(), d)
+				} else {
+					g.setDoc(name.Ident, d)
+				}
+			}
 			isDecl := false
 			for di := dd.DirectDeclarator; di != nil; di = di.DirectDeclarator {
 				if di.Case == cc.DirectDeclaratorDecl {
@@ -590,7 +656,7 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 					if init != nil {
 						inits = []Expr{init}
 					}
-					decls = append(decls, &CVarDecl{
+					vd := &CVarDecl{
 						// There is no real const in C
 						Const: false, // Const: isConst,
 						CVarSpec: CVarSpec{
@@ -599,7 +665,15 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 							Names: []*types.Ident{name.Ident},
 							Inits: inits,
 						},
-					})
+					}
+					if isStatic && g.curFunc != nil && name.Name != "__func__" {
+						// static local keeps its value between calls, thus it becomes a global variable
+						name.Ident.GoName = g.staticLocalName(name.Ident)
+						g.hoisted = append(g.hoisted, vd)
+						skipped++
+					} else {
+						decls = append(decls, vd)
+					}
 				} else {
 					skipped++
 				}
@@ -617,6 +691,22 @@ func (g *translator) convertDecl(d *cc.Declaration) []CDecl {
 	return decls
 }
 
+// staticLocalName returns a unique Go name for a static variable declared in the current function.
+// The name must not collide with package-level symbols, including the ones defined in other files, see Statics.
+func (g *translator) staticLocalName(id *types.Ident) string {
+	base := g.curFunc.String() + "_" + id.String()
+	name := base
+	for i := 2; ; i++ {
+		_, used := g.staticLocals[name]
+		if !used && g.file.Scope[cc.String(name)] == nil && (g.conf.Statics == nil || !g.conf.Statics.declared(g.path, name)) {
+			break
+		}
+		name = base + strconv.Itoa(i)
+	}
+	g.staticLocals[name] = struct{}{}
+	return name
+}
+
 func (g *translator) convertCompStmt(d *cc.CompoundStatement) []CStmt {
 	var stmts []CStmt
 	for it := d.BlockItemList; it != nil; it = it.BlockItemList {
@@ -674,23 +764,26 @@ func (g *translator) convertMulExpr(d *cc.MultiplicativeExpression) Expr {
 	case cc.MultiplicativeExpressionCast:
 		return g.convertCastExpr(d.CastExpression)
 	}
-	x := g.convertMulExpr(d.MultiplicativeExpression)
-	y := g.convertCastExpr(d.CastExpression)
-	var op BinaryOp
-	switch d.Case {
-	case cc.MultiplicativeExpressionMul:
-		op = BinOpMult
-	case cc.MultiplicativeExpressionDiv:
-		op = BinOpDiv
-	case cc.MultiplicativeExpressionMod:
-		op = BinOpMod
-	default:
-		panic(d.Case.String())
+	fnc := func() Expr {
+		x := g.convertMulExpr(d.MultiplicativeExpression)
+		y := g.convertCastExpr(d.CastExpression)
+		var op BinaryOp
+		switch d.Case {
+		case cc.MultiplicativeExpressionMul:
+			op = BinOpMult
+		case cc.MultiplicativeExpressionDiv:
+			op = BinOpDiv
+		case cc.MultiplicativeExpressionMod:
+			op = BinOpMod
+		default:
+			panic(d.Case.String())
+		}
+		return g.NewCBinaryExprT(
+			x, op, y,
+			g.convertTypeOper(d.Operand, d.Position()),
+		)
 	}
-	return g.NewCBinaryExprT(
-		x, op, y,
-		g.convertTypeOper(d.Operand, d.Position()),
-	)
+	return g.convMacroExpr(d, fnc)
 }
 
 func (g *translator) convertAddExpr(d *cc.AdditiveExpression) Expr {
@@ -698,21 +791,24 @@ func (g *translator) convertAddExpr(d *cc.AdditiveExpression) Expr {
 	case cc.AdditiveExpressionMul:
 		return g.convertMulExpr(d.MultiplicativeExpression)
 	}
-	x := g.convertAddExpr(d.AdditiveExpression)
-	y := g.convertMulExpr(d.MultiplicativeExpression)
-	var op BinaryOp
-	switch d.Case {
-	case cc.AdditiveExpressionAdd:
-		op = BinOpAdd
-	case cc.AdditiveExpressionSub:
-		op = BinOpSub
-	default:
-		panic(d.Case.String())
+	fnc := func() Expr {
+		x := g.convertAddExpr(d.AdditiveExpression)
+		y := g.convertMulExpr(d.MultiplicativeExpression)
+		var op BinaryOp
+		switch d.Case {
+		case cc.AdditiveExpressionAdd:
+			op = BinOpAdd
+		case cc.AdditiveExpressionSub:
+			op = BinOpSub
+		default:
+			panic(d.Case.String())
+		}
+		return g.NewCBinaryExprT(
+			x, op, y,
+			g.convertTypeOper(d.Operand, d.Position()),
+		)
 	}
-	return g.NewCBinaryExprT(
-		x, op, y,
-		g.convertTypeOper(d.Operand, d.Position()),
-	)
+	return g.convMacroExpr(d, fnc)
 }
 
 func (g *translator) convertShiftExpr(d *cc.ShiftExpression) Expr {
@@ -720,21 +816,24 @@ func (g *translator) convertShiftExpr(d *cc.ShiftExpression) Expr {
 	case cc.ShiftExpressionAdd:
 		return g.convertAddExpr(d.AdditiveExpression)
 	}
-	x := g.convertShiftExpr(d.ShiftExpression)
-	y := g.convertAddExpr(d.AdditiveExpression)
-	var op BinaryOp
-	switch d.Case {
-	case cc.ShiftExpressionLsh:
-		op = BinOpLsh
-	case cc.ShiftExpressionRsh:
-		op = BinOpRsh
-	default:
-		panic(d.Case.String())
+	fnc := func() Expr {
+		x := g.convertShiftExpr(d.ShiftExpression)
+		y := g.convertAddExpr(d.AdditiveExpression)
+		var op BinaryOp
+		switch d.Case {
+		case cc.ShiftExpressionLsh:
+			op = BinOpLsh
+		case cc.ShiftExpressionRsh:
+			op = BinOpRsh
+		default:
+			panic(d.Case.String())
+		}
+		return g.NewCBinaryExprT(
+			x, op, y,
+			g.convertTypeOper(d.Operand, d.Position()),
+		)
 	}
-	return g.NewCBinaryExprT(
-		x, op, y,
-		g.convertTypeOper(d.Operand, d.Position()),
-	)
+	return g.convMacroExpr(d, fnc)
 }
 
 func (g *translator) convertRelExpr(d *cc.RelationalExpression) Expr {
@@ -784,12 +883,14 @@ func (g *translator) convertAndExpr(d *cc.AndExpression) Expr {
 	case cc.AndExpressionEq:
 		return g.convertEqExpr(d.EqualityExpression)
 	case cc.AndExpressionAnd:
-		x := g.convertAndExpr(d.AndExpression)
-		y := g.convertEqExpr(d.EqualityExpression)
-		return g.NewCBinaryExprT(
-			x, BinOpBitAnd, y,
-			g.convertTypeOper(d.Operand, d.Position()),
-		)
+		return g.convMacroExpr(d, func() Expr {
+			x := g.convertAndExpr(d.AndExpression)
+			y := g.convertEqExpr(d.EqualityExpression)
+			return g.NewCBinaryExprT(
+				x, BinOpBitAnd, y,
+				g.convertTypeOper(d.Operand, d.Position()),
+			)
+		})
 	default:
 		panic(d.Case.String())
 	}
@@ -800,12 +901,14 @@ func (g *translator) convertLOrExcExpr(d *cc.ExclusiveOrExpression) Expr {
 	case cc.ExclusiveOrExpressionAnd:
 		return g.convertAndExpr(d.AndExpression)
 	case cc.ExclusiveOrExpressionXor:
-		x := g.convertLOrExcExpr(d.ExclusiveOrExpression)
-		y := g.convertAndExpr(d.AndExpression)
-		return g.NewCBinaryExprT(
-			x, BinOpBitXor, y,
-			g.convertTypeOper(d.Operand, d.Position()),
-		)
+		return g.convMacroExpr(d, func() Expr {
+			x := g.convertLOrExcExpr(d.ExclusiveOrExpression)
+			y := g.convertAndExpr(d.AndExpression)
+			return g.NewCBinaryExprT(
+				x, BinOpBitXor, y,
+				g.convertTypeOper(d.Operand, d.Position()),
+			)
+		})
 	default:
 		panic(d.Case.String())
 	}
@@ -816,12 +919,14 @@ func (g *translator) convertLOrIncExpr(d *cc.InclusiveOrExpression) Expr {
 	case cc.InclusiveOrExpressionXor:
 		return g.convertLOrExcExpr(d.ExclusiveOrExpression)
 	case cc.InclusiveOrExpressionOr:
-		x := g.convertLOrIncExpr(d.InclusiveOrExpression)
-		y := g.convertLOrExcExpr(d.ExclusiveOrExpression)
-		return g.NewCBinaryExprT(
-			x, BinOpBitOr, y,
-			g.convertTypeOper(d.Operand, d.Position()),
-		)
+		return g.convMacroExpr(d, func() Expr {
+			x := g.convertLOrIncExpr(d.InclusiveOrExpression)
+			y := g.convertLOrExcExpr(d.ExclusiveOrExpression)
+			return g.NewCBinaryExprT(
+				x, BinOpBitOr, y,
+				g.convertTypeOper(d.Operand, d.Position()),
+			)
+		})
 	default:
 		panic(d.Case.String())
 	}
@@ -958,8 +1063,10 @@ func (g *translator) convertPriExpr(d *cc.PrimaryExpression) Expr {
 		}
 		return fnc()
 	case cc.PrimaryExpressionExpr: // "(x)"
-		e := g.convertExpr(d.Expression)
-		return cParen(e)
+		return g.convMacroExpr(d, func() Expr {
+			e := g.convertExpr(d.Expression)
+			return cParen(e)
+		})
 	case cc.PrimaryExpressionStmt: // "({...; x})"
 		stmt := g.convertCompStmt(d.CompoundStatement)
 		if len(stmt) != 1 {
@@ -986,28 +1093,38 @@ func (g *translator) convertPriExpr(d *cc.PrimaryExpression) Expr {
 func (g *translator) convertOneDesignator(typ types.Type, list *cc.DesignatorList, val Expr) *CompLitField {
 	d := list.Designator
 	var (
-		f   *CompLitField
-		sub types.Type
+		f    *CompLitField
+		sub  types.Type
+		path []*types.Ident
 	)
 	switch d.Case {
 	case cc.DesignatorIndex:
 		f = &CompLitField{Index: g.convertConstExpr(d.ConstantExpression)}
 		sub = typ.(types.ArrayType).Elem()
 	case cc.DesignatorField:
-		f = &CompLitField{Field: g.convertIdentOn(typ, d.Token2)}
-		sub = f.Field.CType(nil)
+		path = g.convertFieldPath(typ, d.Token2)
 	case cc.DesignatorField2:
-		f = &CompLitField{Field: g.convertIdentOn(typ, d.Token)}
-		sub = f.Field.CType(nil)
+		path = g.convertFieldPath(typ, d.Token)
 	default:
 		panic(d.Case.String() + " " + d.Position().String())
 	}
+	if len(path) != 0 {
+		f = &CompLitField{Field: path[len(path)-1]}
+		sub = f.Field.CType(nil)
+	}
 	if list.DesignatorList == nil {
 		f.Value = val
-		return f
+	} else {
+		f2 := g.convertOneDesignator(sub, list.DesignatorList, val)
+		f.Value = g.NewCCompLitExpr(sub, []*CompLitField{f2})
+	}
+	// fields of anonymous structs and unions are initialized through the parent field
+	for i := len(path) - 2; i >= 0; i-- {
+		f = &CompLitField{
+			Field: path[i],
+			Value: g.NewCCompLitExpr(path[i].CType(nil), []*CompLitField{f}),
+		}
 	}
-	f2 := g.convertOneDesignator(sub, list.DesignatorList, val)
-	f.Value = g.NewCCompLitExpr(sub, []*CompLitField{f2})
 	return f
 }
 
@@ -1031,21 +1148,21 @@ func (g *translator) convertPostfixExpr(d *cc.PostfixExpression) Expr {
 	case cc.PostfixExpressionPSelect: // x->y
 		exp := g.convertPostfixExpr(d.PostfixExpression)
 		if _, ok := exp.CType(nil).(types.ArrayType); ok { // pointer accesses might be an array
-			return NewCSelectExpr(
+			return newCSelectPath(
 				g.NewCIndexExpr(
 					exp,
 					cUintLit(0, 10), // index the first element
 					g.convertTypeOper(d.Operand, d.Position()),
-				), g.convertIdentOn(exp.CType(nil), d.Token2),
+				), g.convertFieldPath(exp.CType(nil), d.Token2),
 			)
 		}
-		return NewCSelectExpr(
-			exp, g.convertIdentOn(exp.CType(nil), d.Token2),
+		return newCSelectPath(
+			exp, g.convertFieldPath(exp.CType(nil), d.Token2),
 		)
 	case cc.PostfixExpressionSelect: // x.y
 		exp := g.convertPostfixExpr(d.PostfixExpression)
-		return NewCSelectExpr(
-			exp, g.convertIdentOn(exp.CType(nil), d.Token2),
+		return newCSelectPath(
+			exp, g.convertFieldPath(exp.CType(nil), d.Token2),
 		)
 	case cc.PostfixExpressionInc: // x++
 		x := g.convertPostfixExpr(d.PostfixExpression)
@@ -1072,10 +1189,12 @@ func (g *translator) convertCastExpr(d *cc.CastExpression) Expr {
 		if k := d.Operand.Type().Kind(); k == cc.Invalid || k == cc.Void {
 			return x
 		}
-		return g.cCast(
-			g.convertTypeOper(d.Operand, d.Position()),
-			x,
-		)
+		return g.convMacroExpr(d, func() Expr {
+			return g.cCast(
+				g.convertTypeOper(d.Operand, d.Position()),
+				x,
+			)
+		})
 	default:
 		panic(d.Case.String())
 	}
@@ -1144,8 +1263,7 @@ func (g *translator) convertUnaryExpr(d *cc.UnaryExpression) Expr {
 	if m := d.Token.Macro(); m != 0 {
 		return g.convMacro(m.String(), fnc)
 	}
-	return fnc()
-
+	return g.convMacroExpr(d, fnc)
 }
 
 func (g *translator) convertConstExpr(d *cc.ConstantExpression) Expr {
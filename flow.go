@@ -15,6 +15,13 @@ func replacePrev(v Block, p, to Block) {
 	if v == nil {
 		return
 	}
+	if b, ok := v.(interface{ replacePrev(p, to Block) bool }); ok {
+		// BaseBlock keeps an index of predecessors
+		if !b.replacePrev(p, to) {
+			panic("not found")
+		}
+		return
+	}
 	prev := v.PrevBlocks()
 	found := false
 	for i, p2 := range prev {
@@ -58,32 +65,64 @@ type ControlFlow struct {
 	labels map[string]Block
 	breaks []Block
 	conts  []Block
-	doms   map[Block]BlockSet
+	// labels of loops in breaks and conts; empty for unlabeled loops and switches
+	breakLabels []string
+	contLabels  []string
+	doms        domTree
 }
 
-func (cf *ControlFlow) eachBlock(fnc func(b Block)) {
-	cf.eachBlockSub(cf.Start, fnc, make(BlockSet))
+// jumpTarget finds a target of a break or continue: either the innermost one, or the loop with a given label.
+func jumpTarget(targets []Block, labels []string, label string) Block {
+	if label == "" {
+		return targets[len(targets)-1]
+	}
+	for i := len(labels) - 1; i >= 0; i-- {
+		if labels[i] == label {
+			return targets[i]
+		}
+	}
+	panic("unknown loop label: " + label)
 }
 
-func (cf *ControlFlow) allBlocks() BlockSet {
-	m := make(BlockSet)
-	cf.eachBlockSub(cf.Start, nil, m)
-	return m
+func (cf *ControlFlow) eachBlock(fnc func(b Block)) {
+	cf.eachBlockSub(cf.Start, func(b, _ Block) {
+		fnc(b)
+	}, make(BlockSet))
 }
 
-func (cf *ControlFlow) eachBlockSub(b Block, fnc func(b Block), seen BlockSet) {
+// eachBlockSub walks all blocks reachable from b in depth-first preorder. The function is called with the block
+// and its parent in the depth-first tree (nil for b itself).
+func (cf *ControlFlow) eachBlockSub(b Block, fnc func(b, parent Block), seen BlockSet) {
 	if b == nil {
 		return
 	}
 	if _, ok := seen[b]; ok {
 		return
 	}
-	seen[b] = struct{}{}
-	if fnc != nil {
-		fnc(b)
+	type frame struct {
+		b    Block
+		next []Block
 	}
-	for _, b2 := range b.NextBlocks() {
-		cf.eachBlockSub(b2, fnc, seen)
+	seen[b] = struct{}{}
+	fnc(b, nil)
+	stack := []frame{{b: b, next: b.NextBlocks()}}
+	for len(stack) != 0 {
+		top := &stack[len(stack)-1]
+		if len(top.next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		b2 := top.next[0]
+		top.next = top.next[1:]
+		if b2 == nil {
+			continue
+		}
+		if _, ok := seen[b2]; ok {
+			continue
+		}
+		seen[b2] = struct{}{}
+		fnc(b2, top.b)
+		stack = append(stack, frame{b: b2, next: b2.NextBlocks()})
 	}
 }
 
@@ -125,10 +164,10 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 		return b, false
 	case *CContinueStmt:
 		_, _ = cf.process(stmts[1:], after)
-		return cf.conts[len(cf.conts)-1], false
+		return jumpTarget(cf.conts, cf.contLabels, s.Label), false
 	case *CBreakStmt:
 		_, _ = cf.process(stmts[1:], after)
-		return cf.breaks[len(cf.breaks)-1], false
+		return jumpTarget(cf.breaks, cf.breakLabels, s.Label), false
 	//case *CFallthroughStmt:
 	//	_, _ = cf.process(stmts[1:], after)
 	//	return cf.falls[len(cf.falls)-1], false
@@ -152,11 +191,15 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 		ci := len(cf.conts)
 		cf.breaks = append(cf.breaks, brk)
 		cf.conts = append(cf.conts, cont)
+		cf.breakLabels = append(cf.breakLabels, s.Label)
+		cf.contLabels = append(cf.contLabels, s.Label)
 		// process the body assuming those break/continue blocks
 		body, _ := cf.process(s.Body.Stmts, cont)
 		// restore the break/continue stack
 		cf.breaks = cf.breaks[:bi]
 		cf.conts = cf.conts[:ci]
+		cf.breakLabels = cf.breakLabels[:bi]
+		cf.contLabels = cf.contLabels[:ci]
 		// if loop is empty - set an empty body
 		if body == nil {
 			body = &CodeBlock{Next: cont}
@@ -208,6 +251,7 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 		}
 		bi := len(cf.breaks)
 		cf.breaks = append(cf.breaks, next)
+		cf.breakLabels = append(cf.breakLabels, "")
 		// process backward because we need to handle falltrough
 		hasDef := false
 		fall := next
@@ -223,6 +267,7 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 			fall = cb
 		}
 		cf.breaks = cf.breaks[:bi]
+		cf.breakLabels = cf.breakLabels[:bi]
 		if !hasDef {
 			b.Cases = append(b.Cases, nil)
 			b.Blocks = append(b.Blocks, next)
@@ -255,17 +300,59 @@ func (cf *ControlFlow) process(stmts []CStmt, after Block) (Block, bool) {
 	return b, true
 }
 
+// maxPrevScan is the number of predecessors that are checked for duplicates without an index.
+const maxPrevScan = 16
+
 type BaseBlock struct {
 	prev []Block
+	// index of prev blocks, only set if there are more than maxPrevScan of them,
+	// for example, for a label that is targeted by a lot of gotos
+	prevSet BlockSet
 }
 
-func (b *BaseBlock) AddPrevBlock(b2 Block) {
-	for _, b := range b.prev {
-		if b == b2 {
-			return
+func (b *BaseBlock) hasPrevBlock(b2 Block) bool {
+	if b.prevSet == nil && len(b.prev) > maxPrevScan {
+		b.prevSet = make(BlockSet, len(b.prev))
+		for _, p := range b.prev {
+			b.prevSet[p] = struct{}{}
+		}
+	}
+	if b.prevSet != nil {
+		_, ok := b.prevSet[b2]
+		return ok
+	}
+	for _, p := range b.prev {
+		if p == b2 {
+			return true
 		}
 	}
+	return false
+}
+
+func (b *BaseBlock) AddPrevBlock(b2 Block) {
+	if b.hasPrevBlock(b2) {
+		return
+	}
 	b.prev = append(b.prev, b2)
+	if b.prevSet != nil {
+		b.prevSet[b2] = struct{}{}
+	}
+}
+
+func (b *BaseBlock) replacePrev(p, to Block) bool {
+	if !b.hasPrevBlock(p) {
+		return false
+	}
+	for i, p2 := range b.prev {
+		if p == p2 {
+			b.prev[i] = to
+		}
+	}
+	if b.prevSet != nil {
+		delete(b.prevSet, p)
+		b.prevSet[to] = struct{}{}
+	}
+	return true
 }
 func (b *BaseBlock) PrevBlocks() []Block {
 	return b.prev
@@ -355,52 +442,6 @@ func (b *ReturnBlock) NextBlocks() []Block {
 }
 func (b *ReturnBlock) ReplaceNext(old, rep Block) {}
 
-func (cf *ControlFlow) Dom(a, b Block) bool {
-	if a == b {
-		return true
-	}
-	_, ok := cf.doms[b][a]
-	return ok
-}
-
-func (cf *ControlFlow) SDom(a, b Block) bool {
-	if a == b {
-		// strict dominance - nodes shouldn't be the same
-		return false
-	}
-	return cf.Dom(a, b)
-}
-
-func (cf *ControlFlow) IDom(a Block) Block {
-	var doms []Block
-	for d := range cf.doms[a] {
-		if d != a {
-			doms = append(doms, d)
-		}
-	}
-	for len(doms) > 1 {
-	loop:
-		for i := 0; i < len(doms); i++ {
-			d1 := doms[i]
-			for j := 0; j < len(doms); j++ {
-				if i == j {
-					continue
-				}
-				d2 := doms[j]
-				if _, ok := cf.doms[d2][d1]; ok {
-					doms = append(doms[:i], doms[i+1:]...)
-					i--
-					continue loop
-				}
-			}
-		}
-	}
-	if len(doms) == 1 {
-		return doms[0]
-	}
-	return nil
-}
-
 type BlockSet map[Block]struct{}
 
 func (b BlockSet) Clone() BlockSet {
@@ -460,53 +501,6 @@ func (b BlockSet) Contains(b2 BlockSet) bool {
 	return true
 }
 
-func (cf *ControlFlow) buildDoms() {
-	// TODO: quadratic complexity! use a different algorithm
-	blocks := cf.allBlocks()
-	cf.doms = make(map[Block]BlockSet, len(blocks))
-	for b := range blocks {
-		if b == cf.Start {
-			cf.doms[b] = BlockSet{b: {}}
-		} else {
-			cf.doms[b] = blocks
-		}
-	}
-
-	changes := true
-	for changes {
-		changes = false
-		for b := range blocks {
-			d := cf.doms[b]
-			if len(d) == 1 {
-				continue
-			}
-			var m BlockSet
-			for _, b2 := range b.PrevBlocks() {
-				if b == b2 {
-					continue
-				}
-				d2 := cf.doms[b2]
-				if m == nil {
-					m = d2
-					continue
-				}
-				m = m.Intersect(d2)
-				if len(m) == 0 {
-					break
-				}
-			}
-			m = m.Union(BlockSet{b: {}})
-			if len(d) != len(m) {
-				changes = true
-				cf.doms[b] = m
-			}
-			if len(d) < len(m) {
-				panic(fmt.Errorf("set size increased: %p: %v vs %v", b, d, m))
-			}
-		}
-	}
-}
-
 type varDecls struct {
 	Decls []*CVarDecl
 }
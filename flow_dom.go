@@ -0,0 +1,174 @@
+package cxgo
+
+// domTree is a dominator tree of the control flow graph.
+//
+// Blocks are indexed in depth-first preorder, thus all the data is stored in slices instead of per-block sets.
+// This keeps the memory linear in the number of blocks, which matters for large machine-generated functions.
+type domTree struct {
+	blocks []Block       // reachable blocks, in depth-first preorder; the first one is the start block
+	index  map[Block]int // index of each reachable block
+	idom   []int         // index of the immediate dominator, or -1 for the start block
+	in     []int         // preorder number in the dominator tree
+	out    []int         // postorder number in the dominator tree
+}
+
+// Dom checks if a dominates b. Each block dominates itself.
+func (cf *ControlFlow) Dom(a, b Block) bool {
+	if a == b {
+		return true
+	}
+	ia, ok := cf.doms.index[a]
+	if !ok {
+		return false
+	}
+	ib, ok := cf.doms.index[b]
+	if !ok {
+		return false
+	}
+	d := &cf.doms
+	return d.in[ia] <= d.in[ib] && d.out[ib] <= d.out[ia]
+}
+
+// SDom checks if a strictly dominates b.
+func (cf *ControlFlow) SDom(a, b Block) bool {
+	if a == b {
+		// strict dominance - nodes shouldn't be the same
+		return false
+	}
+	return cf.Dom(a, b)
+}
+
+// IDom returns an immediate dominator of a block. It returns nil for the start block and for unreachable blocks.
+func (cf *ControlFlow) IDom(a Block) Block {
+	i, ok := cf.doms.index[a]
+	if !ok || cf.doms.idom[i] < 0 {
+		return nil
+	}
+	return cf.doms.blocks[cf.doms.idom[i]]
+}
+
+// buildDoms computes the dominator tree with the Lengauer-Tarjan algorithm (the simple version with path compression).
+//
+// See "A Fast Algorithm for Finding Dominators in a Flowgraph" (T. Lengauer, R. E. Tarjan, 1979).
+// All the steps are iterative to support functions with a very large number of blocks.
+func (cf *ControlFlow) buildDoms() {
+	d := &cf.doms
+	d.index = make(map[Block]int)
+	var parent []int
+	cf.eachBlockSub(cf.Start, func(b, p Block) {
+		pi := -1
+		if p != nil {
+			pi = d.index[p]
+		}
+		d.index[b] = len(d.blocks)
+		d.blocks = append(d.blocks, b)
+		parent = append(parent, pi)
+	}, make(BlockSet))
+	n := len(d.blocks)
+
+	// all successors of reachable blocks are reachable, thus predecessors are collected from them
+	preds := make([][]int, n)
+	for i, b := range d.blocks {
+		for _, b2 := range b.NextBlocks() {
+			if j, ok := d.index[b2]; ok {
+				preds[j] = append(preds[j], i)
+			}
+		}
+	}
+
+	// semidominators are stored as preorder indexes, which are the same as block indexes
+	semi := make([]int, n)
+	label := make([]int, n)
+	ancestor := make([]int, n)
+	d.idom = make([]int, n)
+	for i := range semi {
+		semi[i] = i
+		label[i] = i
+		ancestor[i] = -1
+	}
+	bucket := make([][]int, n)
+	var path []int
+	eval := func(v int) int {
+		if ancestor[v] < 0 {
+			return v
+		}
+		// compress the path to the root of the forest, starting from the top
+		path = path[:0]
+		for x := v; ancestor[ancestor[x]] >= 0; x = ancestor[x] {
+			path = append(path, x)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			x := path[i]
+			a := ancestor[x]
+			if semi[label[a]] < semi[label[x]] {
+				label[x] = label[a]
+			}
+			ancestor[x] = ancestor[a]
+		}
+		return label[v]
+	}
+	for w := n - 1; w > 0; w-- {
+		for _, v := range preds[w] {
+			if u := eval(v); semi[u] < semi[w] {
+				semi[w] = semi[u]
+			}
+		}
+		bucket[semi[w]] = append(bucket[semi[w]], w)
+		p := parent[w]
+		ancestor[w] = p
+		for _, v := range bucket[p] {
+			if u := eval(v); semi[u] < semi[v] {
+				d.idom[v] = u
+			} else {
+				d.idom[v] = p
+			}
+		}
+		bucket[p] = nil
+	}
+	for w := 1; w < n; w++ {
+		if d.idom[w] != semi[w] {
+			d.idom[w] = d.idom[d.idom[w]]
+		}
+	}
+	if n != 0 {
+		d.idom[0] = -1
+	}
+	d.number()
+}
+
+// number assigns preorder and postorder numbers to the dominator tree nodes, which allows checking dominance
+// in constant time.
+func (d *domTree) number() {
+	n := len(d.blocks)
+	kids := make([][]int, n)
+	for i := 1; i < n; i++ {
+		kids[d.idom[i]] = append(kids[d.idom[i]], i)
+	}
+	d.in = make([]int, n)
+	d.out = make([]int, n)
+	if n == 0 {
+		return
+	}
+	type frame struct {
+		i    int
+		next int
+	}
+	pre, post := 0, 0
+	stack := []frame{{i: 0}}
+	for len(stack) != 0 {
+		top := &stack[len(stack)-1]
+		if top.next == 0 {
+			d.in[top.i] = pre
+			pre++
+		}
+		if top.next < len(kids[top.i]) {
+			k := kids[top.i][top.next]
+			top.next++
+			stack = append(stack, frame{i: k})
+			continue
+		}
+		d.out[top.i] = post
+		post++
+		stack = stack[:len(stack)-1]
+	}
+}
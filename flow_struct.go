@@ -0,0 +1,577 @@
+package cxgo
+
+import (
+	"strconv"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+// Structure reconstructs structured control flow (loops, if/else chains, switches, breaks and continues)
+// from the graph, which allows removing arbitrary gotos while keeping the code readable.
+//
+// The algorithm is based on "Beyond Relooper" (N. Ramsey, 2022): each node is emitted together with its
+// children in the dominator tree, loops are formed at targets of back edges, and nodes with multiple
+// incoming edges are placed after a block that is exited with a break.
+//
+// It only works for reducible graphs and returns false for loops with multiple entry points.
+// In this case, Flatten must be used instead.
+func (cf *ControlFlow) Structure() ([]CStmt, bool) {
+	if cf.Start == nil {
+		return nil, true
+	}
+	s := &flowStructurer{
+		cf:     cf,
+		order:  make(map[Block]int),
+		fwdIn:  make(map[Block]int),
+		loops:  make(map[Block]BlockSet),
+		kids:   make(map[Block][]Block),
+		noFall: make(map[*SwitchBlock]bool),
+	}
+	if !s.analyze() {
+		return nil, false
+	}
+	for !s.tryTree() {
+	}
+	s.loopConds()
+	s.resolveJumps()
+	names := make(map[string]*types.Ident)
+	out := make([]CStmt, 0, len(s.decls.Decls)+len(s.stmts))
+	for _, d := range s.decls.Decls {
+		for _, id := range d.Names {
+			uniqueName(names, id)
+		}
+		out = append(out, &CDeclStmt{Decl: d})
+	}
+	return append(out, s.stmts...), true
+}
+
+type flowStructurer struct {
+	cf    *ControlFlow
+	order map[Block]int      // reverse postorder index
+	fwdIn map[Block]int      // number of forward edges leading to a block
+	loops map[Block]BlockSet // natural loops, by the loop header
+	kids  map[Block][]Block  // children in the dominator tree, in reverse postorder
+	preds map[Block][]Block  // predecessors, excluding unreachable blocks
+
+	noFall map[*SwitchBlock]bool // switches that cannot use fallthrough between cases, see caseFalls
+
+	stmts  []CStmt
+	decls  varDecls     // declarations moved to the function scope
+	scopes []*flowScope // currently open scopes
+	all    []*flowScope // all scopes, in order of creation
+	byLoop map[*CForStmt]*flowScope
+	labels int
+}
+
+type flowScopeKind int
+
+const (
+	flowScopeLoop   flowScopeKind = iota // loop, starts with its target; exited by break of an enclosing block
+	flowScopeBlock                       // block, followed by its target; exited by break
+	flowScopeSwitch                      // switch; only affects unlabeled breaks
+	flowScopeCase                        // switch case, followed by the target case; cannot be exited early
+)
+
+type flowScope struct {
+	kind   flowScopeKind
+	target Block
+	follow Block        // block that is executed after the loop completes
+	sw     *SwitchBlock // switch of the case
+	loop   *CForStmt    // Go loop that implements the scope, if any
+	jumps  []*flowJump
+}
+
+// flowRetry is raised when a switch case cannot fall through to the next case.
+type flowRetry struct {
+	sw *SwitchBlock
+}
+
+// flowJump is a break or continue to a given scope. Other scopes that the jump exits are recorded
+// to decide if the jump needs a label.
+type flowJump struct {
+	brk    *CBreakStmt
+	cont   *CContinueStmt
+	target *flowScope
+	inner  []*flowScope
+}
+
+func (j *flowJump) stmt() CStmt {
+	if j.brk != nil {
+		return j.brk
+	}
+	return j.cont
+}
+
+// analyze numbers blocks in reverse postorder, classifies edges, finds natural loops and builds the dominator tree.
+// It returns false if the graph is irreducible.
+func (s *flowStructurer) analyze() bool {
+	type frame struct {
+		b    Block
+		next int
+	}
+	var post []Block
+	seen := BlockSet{s.cf.Start: {}}
+	stack := []frame{{b: s.cf.Start}}
+	for len(stack) != 0 {
+		top := &stack[len(stack)-1]
+		if next := top.b.NextBlocks(); top.next < len(next) {
+			b := next[top.next]
+			top.next++
+			if _, ok := seen[b]; b != nil && !ok {
+				seen[b] = struct{}{}
+				stack = append(stack, frame{b: b})
+			}
+			continue
+		}
+		post = append(post, top.b)
+		stack = stack[:len(stack)-1]
+	}
+	rpo := make([]Block, len(post))
+	for i, b := range post {
+		rpo[len(post)-1-i] = b
+	}
+	for i, b := range rpo {
+		s.order[b] = i
+	}
+	preds := make(map[Block][]Block)
+	s.preds = preds
+	backs := make(map[Block][]Block)
+	for _, b := range rpo {
+		next := b.NextBlocks()
+		for i, b2 := range next {
+			if b2 == nil {
+				continue
+			}
+			if _, ok := b.(*SwitchBlock); ok && i > 0 && next[i-1] == b2 {
+				// consecutive cases share the code, see emitSwitch
+				continue
+			}
+			preds[b2] = append(preds[b2], b)
+			if s.order[b2] > s.order[b] {
+				s.fwdIn[b2]++
+			} else if s.cf.Dom(b2, b) {
+				backs[b2] = append(backs[b2], b)
+			} else {
+				// jump into the middle of a loop
+				return false
+			}
+		}
+	}
+	for h, srcs := range backs {
+		loop := BlockSet{h: {}}
+		work := append([]Block{}, srcs...)
+		for len(work) != 0 {
+			b := work[len(work)-1]
+			work = work[:len(work)-1]
+			if _, ok := loop[b]; ok {
+				continue
+			}
+			loop[b] = struct{}{}
+			work = append(work, preds[b]...)
+		}
+		s.loops[h] = loop
+	}
+	for _, b := range rpo[1:] {
+		p := s.cf.IDom(b)
+		if p == nil {
+			return false
+		}
+		s.kids[p] = append(s.kids[p], b)
+	}
+	return true
+}
+
+// tryTree emits the whole function. It returns false if the process must be restarted with a different set of switches
+// that can use fallthrough.
+func (s *flowStructurer) tryTree() (ok bool) {
+	s.decls, s.scopes, s.all = varDecls{}, nil, nil
+	s.byLoop = make(map[*CForStmt]*flowScope)
+	defer func() {
+		if r := recover(); r != nil {
+			e, isRetry := r.(flowRetry)
+			if !isRetry {
+				panic(r)
+			}
+			s.noFall[e.sw] = true
+			ok = false
+		}
+	}()
+	s.stmts = s.doTree(s.cf.Start, nil)
+	return true
+}
+
+func (s *flowStructurer) push(kind flowScopeKind, target Block) *flowScope {
+	sc := &flowScope{kind: kind, target: target}
+	s.scopes = append(s.scopes, sc)
+	s.all = append(s.all, sc)
+	return sc
+}
+
+func (s *flowStructurer) pop() {
+	s.scopes = s.scopes[:len(s.scopes)-1]
+}
+
+// doTree emits a given block and all the blocks it dominates. Follow is the block that is executed
+// after the emitted statements complete normally.
+func (s *flowStructurer) doTree(x, follow Block) []CStmt {
+	loop := s.loops[x]
+	falls := s.caseFalls(x)
+	var exits, merges []Block
+	for _, k := range s.kids[x] {
+		if _, in := loop[k]; loop != nil && !in {
+			exits = append(exits, k)
+		} else if s.fwdIn[k] > 1 && !falls[k] {
+			merges = append(merges, k)
+		}
+	}
+	if loop == nil {
+		return s.nodeWithin(x, merges, follow)
+	}
+	// code that follows the loop is placed after it, not in the loop body
+	return s.withBlocks(exits, follow, func(follow Block) []CStmt {
+		sc := s.push(flowScopeLoop, x)
+		sc.follow = follow
+		body := s.nodeWithin(x, merges, x)
+		s.pop()
+		sc.loop = &CForStmt{Body: *s.cf.g.NewCBlock(body...)}
+		s.byLoop[sc.loop] = sc
+		return []CStmt{sc.loop}
+	})
+}
+
+func (s *flowStructurer) nodeWithin(x Block, merges []Block, follow Block) []CStmt {
+	return s.withBlocks(merges, follow, func(follow Block) []CStmt {
+		return s.emitNode(x, follow)
+	})
+}
+
+// withBlocks wraps code into nested blocks that are followed by given merge nodes.
+// Nodes must be in reverse postorder, the last one is placed at the end.
+func (s *flowStructurer) withBlocks(ys []Block, follow Block, inner func(follow Block) []CStmt) []CStmt {
+	if len(ys) == 0 {
+		return inner(follow)
+	}
+	y := ys[len(ys)-1]
+	sc := s.push(flowScopeBlock, y)
+	body := s.withBlocks(ys[:len(ys)-1], y, inner)
+	s.pop()
+	out := s.closeBlock(sc, body)
+	return append(out, s.doTree(y, follow)...)
+}
+
+// closeBlock converts a block to Go. Go has no breakable blocks, thus a loop is used in case the block has any breaks.
+func (s *flowStructurer) closeBlock(sc *flowScope, body []CStmt) []CStmt {
+	if len(sc.jumps) == 0 {
+		return body
+	}
+	if len(body) == 1 {
+		if f, ok := body[0].(*CForStmt); ok {
+			// exit from the block is the same as exit from the loop
+			if lsc := s.byLoop[f]; lsc != nil {
+				for _, j := range sc.jumps {
+					j.target = lsc
+					for i, in := range j.inner {
+						if in == lsc {
+							j.inner = append(j.inner[:i:i], j.inner[i+1:]...)
+							break
+						}
+					}
+				}
+				lsc.jumps = append(lsc.jumps, sc.jumps...)
+				sc.jumps = nil
+				return body
+			}
+		}
+	}
+	if !flowEndsWithJump(body) {
+		body = append(body, &CBreakStmt{})
+	}
+	sc.loop = &CForStmt{Body: *s.cf.g.NewCBlock(body...)}
+	s.byLoop[sc.loop] = sc
+	return []CStmt{sc.loop}
+}
+
+func (s *flowStructurer) emitNode(x, follow Block) []CStmt {
+	switch b := x.(type) {
+	case *CodeBlock:
+		stmts := s.moveDecls(b.Stmts)
+		if b.Next == nil {
+			if follow != nil {
+				stmts = append(stmts, &CReturnStmt{})
+			}
+			return stmts
+		}
+		return append(stmts, s.doBranch(b, b.Next, follow)...)
+	case *CondBlock:
+		then := s.doBranch(b, b.Then, follow)
+		els := s.doBranch(b, b.Else, follow)
+		return s.emitIf(b.Expr, then, els)
+	case *SwitchBlock:
+		return s.emitSwitch(b, follow)
+	case *ReturnBlock:
+		if b.Expr == nil && follow == nil {
+			// implicit return at the end of the function
+			return nil
+		}
+		return []CStmt{b.CReturnStmt}
+	default:
+		panic(b)
+	}
+}
+
+func (s *flowStructurer) emitIf(expr Expr, then, els []CStmt) []CStmt {
+	g := s.cf.g
+	cond := g.ToBool(expr)
+	switch {
+	case len(els) == 0:
+		// keep the condition even if both branches are empty, it may have side effects
+		return []CStmt{g.NewCIfStmt(cond, then, nil)}
+	case len(then) == 0:
+		return []CStmt{g.NewCIfStmt(g.cNot(cond), els, nil)}
+	case flowEndsWithJump(then):
+		return append([]CStmt{g.NewCIfStmt(cond, then, nil)}, els...)
+	case flowEndsWithJump(els):
+		return append([]CStmt{g.NewCIfStmt(g.cNot(cond), els, nil)}, then...)
+	}
+	var e IfElseStmt = g.NewCBlock(els...)
+	if len(els) == 1 {
+		if eif, ok := els[0].(*CIfStmt); ok {
+			e = eif
+		}
+	}
+	return []CStmt{g.NewCIfStmt(cond, then, e)}
+}
+
+// caseFalls finds switch cases that are only reachable from the switch and from the end of the previous case.
+// Such cases are placed into the switch and the previous case falls through to them.
+func (s *flowStructurer) caseFalls(x Block) map[Block]bool {
+	b, ok := x.(*SwitchBlock)
+	if !ok || s.noFall[b] {
+		return nil
+	}
+	var falls map[Block]bool
+	for i := 1; i < len(b.Blocks); i++ {
+		prev, cur := b.Blocks[i-1], b.Blocks[i]
+		if cur == prev || s.fwdIn[cur] != 2 || s.cf.IDom(cur) != x {
+			continue
+		}
+		if !falls[prev] && s.fwdIn[prev] != 1 {
+			// previous case is placed after the switch
+			continue
+		}
+		n := 0
+		for _, p := range s.preds[cur] {
+			if p != b && s.cf.Dom(prev, p) {
+				n++
+			}
+		}
+		if n != 1 {
+			continue
+		}
+		if falls == nil {
+			falls = make(map[Block]bool)
+		}
+		falls[cur] = true
+	}
+	return falls
+}
+
+func (s *flowStructurer) emitSwitch(b *SwitchBlock, follow Block) []CStmt {
+	g := s.cf.g
+	falls := s.caseFalls(b)
+	s.push(flowScopeSwitch, nil)
+	sw := &CSwitchStmt{g: g, Cond: b.Expr}
+	for i, e := range b.Cases {
+		var body []CStmt
+		if i+1 < len(b.Blocks) && falls[b.Blocks[i+1]] {
+			// C fallthrough to the next case
+			next := b.Blocks[i+1]
+			s.push(flowScopeCase, next).sw = b
+			body = s.doBranch(b, b.Blocks[i], next)
+			s.pop()
+		} else if i+1 >= len(b.Blocks) || b.Blocks[i+1] != b.Blocks[i] {
+			body = s.doBranch(b, b.Blocks[i], follow)
+			if !flowEndsWithJump(body) {
+				// C switch falls through by default
+				body = append(body, &CBreakStmt{})
+			}
+		}
+		// empty case falls through to the next one with the same code
+		sw.Cases = append(sw.Cases, g.NewCaseStmt(e, body...))
+	}
+	s.pop()
+	return []CStmt{sw}
+}
+
+// doBranch emits a jump from one block to the other.
+func (s *flowStructurer) doBranch(src, dst, follow Block) []CStmt {
+	if dst == follow {
+		return nil
+	}
+	if s.order[dst] <= s.order[src] {
+		return []CStmt{s.jump(dst, flowScopeLoop)}
+	}
+	for _, sc := range s.scopes {
+		if sc.target != dst {
+			continue
+		}
+		switch sc.kind {
+		case flowScopeBlock:
+			return []CStmt{s.jump(dst, flowScopeBlock)}
+		case flowScopeCase:
+			// Go can only fall through at the end of the case
+			panic(flowRetry{sw: sc.sw})
+		}
+	}
+	return s.doTree(dst, follow)
+}
+
+func (s *flowStructurer) jump(dst Block, kind flowScopeKind) CStmt {
+	for i := len(s.scopes) - 1; i >= 0; i-- {
+		sc := s.scopes[i]
+		if sc.kind != kind || sc.target != dst {
+			continue
+		}
+		j := &flowJump{target: sc, inner: append([]*flowScope{}, s.scopes[i+1:]...)}
+		sc.jumps = append(sc.jumps, j)
+		if kind == flowScopeLoop {
+			j.cont = &CContinueStmt{}
+			return j.cont
+		}
+		j.brk = &CBreakStmt{}
+		return j.brk
+	}
+	panic("no scope for the jump")
+}
+
+// loopConds converts loops starting with a conditional exit to loops with a condition.
+func (s *flowStructurer) loopConds() {
+	owner := make(map[CStmt]*flowScope)
+	for _, sc := range s.all {
+		for _, j := range sc.jumps {
+			owner[j.stmt()] = sc
+		}
+	}
+	for _, sc := range s.all {
+		if sc.kind != flowScopeLoop || len(sc.loop.Body.Stmts) == 0 {
+			continue
+		}
+		iff, ok := sc.loop.Body.Stmts[0].(*CIfStmt)
+		if !ok || iff.Else != nil || len(iff.Then.Stmts) != 1 {
+			continue
+		}
+		// the exit must either break this loop, or jump to the code that follows the loop anyway
+		st := iff.Then.Stmts[0]
+		tsc := owner[st]
+		if tsc == nil || (tsc != sc && tsc.target != sc.follow) {
+			continue
+		}
+		for i, j := range tsc.jumps {
+			if j.stmt() == st {
+				tsc.jumps = append(tsc.jumps[:i:i], tsc.jumps[i+1:]...)
+				break
+			}
+		}
+		sc.loop.Cond = s.cf.g.cNot(iff.Cond)
+		if cIsTrue(sc.loop.Cond) {
+			sc.loop.Cond = nil
+		}
+		sc.loop.Body.Stmts = sc.loop.Body.Stmts[1:]
+	}
+}
+
+// resolveJumps adds labels to jumps that would otherwise exit a different loop or switch.
+func (s *flowStructurer) resolveJumps() {
+	for _, sc := range s.all {
+		for _, j := range sc.jumps {
+			need := false
+			for _, in := range j.inner {
+				if in.loop != nil || (in.kind == flowScopeSwitch && j.brk != nil) {
+					need = true
+					break
+				}
+			}
+			if !need {
+				continue
+			}
+			if sc.loop.Label == "" {
+				s.labels++
+				sc.loop.Label = numLabelName(s.labels)
+			}
+			if j.brk != nil {
+				j.brk.Label = sc.loop.Label
+			} else {
+				j.cont.Label = sc.loop.Label
+			}
+		}
+	}
+}
+
+// moveDecls moves variable declarations to the function scope, since the code that uses them might be
+// placed into a different Go block. Initializers are replaced with assignments.
+func (s *flowStructurer) moveDecls(stmts []CStmt) []CStmt {
+	out := make([]CStmt, 0, len(stmts))
+	for _, st := range stmts {
+		ds, ok := st.(*CDeclStmt)
+		if !ok {
+			out = append(out, st)
+			continue
+		}
+		d, ok := ds.Decl.(*CVarDecl)
+		if !ok || d.Const || d.Names[0].Name == "__func__" {
+			out = append(out, st)
+			continue
+		}
+		s.decls.Decls = append(s.decls.Decls, &CVarDecl{
+			Const:  d.Const,
+			Single: d.Single,
+			CVarSpec: CVarSpec{
+				g:     d.g,
+				Type:  d.Type,
+				Names: d.Names,
+			},
+		})
+		for j, val := range d.Inits {
+			if val != nil {
+				out = append(out, d.g.NewCAssignStmt(IdentExpr{d.Names[j]}, "", val, false)...)
+			}
+		}
+	}
+	return out
+}
+
+// uniqueName renames variables from different C scopes that have the same name.
+func uniqueName(names map[string]*types.Ident, id *types.Ident) {
+	name := id.String()
+	if names[name] == nil {
+		names[name] = id
+		return
+	}
+	for i := 2; ; i++ {
+		name2 := name + "_" + strconv.Itoa(i)
+		if names[name2] == nil {
+			id.GoName = name2
+			names[name2] = id
+			return
+		}
+	}
+}
+
+// flowEndsWithJump checks if the last statement never completes normally.
+func flowEndsWithJump(stmts []CStmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	switch st := stmts[len(stmts)-1].(type) {
+	case *CReturnStmt, *CBreakStmt, *CContinueStmt, *CGotoStmt:
+		return true
+	case *BlockStmt:
+		return flowEndsWithJump(st.Stmts)
+	case *CIfStmt:
+		if st.Else == nil || !flowEndsWithJump(st.Then.Stmts) {
+			return false
+		}
+		return flowEndsWithJump([]CStmt{st.Else})
+	}
+	return false
+}
@@ -48,11 +48,12 @@ func newBlock(stmts ...CStmt) *BlockStmt {
 }
 
 var casesControlFlow = []struct {
-	name string
-	tree []CStmt
-	exp  string
-	dom  string
-	flat string
+	name       string
+	tree       []CStmt
+	exp        string
+	dom        string
+	flat       string
+	structured string
 }{
 	{
 		name: "return",
@@ -69,6 +70,9 @@ var casesControlFlow = []struct {
 `,
 		flat: `
 return 1
+`,
+		structured: `
+return 1
 `,
 	},
 	{
@@ -94,6 +98,9 @@ foo(1)
 goto L_1
 L_1:
 return
+`,
+		structured: `
+foo(1)
 `,
 	},
 	{
@@ -120,6 +127,10 @@ foo(1)
 goto L_1
 L_1:
 return 2
+`,
+		structured: `
+foo(1)
+return 2
 `,
 	},
 	{
@@ -148,6 +159,11 @@ foo(2)
 goto L_1
 L_1:
 return 3
+`,
+		structured: `
+foo(1)
+foo(2)
+return 3
 `,
 	},
 	{
@@ -198,6 +214,15 @@ foo2 = 2
 goto L_3
 L_3:
 return 1
+`,
+		structured: `
+var foo1 bar
+var foo2 bar
+if 1 {
+foo1 = 1
+}
+foo2 = 2
+return 1
 `,
 	},
 	{
@@ -248,6 +273,14 @@ foo(4)
 goto L_3
 L_3:
 return 5
+`,
+		structured: `
+if 1 {
+foo(2)
+}
+foo(3)
+foo(4)
+return 5
 `,
 	},
 	{
@@ -295,6 +328,14 @@ return 4
 L_3:
 foo(3)
 goto L_2
+`,
+		structured: `
+if 1 {
+foo(2)
+} else {
+foo(3)
+}
+return 4
 `,
 	},
 	{
@@ -339,6 +380,13 @@ foo(3)
 goto L_3
 L_3:
 return 4
+`,
+		structured: `
+if 1 {
+return 2
+}
+foo(3)
+return 4
 `,
 	},
 	{
@@ -383,6 +431,13 @@ L_2:
 return 4
 L_3:
 return 3
+`,
+		structured: `
+if 1 {
+foo(2)
+return 4
+}
+return 3
 `,
 	},
 	{
@@ -418,6 +473,12 @@ L_1:
 return 2
 L_2:
 return 3
+`,
+		structured: `
+if 1 {
+return 2
+}
+return 3
 `,
 	},
 	{
@@ -454,6 +515,12 @@ L_1:
 return 2
 L_2:
 return 3
+`,
+		structured: `
+if 1 {
+return 2
+}
+return 3
 `,
 	},
 	{
@@ -534,6 +601,21 @@ return 1
 L_5:
 foo(4)
 goto L_4
+`,
+		structured: `
+switch 1 {
+case 1:
+foo(1)
+fallthrough
+case 2:
+foo(2)
+fallthrough
+case 3:
+foo(3)
+default:
+foo(4)
+}
+return 1
 `,
 	},
 	{
@@ -596,6 +678,17 @@ return 1
 L_3:
 foo(3)
 goto L_2
+`,
+		structured: `
+switch 1 {
+case 1:
+foo(1)
+case 2:
+case 3:
+foo(3)
+default:
+}
+return 1
 `,
 	},
 	{
@@ -651,6 +744,13 @@ foo(2)
 goto L_3
 L_3:
 return 3
+`,
+		structured: `
+foo(1)
+if 1 {
+foo(2)
+}
+return 3
 `,
 	},
 	{
@@ -706,6 +806,13 @@ foo(1)
 goto L_1
 L_3:
 return 3
+`,
+		structured: `
+foo(0)
+for 1 {
+foo(1)
+}
+return 3
 `,
 	},
 	{
@@ -727,6 +834,10 @@ return 3
 		flat: `
 L_1:
 goto L_1
+`,
+		structured: `
+for {
+}
 `,
 	},
 	{
@@ -749,6 +860,11 @@ goto L_1
 L_1:
 foo(1)
 goto L_1
+`,
+		structured: `
+for {
+foo(1)
+}
 `,
 	},
 	{
@@ -778,6 +894,10 @@ foo(1)
 goto L_1
 L_1:
 return 2
+`,
+		structured: `
+foo(1)
+return 2
 `,
 	},
 	{
@@ -803,6 +923,11 @@ return 2
 L_1:
 foo(1)
 goto L_1
+`,
+		structured: `
+for {
+foo(1)
+}
 `,
 	},
 	{
@@ -845,6 +970,12 @@ return 2
 L_3:
 foo(1)
 goto L_1
+`,
+		structured: `
+for {
+foo(1)
+}
+return 2
 `,
 	},
 	{
@@ -918,6 +1049,16 @@ goto L_2
 L_5:
 foo(4)
 goto L_1
+`,
+		structured: `
+for {
+if 2 {
+foo(3)
+break
+}
+foo(4)
+}
+return 5
 `,
 	},
 	{
@@ -984,6 +1125,15 @@ goto L_5
 L_5:
 foo(3)
 goto L_4
+`,
+		structured: `
+for 2 != 0 {
+foo(2)
+for 3 != 0 {
+foo(3)
+}
+}
+return 1
 `,
 	},
 	{
@@ -1059,6 +1209,16 @@ goto L_1
 L_6:
 foo(3)
 goto L_4
+`,
+		structured: `
+for 2 != 0 {
+foo(2)
+for 3 != 0 {
+foo(3)
+}
+foo(4)
+}
+return 1
 `,
 	},
 	{
@@ -1119,6 +1279,14 @@ goto L_4
 L_4:
 foo(2)
 goto L_1
+`,
+		structured: `
+foo(1)
+for {
+foo(3)
+foo(2)
+}
+return 4
 `,
 	},
 	{
@@ -1207,6 +1375,18 @@ goto L_6
 L_6:
 foo(2)
 goto L_1
+`,
+		structured: `
+foo(1)
+for {
+if 2 {
+foo(3)
+break
+}
+foo(4)
+foo(2)
+}
+return 5
 `,
 	},
 }
@@ -1268,6 +1448,226 @@ func TestControlFlow(t *testing.T) {
 			got = printStmts(stmts)
 			got = strings.ReplaceAll(got, "\t", "")
 			require.Equal(t, strings.TrimSpace(c.flat), got)
+
+			stmts, ok := cf.Structure()
+			require.True(t, ok)
+			got = printStmts(stmts)
+			got = strings.ReplaceAll(got, "\t", "")
+			require.Equal(t, strings.TrimSpace(c.structured), got)
+		})
+	}
+}
+
+var casesControlFlowStructure = []struct {
+	name string
+	tree []CStmt
+	exp  string
+}{
+	{
+		name: "irreducible",
+		tree: []CStmt{
+			&CIfStmt{
+				Cond: numCond(1),
+				Then: newBlock(&CGotoStmt{Label: "L2"}),
+			},
+			&CLabelStmt{Label: "L1"},
+			numStmt(1),
+			&CLabelStmt{Label: "L2"},
+			numStmt(2),
+			&CIfStmt{
+				Cond: numCond(2),
+				Then: newBlock(&CGotoStmt{Label: "L1"}),
+			},
+			ret(3),
+		},
+	},
+	{
+		name: "goto out of nested loops",
+		tree: []CStmt{
+			&CForStmt{Body: *newBlock(
+				numStmt(1),
+				&CForStmt{Body: *newBlock(
+					&CIfStmt{
+						Cond: numCond(2),
+						Then: newBlock(&CGotoStmt{Label: "L1"}),
+					},
+					&CIfStmt{
+						Cond: numCond(3),
+						Then: newBlock(&CBreakStmt{}),
+					},
+					numStmt(3),
+				)},
+			)},
+			&CLabelStmt{Label: "L1"},
+			ret(4),
+		},
+		exp: `
+L_1:
+for {
+foo(1)
+for !2 {
+if 3 {
+continue L_1
+}
+foo(3)
+}
+return 4
+}
+`,
+	},
+	{
+		name: "goto into switch case",
+		tree: []CStmt{
+			&CSwitchStmt{
+				Cond: numCond(1),
+				Cases: []*CCaseStmt{
+					{Expr: cIntLit(1, 10), Stmts: []CStmt{
+						numStmt(1),
+						&CGotoStmt{Label: "L1"},
+					}},
+					{Expr: cIntLit(2, 10), Stmts: []CStmt{
+						numStmt(2),
+						&CLabelStmt{Label: "L1"},
+						numStmt(3),
+						&CBreakStmt{},
+					}},
+					{Expr: cIntLit(3, 10), Stmts: []CStmt{
+						numStmt(4),
+					}},
+				},
+			},
+			ret(5),
+		},
+		exp: `
+L_1:
+for {
+switch 1 {
+case 1:
+foo(1)
+case 2:
+foo(2)
+case 3:
+foo(4)
+break L_1
+default:
+break L_1
+}
+foo(3)
+break
+}
+return 5
+`,
+	},
+}
+
+func TestControlFlowStructure(t *testing.T) {
+	for _, c := range casesControlFlowStructure {
+		t.Run(c.name, func(t *testing.T) {
+			tr := newTranslator(libs.NewEnv(types.Config32()), Config{})
+			cf := tr.NewControlFlow(c.tree)
+			stmts, ok := cf.Structure()
+			if c.exp == "" {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			got := printStmts(stmts)
+			got = strings.ReplaceAll(got, "\t", "")
+			require.Equal(t, strings.TrimSpace(c.exp), got)
 		})
 	}
 }
+
+func TestControlFlowLarge(t *testing.T) {
+	const n = 20000
+	var body []CStmt
+	for i := 0; i < n; i++ {
+		body = append(body,
+			&CIfStmt{
+				Cond: numCond(i),
+				Then: newBlock(numStmt(i), &CContinueStmt{}),
+			},
+			numStmt(-i),
+		)
+	}
+	tree := []CStmt{
+		&CForStmt{Cond: cIntLit(1, 10), Body: *newBlock(body...)},
+		ret(0),
+	}
+	tr := newTranslator(libs.NewEnv(types.Config32()), Config{})
+	cf := tr.NewControlFlow(tree)
+	blocks := 0
+	cf.eachBlock(func(b Block) {
+		blocks++
+		if b != cf.Start {
+			require.True(t, cf.SDom(cf.Start, b))
+		}
+	})
+	require.Equal(t, 3*n+2, blocks)
+
+	stmts, ok := cf.Structure()
+	require.True(t, ok)
+	require.Len(t, stmts, 2)
+}
+
+func TestTranslateFlow(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.c": `
+int loops(int n) {
+	int s = 0;
+	for (int i = 0; i < n; i++) {
+		for (int j = 0; j < n; j++) {
+			if (i * j > 10) goto out;
+			s += j;
+		}
+	}
+out:
+	return s;
+}
+
+int irreducible(int x) {
+	if (x) goto b;
+a:
+	x++;
+b:
+	x *= 2;
+	if (x < 100) goto a;
+	return x;
+}
+`,
+	})
+	fname := filepath.Join(dir, "a.c")
+	env := libs.NewEnv(types.Config32())
+
+	ff, err := TranslateFlow(dir, fname, "loops", env, Config{})
+	require.NoError(t, err)
+	require.True(t, ff.Structured)
+	// labeled break from the inner loop must lead to the return
+	fg := ff.Flat.Graph()
+	nodes := make(map[string]FlowNode)
+	for _, n := range fg.Nodes {
+		nodes[n.Label] = n
+	}
+	ret, ok := nodes["return s"]
+	require.True(t, ok)
+	cond, ok := nodes["if i*j > 10"]
+	require.True(t, ok)
+	require.Equal(t, FlowEdge{To: ret.ID, Kind: "then"}, cond.Edges[0])
+
+	ff, err = TranslateFlow(dir, fname, "irreducible", env, Config{})
+	require.NoError(t, err)
+	require.False(t, ff.Structured)
+	fg = ff.Flow.Graph()
+	require.Equal(t, "n2", fg.Start)
+	for _, n := range fg.Nodes {
+		if n.ID == fg.Start {
+			require.Empty(t, n.IDom)
+		} else {
+			require.NotEmpty(t, n.IDom)
+		}
+	}
+
+	_, err = TranslateFlow(dir, fname, "missing", env, Config{})
+	require.Error(t, err)
+}
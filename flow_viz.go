@@ -27,30 +27,37 @@ func printExpr(e Expr) string {
 	return buf.String()
 }
 
-func dotAddNode(g *dot.Graph, id string, b Block) dot.Node {
-	var (
-		label string
-		shape = "circle"
-	)
+// blockLabel returns a kind of the block ("code", "if", "switch" or "return") and its text.
+func blockLabel(b Block) (string, string) {
+	var kind, label string
 	switch b := b.(type) {
 	case nil:
 		panic("must not be nil")
 	case *CodeBlock:
-		label = printStmts(b.Stmts)
-		shape = "box"
+		kind, label = "code", printStmts(b.Stmts)
 	case *CondBlock:
-		label = "if " + printExpr(b.Expr)
-		shape = "hexagon"
+		kind, label = "if", "if "+printExpr(b.Expr)
 	case *ReturnBlock:
-		label = printStmts([]CStmt{b.CReturnStmt})
-		shape = "box"
+		kind, label = "return", printStmts([]CStmt{b.CReturnStmt})
 	case *SwitchBlock:
-		label = "switch " + printExpr(b.Expr)
-		shape = "trapezium"
+		kind, label = "switch", "switch "+printExpr(b.Expr)
 	default:
 		panic(b)
 	}
-	label = strings.ReplaceAll(label, "\t", "  ")
+	return kind, strings.ReplaceAll(label, "\t", "  ")
+}
+
+func dotAddNode(g *dot.Graph, id string, b Block) dot.Node {
+	kind, label := blockLabel(b)
+	shape := "circle"
+	switch kind {
+	case "code", "return":
+		shape = "box"
+	case "if":
+		shape = "hexagon"
+	case "switch":
+		shape = "trapezium"
+	}
 	return g.Node(id).Label(label).Attr("shape", shape)
 }
 
@@ -128,3 +135,74 @@ func printStmts(stmts []CStmt) string {
 	}
 	return buf.String()
 }
+
+// Dot returns the control flow graph in Graphviz DOT format.
+func (cf *ControlFlow) Dot() string {
+	return cf.dumpDot()
+}
+
+// DomDot returns the dominator tree in Graphviz DOT format.
+func (cf *ControlFlow) DomDot() string {
+	return cf.dumpDomDot()
+}
+
+// FlowGraph is a serializable representation of the control flow graph and its dominator tree.
+type FlowGraph struct {
+	Start string     `json:"start"`
+	Nodes []FlowNode `json:"nodes"`
+}
+
+// FlowNode is a basic block in FlowGraph.
+type FlowNode struct {
+	ID    string     `json:"id"`
+	Kind  string     `json:"kind"` // code, if, switch or return
+	Label string     `json:"label"`
+	IDom  string     `json:"idom,omitempty"` // immediate dominator; empty for the start node
+	Edges []FlowEdge `json:"edges,omitempty"`
+}
+
+// FlowEdge is a transition between blocks in FlowGraph.
+type FlowEdge struct {
+	To   string `json:"to"`
+	Kind string `json:"kind,omitempty"` // then, else, case or default; empty for unconditional edges
+	Case string `json:"case,omitempty"`
+}
+
+// Graph returns a serializable control flow graph. Blocks that are not reachable from the start are omitted.
+func (cf *ControlFlow) Graph() *FlowGraph {
+	ids := make(map[Block]string)
+	var blocks []Block
+	cf.eachBlock(func(b Block) {
+		ids[b] = "n" + strconv.Itoa(len(ids)+2) // same as in dumpDomDot
+		blocks = append(blocks, b)
+	})
+	fg := &FlowGraph{Start: ids[cf.Start], Nodes: make([]FlowNode, 0, len(blocks))}
+	for _, b := range blocks {
+		n := FlowNode{ID: ids[b]}
+		n.Kind, n.Label = blockLabel(b)
+		if d := cf.IDom(b); d != nil {
+			n.IDom = ids[d]
+		}
+		switch b := b.(type) {
+		case *CondBlock:
+			n.Edges = []FlowEdge{
+				{To: ids[b.Then], Kind: "then"},
+				{To: ids[b.Else], Kind: "else"},
+			}
+		case *CodeBlock:
+			if b.Next != nil {
+				n.Edges = []FlowEdge{{To: ids[b.Next]}}
+			}
+		case *SwitchBlock:
+			for i, c := range b.Blocks {
+				e := FlowEdge{To: ids[c], Kind: "default"}
+				if v := b.Cases[i]; v != nil {
+					e.Kind, e.Case = "case", printExpr(v)
+				}
+				n.Edges = append(n.Edges, e)
+			}
+		}
+		fg.Nodes = append(fg.Nodes, n)
+	}
+	return fg
+}
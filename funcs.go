@@ -108,8 +108,67 @@ func asSizeofT(e Expr) types.Type {
 	return sz.Type
 }
 
+// asArraySize matches n * sizeof(T) (in any order of factors) and returns T and n.
+func (g *translator) asArraySize(e Expr) (types.Type, Expr) {
+	m, ok := unwrapCasts(e).(*CBinaryExpr)
+	if !ok || m.Op != BinOpMult {
+		return nil, nil
+	}
+	if tp := asSizeofT(m.Right); tp != nil {
+		return tp, m.Left
+	}
+	if tp := asSizeofT(m.Left); tp != nil {
+		return tp, m.Right
+	}
+	if tp, n := g.asArraySize(m.Left); tp != nil {
+		return tp, g.NewCBinaryExpr(n, BinOpMult, m.Right)
+	}
+	if tp, n := g.asArraySize(m.Right); tp != nil {
+		return tp, g.NewCBinaryExpr(m.Left, BinOpMult, n)
+	}
+	return nil, nil
+}
+
+// typedRealloc converts realloc of a typed pointer or a slice to a typed runtime helper.
+// It returns nil if the size or the pointer type do not match.
+func (g *translator) typedRealloc(p, size Expr) Expr {
+	tp, n := g.asArraySize(size)
+	if tp == nil {
+		return nil
+	}
+	n = g.cCast(g.env.Go().Int(), n)
+	if IsNil(p) {
+		return &MakeExpr{e: g.env.Env, Elem: tp, Size: n}
+	}
+	p = unwrapCasts(p)
+	var name string
+	switch pt := types.Unwrap(p.CType(nil)).(type) {
+	case types.PtrType:
+		if !types.Same(pt.Elem(), tp) {
+			return nil
+		}
+		name = "libc.ReallocArray"
+	case types.ArrayType:
+		if !pt.IsSlice() || !types.Same(pt.Elem(), tp) {
+			return nil
+		}
+		name = "libc.ReallocSlice"
+	default:
+		return nil
+	}
+	t := p.CType(nil)
+	fnc := types.NewIdentGo("realloc", name, g.env.FuncTT(t, t, g.env.Go().Int()))
+	return &CallExpr{
+		Fun:  FuncIdent{fnc},
+		Args: []Expr{p, n},
+	}
+}
+
 func (g *translator) NewCCallExpr(fnc FuncExpr, args []Expr) Expr {
 	if id, ok := cUnwrap(fnc).(IdentExpr); ok {
+		if len(args) == 1 && g.isSetJumpCall(id.Ident) {
+			return g.NewSetJumpExpr(args[0])
+		}
 		// TODO: another way to hook into it?
 		switch id.Ident {
 		case g.env.C().AssertFunc():
@@ -165,6 +224,14 @@ func (g *translator) NewCCallExpr(fnc FuncExpr, args []Expr) Expr {
 						Elem: tp,
 					}
 				}
+				// malloc(n * sizeof(T)) -> make([]T, n)
+				if tp, n := g.asArraySize(args[0]); tp != nil {
+					return &MakeExpr{
+						e:    g.env.Env,
+						Elem: tp,
+						Size: g.cCast(g.env.Go().Int(), n),
+					}
+				}
 			}
 		case g.env.C().CallocFunc():
 			// calloc(n, sizeof(T)) -> make([]T, n)
@@ -177,6 +244,13 @@ func (g *translator) NewCCallExpr(fnc FuncExpr, args []Expr) Expr {
 					}
 				}
 			}
+		case g.env.C().ReallocFunc():
+			// realloc(p, n * sizeof(T)) -> libc.ReallocArray(p, n)
+			if len(args) == 2 {
+				if e := g.typedRealloc(args[0], args[1]); e != nil {
+					return e
+				}
+			}
 		case g.env.C().MemsetFunc():
 			// memset(p, 0, sizeof(T)) -> *p = T{}
 			if len(args) == 3 {
@@ -54,9 +54,8 @@ func TestGCCExecute(t *testing.T) {
 	dir := filepath.Join(testDataDir, "gcc")
 
 	ignoreTests := map[string]string{
-		"limits-caselabels": "OOM",
-		"limits-declparen":  "stack exceeded",
-		"limits-exprparen":  "stack exceeded",
+		"limits-declparen": "stack exceeded",
+		"limits-exprparen": "stack exceeded",
 	}
 
 	blacklist := map[string]struct{}{}
@@ -1,8 +1,10 @@
 package cxgo
 
 import (
+	"bytes"
 	"go/ast"
 	"go/format"
+	"go/parser"
 	token2 "go/token"
 	"io"
 	"sort"
@@ -16,10 +18,124 @@ func PrintGo(w io.Writer, pkg string, decls []GoDecl, donotedit bool) error {
 	if donotedit {
 		w.Write([]byte("// Code generated by cxgo. DO NOT EDIT.\n\n"))
 	}
-	return format.Node(w, token2.NewFileSet(), &ast.File{
+	file := &ast.File{
 		Decls: decls,
 		Name:  ident(pkg),
+	}
+	docs := docHolders(file)
+	hasDocs := false
+	for _, d := range docs {
+		if *d != nil {
+			hasDocs = true
+			break
+		}
+	}
+	if !hasDocs {
+		return format.Node(w, token2.NewFileSet(), file)
+	}
+	return printGoWithDocs(w, file, docs)
+}
+
+// docHolders returns pointers to all doc comments of declarations in the file, in the order of traversal.
+func docHolders(file *ast.File) []**ast.CommentGroup {
+	var out []**ast.CommentGroup
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			out = append(out, &n.Doc)
+		case *ast.GenDecl:
+			out = append(out, &n.Doc)
+		case *ast.ValueSpec:
+			out = append(out, &n.Doc)
+		case *ast.StructType:
+			if n.Fields != nil {
+				for _, f := range n.Fields.List {
+					out = append(out, &f.Doc)
+				}
+			}
+		}
+		return true
 	})
+	return out
+}
+
+// printGoWithDocs prints the file with doc comments.
+//
+// Go printer places comments by their positions, but generated nodes have none.
+// Thus, we print the code without comments first, parse it back and insert comments before the lines of parsed nodes.
+func printGoWithDocs(w io.Writer, file *ast.File, docs []**ast.CommentGroup) error {
+	saved := make([]*ast.CommentGroup, len(docs))
+	for i, d := range docs {
+		saved[i], *d = *d, nil
+	}
+	defer func() {
+		for i, d := range docs {
+			*d = saved[i]
+		}
+	}()
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token2.NewFileSet(), file); err != nil {
+		return err
+	}
+	src := buf.Bytes()
+	fset := token2.NewFileSet()
+	pfile, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	var nodes []ast.Node
+	ast.Inspect(pfile, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl, *ast.GenDecl, *ast.ValueSpec:
+			nodes = append(nodes, n)
+		case *ast.StructType:
+			if n.Fields != nil {
+				for _, f := range n.Fields.List {
+					nodes = append(nodes, f)
+				}
+			}
+		}
+		return true
+	})
+	if len(nodes) != len(saved) {
+		// should not happen, but comments are not worth failing the translation
+		_, err = w.Write(src)
+		return err
+	}
+	tf := fset.File(pfile.Pos())
+	comments := make(map[int][]string) // line offset -> comment lines
+	for i, doc := range saved {
+		if doc == nil {
+			continue
+		}
+		off := tf.Offset(nodes[i].Pos())
+		start := bytes.LastIndexByte(src[:off], '\n') + 1
+		indent := src[start:off]
+		if len(bytes.TrimLeft(indent, " \t")) != 0 {
+			continue // node is not the first on its line
+		}
+		for _, c := range doc.List {
+			comments[start] = append(comments[start], string(indent)+c.Text+"\n")
+		}
+	}
+	var out bytes.Buffer
+	last := 0
+	for off := range src {
+		if lines, ok := comments[off]; ok {
+			out.Write(src[last:off])
+			last = off
+			for _, line := range lines {
+				out.WriteString(line)
+			}
+		}
+	}
+	out.Write(src[last:])
+	res, err := format.Source(out.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(res)
+	return err
 }
 
 type usageVisitor struct {
@@ -300,6 +300,11 @@ func unsafePtr() GoType {
 	return ident("unsafe.Pointer")
 }
 
+// unionView returns an expression that accesses a union member of type t via a pointer to the union storage.
+func unionView(t GoType, ptr GoExpr) GoExpr {
+	return deref(call(paren(deref(t)), call(unsafePtr(), ptr)))
+}
+
 func typAssert(x GoExpr, t GoType) GoExpr {
 	return &ast.TypeAssertExpr{
 		X:    x,
@@ -72,6 +72,20 @@ func init() {
 			pre += `
 #define __ILP32__ 1
 #define _ILP32_ 1
+`
+		}
+		if c.Config().BigEndian {
+			pre += `
+#define __BYTE_ORDER__ __ORDER_BIG_ENDIAN__
+`
+		} else {
+			pre += `
+#define __BYTE_ORDER__ __ORDER_LITTLE_ENDIAN__
+`
+		}
+		if c.Config().UnsignedChar {
+			pre += `
+#define __CHAR_UNSIGNED__ 1
 `
 		}
 		var post strings.Builder
@@ -86,7 +100,6 @@ typedef uintptr_t size_t;
 			Header: pre + `
 #define __ORDER_BIG_ENDIAN__ 4321
 #define __ORDER_LITTLE_ENDIAN__ 1234
-#define __BYTE_ORDER__ __ORDER_LITTLE_ENDIAN__
 
 #define __CXGO__
 #define __linux__
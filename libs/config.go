@@ -50,6 +50,11 @@ func (c *Env) Clone() *Env {
 	return c2
 }
 
+// AddImport registers an import path for a Go package name used by qualified identifiers.
+func (c *Env) AddImport(name, path string) {
+	c.imports[name] = path
+}
+
 func (c *Env) ResolveImport(name string) string {
 	path := c.imports[name]
 	if path == "" {
@@ -0,0 +1,87 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/stdio"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	direntH = "dirent.h"
+)
+
+func init() {
+	RegisterLibrary(direntH, func(c *Env) *Library {
+		intT := types.IntT(4)
+		longT := types.IntT(8)
+		ucharT := types.UintT(1)
+		direntT := types.NamedTGo("dirent", "stdio.DirEnt", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("d_ino", "Ino", types.UintT(8))},
+			{Name: types.NewIdentGo("d_type", "Type", ucharT)},
+			{Name: types.NewIdentGo("d_name", "Name", types.ArrayT(c.C().Char(), 256))},
+		}))
+		dirT := types.NamedTGo("DIR", "stdio.Dir", c.MethStructT(map[string]*types.FuncType{
+			"Read":    c.FuncTT(c.PtrT(direntT)),
+			"Close":   c.FuncTT(intT),
+			"Rewind":  c.FuncTT(nil),
+			"TellDir": c.FuncTT(longT),
+			"SeekDir": c.FuncTT(nil, longT),
+		}))
+		l := &Library{
+			Imports: map[string]string{
+				"csys":  RuntimePrefix + "csys",
+				"stdio": RuntimePrefix + "stdio",
+			},
+			Types: map[string]types.Type{
+				"DIR":    dirT,
+				"dirent": direntT,
+			},
+			Idents: map[string]*types.Ident{
+				"DT_UNKNOWN": c.NewIdent("DT_UNKNOWN", "csys.DT_UNKNOWN", csys.DT_UNKNOWN, ucharT),
+				"DT_FIFO":    c.NewIdent("DT_FIFO", "csys.DT_FIFO", csys.DT_FIFO, ucharT),
+				"DT_CHR":     c.NewIdent("DT_CHR", "csys.DT_CHR", csys.DT_CHR, ucharT),
+				"DT_DIR":     c.NewIdent("DT_DIR", "csys.DT_DIR", csys.DT_DIR, ucharT),
+				"DT_BLK":     c.NewIdent("DT_BLK", "csys.DT_BLK", csys.DT_BLK, ucharT),
+				"DT_REG":     c.NewIdent("DT_REG", "csys.DT_REG", csys.DT_REG, ucharT),
+				"DT_LNK":     c.NewIdent("DT_LNK", "csys.DT_LNK", csys.DT_LNK, ucharT),
+				"DT_SOCK":    c.NewIdent("DT_SOCK", "csys.DT_SOCK", csys.DT_SOCK, ucharT),
+			},
+			Header: `
+#include <` + sysTypesH + `>
+
+const unsigned char DT_UNKNOWN = 0;
+const unsigned char DT_FIFO = 1;
+const unsigned char DT_CHR = 2;
+const unsigned char DT_DIR = 4;
+const unsigned char DT_BLK = 6;
+const unsigned char DT_REG = 8;
+const unsigned char DT_LNK = 10;
+const unsigned char DT_SOCK = 12;
+
+typedef struct dirent {
+	_cxgo_uint64 d_ino;
+	unsigned char d_type;
+	char d_name[256];
+} dirent;
+
+typedef struct DIR {
+	struct dirent* (*Read)(void);
+	_cxgo_sint32 (*Close)(void);
+	void (*Rewind)(void);
+	_cxgo_int64 (*TellDir)(void);
+	void (*SeekDir)(_cxgo_int64);
+} DIR;
+
+#define readdir(d) ((DIR*)(d))->Read()
+#define closedir(d) ((DIR*)(d))->Close()
+#define rewinddir(d) ((DIR*)(d))->Rewind()
+#define telldir(d) ((DIR*)(d))->TellDir()
+#define seekdir(d, pos) ((DIR*)(d))->SeekDir(pos)
+`,
+		}
+		l.Declare(
+			c.NewIdent("opendir", "stdio.OpenDir", stdio.OpenDir, c.FuncTT(c.PtrT(dirT), c.Go().String())),
+		)
+		return l
+	})
+}
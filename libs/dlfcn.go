@@ -33,16 +33,18 @@ func init() {
 			Header: `
 const int RTLD_LAZY = 1;
 const int RTLD_NOW = 2;
-const int RTLD_GLOBAL = 4;
-const int RTLD_LOCAL = 8;
+const int RTLD_GLOBAL = 0x100;
+const int RTLD_LOCAL = 0;
 
 typedef struct _cxgo_dllib {
 	void* (*Sym) (_cxgo_go_string);
 	_cxgo_go_int (*Close) ();
 } _cxgo_dllib;
 
-#define dlsym(l, s) ((_cxgo_dllib*)l)->Sym(s)
-#define dlclose(l) ((_cxgo_dllib*)b)->Close(v)
+#define RTLD_DEFAULT ((void*)0)
+
+#define dlsym(l, s) ((_cxgo_dllib*)(l))->Sym(s)
+#define dlclose(l) ((_cxgo_dllib*)(l))->Close()
 `,
 		}
 		l.Declare(
@@ -0,0 +1,29 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	fcntlH = "fcntl.h"
+)
+
+func init() {
+	RegisterLibrary(fcntlH, func(c *Env) *Library {
+		intT := types.IntT(4)
+		return &Library{
+			Imports: map[string]string{
+				"csys": RuntimePrefix + "csys",
+			},
+			Idents: map[string]*types.Ident{
+				"F_DUPFD":    c.NewIdent("F_DUPFD", "csys.F_DUPFD", csys.F_DUPFD, intT),
+				"F_GETFD":    c.NewIdent("F_GETFD", "csys.F_GETFD", csys.F_GETFD, intT),
+				"F_SETFD":    c.NewIdent("F_SETFD", "csys.F_SETFD", csys.F_SETFD, intT),
+				"F_GETFL":    c.NewIdent("F_GETFL", "csys.F_GETFL", csys.F_GETFL, intT),
+				"F_SETFL":    c.NewIdent("F_SETFL", "csys.F_SETFL", csys.F_SETFL, intT),
+				"FD_CLOEXEC": c.NewIdent("FD_CLOEXEC", "csys.FD_CLOEXEC", csys.FD_CLOEXEC, intT),
+			},
+		}
+	})
+}
@@ -1,50 +1,85 @@
 #include <time.h>
 
+const _cxgo_go_int PTHREAD_MUTEX_NORMAL = 0;
 const _cxgo_go_int PTHREAD_MUTEX_RECURSIVE = 1;
+const _cxgo_go_int PTHREAD_MUTEX_ERRORCHECK = 2;
+const _cxgo_go_int PTHREAD_MUTEX_DEFAULT = 0;
 
 typedef struct pthread_mutex_t pthread_mutex_t;
 
-typedef struct pthread_attr_t {} pthread_attr_t;
+typedef struct pthread_attr_t {
+	_cxgo_sint32 (*Init)(void);
+	_cxgo_sint32 (*Destroy)(void);
+	_cxgo_sint32 (*SetDetachState)(_cxgo_sint32 state);
+	_cxgo_sint32 (*GetDetachState)(_cxgo_sint32 *state);
+} pthread_attr_t;
+const _cxgo_go_int PTHREAD_CREATE_JOINABLE = 0;
+const _cxgo_go_int PTHREAD_CREATE_DETACHED = 1;
+#define pthread_attr_init(attr) ((pthread_attr_t*)attr)->Init()
+#define pthread_attr_destroy(attr) ((pthread_attr_t*)attr)->Destroy()
+#define pthread_attr_setdetachstate(attr, state) ((pthread_attr_t*)attr)->SetDetachState(state)
+#define pthread_attr_getdetachstate(attr, state) ((pthread_attr_t*)attr)->GetDetachState(state)
 
 typedef struct {
-	void (*Do)(void (*fnc)(void));
+	_cxgo_sint32 (*CDo)(void (*fnc)(void));
 } pthread_once_t;
 #define PTHREAD_ONCE_INIT {0}
-#define pthread_once(o,f) (o)->Do(f)
+#define pthread_once(o,f) (o)->CDo(f)
 
-typedef struct {
-	pthread_mutex_t* L;
-	void (*Wait)(void);
-	void (*Signal)(void);
-	void (*Broadcast)(void);
+typedef struct pthread_condattr_t {
+	_cxgo_sint32 (*Init)(void);
+	_cxgo_sint32 (*Destroy)(void);
+	_cxgo_sint32 (*SetClock)(clockid_t clock);
+	_cxgo_sint32 (*GetClock)(clockid_t *clock);
+} pthread_condattr_t;
+#define pthread_condattr_init(attr) ((pthread_condattr_t*)attr)->Init()
+#define pthread_condattr_destroy(attr) ((pthread_condattr_t*)attr)->Destroy()
+#define pthread_condattr_setclock(attr, clock) ((pthread_condattr_t*)attr)->SetClock(clock)
+#define pthread_condattr_getclock(attr, clock) ((pthread_condattr_t*)attr)->GetClock(clock)
+
+typedef struct pthread_cond_t {
+	_cxgo_sint32 (*Init)(const pthread_condattr_t *restrict attr);
+	_cxgo_sint32 (*Destroy)(void);
+	_cxgo_sint32 (*Wait)(pthread_mutex_t *restrict mutex);
+	_cxgo_sint32 (*TimedWait)(pthread_mutex_t *restrict mutex, const struct timespec *restrict abstime);
+	_cxgo_sint32 (*Signal)(void);
+	_cxgo_sint32 (*Broadcast)(void);
 } pthread_cond_t;
-typedef struct {} pthread_condattr_t;
-int pthread_cond_destroy(pthread_cond_t *cond);
-int pthread_cond_init(pthread_cond_t *cond, const pthread_condattr_t * attr);
-#define pthread_cond_broadcast(c) (c)->Broadcast()
-#define pthread_cond_signal(c) (c)->Signal()
+#define pthread_cond_init(cond, attr) ((pthread_cond_t*)cond)->Init(attr)
+#define pthread_cond_destroy(cond) ((pthread_cond_t*)cond)->Destroy()
+#define pthread_cond_wait(cond, mutex) ((pthread_cond_t*)cond)->Wait(mutex)
+#define pthread_cond_timedwait(cond, mutex, abstime) ((pthread_cond_t*)cond)->TimedWait(mutex, abstime)
+#define pthread_cond_signal(cond) ((pthread_cond_t*)cond)->Signal()
+#define pthread_cond_broadcast(cond) ((pthread_cond_t*)cond)->Broadcast()
 #define PTHREAD_COND_INITIALIZER {0}
 
-typedef struct {} pthread_mutex_t;
-int pthread_cond_timedwait(pthread_cond_t * cond, pthread_mutex_t * mutex, const struct timespec * abstime);
-//int pthread_cond_wait(pthread_cond_t * cond, pthread_mutex_t * mutex);
-#define pthread_cond_wait(c,m) {(c)->L = m; (c)->Wait();}
-
 typedef struct{
 	_cxgo_sint32 (*Join)(void **retval);
 	_cxgo_sint32 (*TimedJoinNP)(void **retval, const struct timespec *abstime);
+	_cxgo_sint32 (*Detach)(void);
 } pthread_t_;
 #define pthread_t pthread_t_*
 
 _cxgo_sint32 pthread_create(pthread_t *thread, const pthread_attr_t *attr, void *(*start_routine) (void *), void *arg);
+pthread_t pthread_self(void);
+_cxgo_sint32 pthread_equal(pthread_t t1, pthread_t t2);
+
+typedef _cxgo_uint32 pthread_key_t;
+const _cxgo_go_int PTHREAD_DESTRUCTOR_ITERATIONS = 4;
+_cxgo_sint32 pthread_key_create(pthread_key_t *key, void (*destructor)(void*));
+_cxgo_sint32 pthread_key_delete(pthread_key_t key);
+void *pthread_getspecific(pthread_key_t key);
+_cxgo_sint32 pthread_setspecific(pthread_key_t key, const void *value);
 
 typedef struct pthread_mutexattr_t {
 	_cxgo_sint32 (*Init)(void);
 	_cxgo_sint32 (*SetType)(_cxgo_sint32 type);
+	_cxgo_sint32 (*GetType)(_cxgo_sint32 *type);
 	_cxgo_sint32 (*Destroy)(void);
 } pthread_mutexattr_t;
 #define pthread_mutexattr_init(attr) ((pthread_mutexattr_t*)attr)->Init()
 #define pthread_mutexattr_settype(attr, type) ((pthread_mutexattr_t*)attr)->SetType(type)
+#define pthread_mutexattr_gettype(attr, type) ((pthread_mutexattr_t*)attr)->GetType(type)
 #define pthread_mutexattr_destroy(attr) ((pthread_mutexattr_t*)attr)->Destroy()
 
 typedef struct pthread_mutex_t {
@@ -61,8 +96,39 @@ typedef struct pthread_mutex_t {
 #define pthread_mutex_trylock(mutex) ((pthread_mutex_t*)mutex)->TryLock()
 #define pthread_mutex_unlock(mutex) ((pthread_mutex_t*)mutex)->CUnlock()
 #define pthread_mutex_timedlock(mutex, abstime) ((pthread_mutex_t*)mutex)->TimedLock(abstime)
+#define PTHREAD_MUTEX_INITIALIZER {0}
+
+typedef struct pthread_rwlockattr_t {
+	_cxgo_sint32 (*Init)(void);
+	_cxgo_sint32 (*Destroy)(void);
+} pthread_rwlockattr_t;
+#define pthread_rwlockattr_init(attr) ((pthread_rwlockattr_t*)attr)->Init()
+#define pthread_rwlockattr_destroy(attr) ((pthread_rwlockattr_t*)attr)->Destroy()
+
+typedef struct pthread_rwlock_t {
+	_cxgo_sint32 (*Init)(const pthread_rwlockattr_t *restrict attr);
+	_cxgo_sint32 (*Destroy)(void);
+	_cxgo_sint32 (*RdLock)(void);
+	_cxgo_sint32 (*TryRdLock)(void);
+	_cxgo_sint32 (*TimedRdLock)(const struct timespec *restrict abstime);
+	_cxgo_sint32 (*WrLock)(void);
+	_cxgo_sint32 (*TryWrLock)(void);
+	_cxgo_sint32 (*TimedWrLock)(const struct timespec *restrict abstime);
+	_cxgo_sint32 (*CUnlock)(void);
+} pthread_rwlock_t;
+#define pthread_rwlock_init(lock, attr) ((pthread_rwlock_t*)lock)->Init(attr)
+#define pthread_rwlock_destroy(lock) ((pthread_rwlock_t*)lock)->Destroy()
+#define pthread_rwlock_rdlock(lock) ((pthread_rwlock_t*)lock)->RdLock()
+#define pthread_rwlock_tryrdlock(lock) ((pthread_rwlock_t*)lock)->TryRdLock()
+#define pthread_rwlock_timedrdlock(lock, abstime) ((pthread_rwlock_t*)lock)->TimedRdLock(abstime)
+#define pthread_rwlock_wrlock(lock) ((pthread_rwlock_t*)lock)->WrLock()
+#define pthread_rwlock_trywrlock(lock) ((pthread_rwlock_t*)lock)->TryWrLock()
+#define pthread_rwlock_timedwrlock(lock, abstime) ((pthread_rwlock_t*)lock)->TimedWrLock(abstime)
+#define pthread_rwlock_unlock(lock) ((pthread_rwlock_t*)lock)->CUnlock()
+#define PTHREAD_RWLOCK_INITIALIZER {0}
 
 #define pthread_join(thread, retval) ((pthread_t_*)thread)->Join(retval)
 #define pthread_timedjoin_np(thread, retval, abstime) ((pthread_t_*)thread)->TimedJoinNP(retval, abstime)
+#define pthread_detach(thread) ((pthread_t_*)thread)->Detach()
 
 void pthread_exit(void *retval);
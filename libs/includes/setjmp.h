@@ -1,7 +1,13 @@
 typedef struct jmp_buf {
-	_cxgo_go_int (*SetJump) ();
 	void (*LongJump) (_cxgo_go_int);
 } jmp_buf;
+typedef jmp_buf sigjmp_buf;
 
-#define setjmp(b) ((jmp_buf)b).SetJump()
+_cxgo_go_int _cxgo_setjmp(jmp_buf* env);
+
+#define setjmp(b) _cxgo_setjmp(&(b))
 #define longjmp(b, v) ((jmp_buf)b).LongJump(v)
+#define sigsetjmp(b, s) _cxgo_setjmp(&(b))
+#define siglongjmp(b, v) ((jmp_buf)b).LongJump(v)
+#define _setjmp(b) _cxgo_setjmp(&(b))
+#define _longjmp(b, v) ((jmp_buf)b).LongJump(v)
@@ -4,24 +4,28 @@
 typedef _cxgo_sint32 mode_t;
 
 struct stat {
-    _cxgo_sint32  st_dev;     /* ID of device containing file */
-    _cxgo_sint32  st_ino;     /* inode number */
+    _cxgo_uint64  st_dev;     /* ID of device containing file */
+    _cxgo_uint64  st_ino;     /* inode number */
     mode_t    st_mode;    /* protection */
     _cxgo_sint32     st_nlink;   /* number of hard links */
     _cxgo_sint32       st_uid;     /* user ID of owner */
     _cxgo_sint32       st_gid;     /* group ID of owner */
-    _cxgo_sint32       st_rdev;    /* device ID (if special file) */
+    _cxgo_uint64       st_rdev;    /* device ID (if special file) */
     off_t       st_size;    /* total size, in bytes */
-    struct timeval      st_atime;   /* time of last access */
-    struct timeval      st_mtime;   /* time of last modification */
-    struct timeval      st_ctime;   /* time of last status change */
+    struct timespec     st_atim;    /* time of last access */
+    struct timespec     st_mtim;    /* time of last modification */
+    struct timespec     st_ctim;    /* time of last status change */
     _cxgo_sint32   st_blksize; /* blocksize for filesystem I/O */
-    _cxgo_sint32    st_blocks;  /* number of blocks allocated */
+    _cxgo_sint64    st_blocks;  /* number of blocks allocated */
 };
 
+#define st_atime st_atim.tv_sec
+#define st_mtime st_mtim.tv_sec
+#define st_ctime st_ctim.tv_sec
+
 _cxgo_sint32  chmod(const char *, mode_t);
 int    fchmod(int, mode_t);
-int    fstat(int, struct stat *);
+_cxgo_sint32 fstat(_cxgo_go_uintptr, struct stat *);
 int    lstat(const char *restrict, struct stat *restrict);
 _cxgo_sint32  mkdir(const char *, mode_t);
 int    mkfifo(const char *, mode_t);
@@ -30,29 +34,30 @@ mode_t umask(mode_t);
 
 _cxgo_sint32 S_ISDIR(mode_t m);
 
-#define S_IRUSR 1
-#define S_IWUSR 1
-#define S_IXUSR 1
-
-#define S_IRGRP 1
-#define S_IWGRP 1
-#define S_IXGRP 1
-
-#define S_IROTH 1
-#define S_IWOTH 1
-#define S_IXOTH 1
-
-#define S_ISUID 1
-#define S_ISGID 1
-#define S_ISVTX 1
-
-#define S_IFMT 1
-#define S_IFLNK 1
-#define S_IFREG 1
-#define S_IFCHR 1
-#define S_IFBLK 1
-#define S_IFIFO 1
-#define S_IFSOCK 1
-#define S_IFDIR 1
-
-int S_ISLNK(int);
+#define S_IRWXU 00700
+#define S_IRUSR 00400
+#define S_IWUSR 00200
+#define S_IXUSR 00100
+#define S_IRWXG 00070
+#define S_IRGRP 00040
+#define S_IWGRP 00020
+#define S_IXGRP 00010
+#define S_IRWXO 00007
+#define S_IROTH 00004
+#define S_IWOTH 00002
+#define S_IXOTH 00001
+#define S_ISUID 0004000
+#define S_ISGID 0002000
+#define S_ISVTX 0001000
+
+#define S_IFMT   0170000
+#define S_IFSOCK 0140000
+#define S_IFLNK  0120000
+#define S_IFREG  0100000
+#define S_IFBLK  0060000
+#define S_IFDIR  0040000
+#define S_IFCHR  0020000
+#define S_IFIFO  0010000
+
+_cxgo_sint32 S_ISREG(mode_t m);
+_cxgo_sint32 S_ISLNK(mode_t m);
@@ -20,3 +20,7 @@ const _cxgo_int32 O_RDWR = 3;
 const _cxgo_int32 O_CREAT = 4;
 const _cxgo_int32 O_EXCL = 5;
 const _cxgo_int32 O_TRUNC = 6;
+const _cxgo_int32 O_APPEND = 7;
+const _cxgo_int32 O_SYNC = 8;
+const _cxgo_int32 O_NONBLOCK = 9;
+const _cxgo_int32 O_ACCMODE = 10;
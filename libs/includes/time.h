@@ -2,6 +2,8 @@
 
 const _cxgo_go_int CLOCK_REALTIME = 0;
 const _cxgo_go_int CLOCK_MONOTONIC = 1;
+const _cxgo_go_int CLOCK_PROCESS_CPUTIME_ID = 2;
+const _cxgo_go_int CLOCK_THREAD_CPUTIME_ID = 3;
 const _cxgo_go_int CLOCKS_PER_SEC = 1000000;
 
 typedef _cxgo_int32 time_t;
@@ -20,7 +22,7 @@ struct tm {
     _cxgo_int32    tm_wday;
     _cxgo_int32    tm_yday;
     _cxgo_int32    tm_isdst;
-    void*  tm_zone;
+    const char*  tm_zone;
     _cxgo_int32    tm_gmtoff;
 };
 struct timeval {
@@ -56,4 +58,9 @@ int        timer_delete(timer_t);
 int        timer_gettime(timer_t, struct itimerspec *);
 int        timer_getoverrun(timer_t);
 int        timer_settime(timer_t, int, const struct itimerspec *, struct itimerspec *);
+time_t     timegm(struct tm *);
 void       tzset(void);
+
+long timezone;
+int daylight;
+char *tzname[2];
@@ -11,11 +11,12 @@ _cxgo_sint32 access(const char *, _cxgo_sint32);
 unsigned     alarm(unsigned);
 _cxgo_sint32 chdir(const char *);
 _cxgo_sint32 fchdir(int fd);
-int          chown(const char *, uid_t, gid_t);
-#define close(fd) _cxgo_fileByFD((_cxgo_go_uintptr)fd)->Close()
+_cxgo_sint32 chown(const char *, uid_t, gid_t);
+_cxgo_sint32 close(_cxgo_go_uintptr);
 size_t       confstr(int, char *, size_t);
-int          dup(int);
-int          dup2(int, int);
+_cxgo_go_uintptr dup(_cxgo_go_uintptr);
+_cxgo_go_uintptr dup2(_cxgo_go_uintptr, _cxgo_go_uintptr);
+_cxgo_sint32 getdtablesize(void);
 int          execl(const char *, const char *, ...);
 int          execle(const char *, const char *, ...);
 int          execlp(const char *, const char *, ...);
@@ -45,9 +46,9 @@ int          link(const char *, const char *);
 _cxgo_uint64 lseek(_cxgo_go_uintptr, _cxgo_uint64, _cxgo_sint32);
 long         pathconf(const char *, int);
 int          pause(void);
-int          pipe(int [2]);
-#define read(fd, p, sz) _cxgo_fileByFD((_cxgo_go_uintptr)fd)->Read(p, sz)
-#define write(fd, p, sz) _cxgo_fileByFD((_cxgo_go_uintptr)fd)->Write(p, sz)
+_cxgo_sint32 pipe(_cxgo_sint32 [2]);
+_cxgo_go_int read(_cxgo_go_uintptr, void*, _cxgo_go_int);
+_cxgo_go_int write(_cxgo_go_uintptr, void*, _cxgo_go_int);
 ssize_t      readlink(const char *restrict, char *restrict, size_t);
 _cxgo_sint32 rmdir(const char *);
 int          setegid(gid_t);
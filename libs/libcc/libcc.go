@@ -8,22 +8,30 @@ import (
 )
 
 func NewABI(c *types.Env) cc.ABI {
+	conf := c.Config()
 	intSize := c.IntSize()
 	ptrSize := c.PtrSize()
+	longSize := conf.LongSize
+	align64 := conf.Align64
+	var order binary.ByteOrder = binary.LittleEndian
+	if conf.BigEndian {
+		order = binary.BigEndian
+	}
 	return cc.ABI{
-		ByteOrder: binary.LittleEndian,
+		ByteOrder:  order,
+		SignedChar: !conf.UnsignedChar,
 		Types: map[cc.Kind]cc.ABIType{
 			cc.Bool:      {1, 1, 1},
 			cc.Char:      {1, 1, 1},
 			cc.Int:       {uintptr(intSize), intSize, intSize},
-			cc.Long:      {uintptr(ptrSize), ptrSize, ptrSize},
-			cc.LongLong:  {8, 8, intSize},
+			cc.Long:      {uintptr(longSize), longSize, longSize},
+			cc.LongLong:  {8, 8, align64},
 			cc.SChar:     {1, 1, 1},
 			cc.Short:     {2, 2, 2},
 			cc.UChar:     {1, 1, 1},
 			cc.UInt:      {uintptr(intSize), intSize, intSize},
-			cc.ULong:     {uintptr(ptrSize), ptrSize, ptrSize},
-			cc.ULongLong: {8, 8, intSize},
+			cc.ULong:     {uintptr(longSize), longSize, longSize},
+			cc.ULongLong: {8, 8, align64},
 			cc.UShort:    {2, 2, 2},
 
 			cc.Int8:   {1, 1, 1},
@@ -32,12 +40,12 @@ func NewABI(c *types.Env) cc.ABI {
 			cc.UInt16: {2, 2, 2},
 			cc.Int32:  {4, 4, 4},
 			cc.UInt32: {4, 4, 4},
-			cc.Int64:  {8, intSize, intSize},
-			cc.UInt64: {8, intSize, intSize},
+			cc.Int64:  {8, align64, align64},
+			cc.UInt64: {8, align64, align64},
 
-			cc.Float:      {4, 4, intSize},
-			cc.Double:     {8, 8, intSize},
-			cc.LongDouble: {8, 8, intSize},
+			cc.Float:      {4, 4, 4},
+			cc.Double:     {8, 8, align64},
+			cc.LongDouble: {8, 8, align64},
 
 			cc.Void: {1, 1, 1},
 			cc.Ptr:  {uintptr(ptrSize), ptrSize, ptrSize},
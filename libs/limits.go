@@ -20,7 +20,12 @@ func init() {
 		idents := make(map[string]*types.Ident)
 		intMinMax(&buf, idents, "SCHAR", "Int", math.MinInt8, math.MaxInt8, 8)
 		uintMax(&buf, idents, "UCHAR", "Uint", math.MaxUint8, 8)
-		intMinMax(&buf, idents, "CHAR", "Int", math.MinInt8, math.MaxInt8, 8)
+		if c.Config().UnsignedChar {
+			buf.WriteString("#define CHAR_MIN 0\n")
+			uintMax(&buf, idents, "CHAR", "Uint", math.MaxUint8, 8)
+		} else {
+			intMinMax(&buf, idents, "CHAR", "Int", math.MinInt8, math.MaxInt8, 8)
+		}
 
 		intMinMax(&buf, idents, "SHRT", "Int", math.MinInt16, math.MaxInt16, 16)
 		uintMax(&buf, idents, "USHRT", "Uint", math.MaxUint16, 16)
@@ -29,11 +34,15 @@ func init() {
 		case 4:
 			intMinMax(&buf, idents, "INT", "Int", math.MinInt32, math.MaxInt32, 32)
 			uintMax(&buf, idents, "UINT", "Uint", math.MaxUint32, 32)
-			intMinMax(&buf, idents, "LONG", "Int", math.MinInt32, math.MaxInt32, 32)
-			uintMax(&buf, idents, "ULONG", "Uint", math.MaxUint32, 32)
 		case 8:
 			intMinMax(&buf, idents, "INT", "Int", math.MinInt64, math.MaxInt64, 64)
 			uintMax(&buf, idents, "UINT", "Uint", math.MaxUint64, 64)
+		}
+		switch c.C().Long().Sizeof() {
+		case 4:
+			intMinMax(&buf, idents, "LONG", "Int", math.MinInt32, math.MaxInt32, 32)
+			uintMax(&buf, idents, "ULONG", "Uint", math.MaxUint32, 32)
+		case 8:
 			intMinMax(&buf, idents, "LONG", "Int", math.MinInt64, math.MaxInt64, 64)
 			uintMax(&buf, idents, "ULONG", "Uint", math.MaxUint64, 64)
 		}
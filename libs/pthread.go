@@ -16,12 +16,14 @@ func init() {
 		argT := c.PtrT(nil)
 		retT := c.PtrT(nil)
 		timespecT := c.GetLibraryType(timeH, "timespec")
-		onceT := types.NamedTGo("pthread_once_t", "sync.Once", c.MethStructT(map[string]*types.FuncType{
-			"Do": c.FuncTT(nil, c.FuncTT(nil)),
+		clockIDT := c.GetLibraryType(timeH, "clockid_t")
+		onceT := types.NamedTGo("pthread_once_t", "pthread.Once", c.MethStructT(map[string]*types.FuncType{
+			"CDo": c.FuncTT(intT, c.FuncTT(nil)),
 		}))
 		mutexAttrT := types.NamedTGo("pthread_mutexattr_t", "pthread.MutexAttr", c.MethStructT(map[string]*types.FuncType{
 			"Init":    c.FuncTT(intT),
 			"SetType": c.FuncTT(intT, intT),
+			"GetType": c.FuncTT(intT, c.PtrT(intT)),
 			"Destroy": c.FuncTT(intT),
 		}))
 		mutexT := types.NamedTGo("pthread_mutex_t", "pthread.Mutex", c.MethStructT(map[string]*types.FuncType{
@@ -32,38 +34,80 @@ func init() {
 			"TimedLock": c.FuncTT(intT, c.PtrT(timespecT)),
 			"CUnlock":   c.FuncTT(intT),
 		}))
-		condAttrT := types.NamedTGo("pthread_condattr_t", "pthread.CondAttr", types.StructT(nil))
-		condT := types.NamedTGo("pthread_cond_t", "sync.Cond", types.StructT([]*types.Field{
-			{Name: types.NewIdent("L", c.PtrT(mutexT))},
-			{Name: types.NewIdent("Wait", c.FuncTT(nil))},
-			{Name: types.NewIdent("Signal", c.FuncTT(nil))},
-			{Name: types.NewIdent("Broadcast", c.FuncTT(nil))},
+		rwlockAttrT := types.NamedTGo("pthread_rwlockattr_t", "pthread.RWLockAttr", c.MethStructT(map[string]*types.FuncType{
+			"Init":    c.FuncTT(intT),
+			"Destroy": c.FuncTT(intT),
+		}))
+		rwlockT := types.NamedTGo("pthread_rwlock_t", "pthread.RWLock", c.MethStructT(map[string]*types.FuncType{
+			"Init":        c.FuncTT(intT, c.PtrT(rwlockAttrT)),
+			"Destroy":     c.FuncTT(intT),
+			"RdLock":      c.FuncTT(intT),
+			"TryRdLock":   c.FuncTT(intT),
+			"TimedRdLock": c.FuncTT(intT, c.PtrT(timespecT)),
+			"WrLock":      c.FuncTT(intT),
+			"TryWrLock":   c.FuncTT(intT),
+			"TimedWrLock": c.FuncTT(intT, c.PtrT(timespecT)),
+			"CUnlock":     c.FuncTT(intT),
+		}))
+		condAttrT := types.NamedTGo("pthread_condattr_t", "pthread.CondAttr", c.MethStructT(map[string]*types.FuncType{
+			"Init":     c.FuncTT(intT),
+			"Destroy":  c.FuncTT(intT),
+			"SetClock": c.FuncTT(intT, clockIDT),
+			"GetClock": c.FuncTT(intT, c.PtrT(clockIDT)),
+		}))
+		condT := types.NamedTGo("pthread_cond_t", "pthread.Cond", c.MethStructT(map[string]*types.FuncType{
+			"Init":      c.FuncTT(intT, c.PtrT(condAttrT)),
+			"Destroy":   c.FuncTT(intT),
+			"Wait":      c.FuncTT(intT, c.PtrT(mutexT)),
+			"TimedWait": c.FuncTT(intT, c.PtrT(mutexT), c.PtrT(timespecT)),
+			"Signal":    c.FuncTT(intT),
+			"Broadcast": c.FuncTT(intT),
 		}))
 		threadT := types.NamedTGo("pthread_t", "pthread.Thread", c.MethStructT(map[string]*types.FuncType{
 			"Join":        c.FuncTT(intT, c.PtrT(retT)),
 			"TimedJoinNP": c.FuncTT(intT, c.PtrT(retT), c.PtrT(timespecT)),
+			"Detach":      c.FuncTT(intT),
+		}))
+		threadAttrT := types.NamedTGo("pthread_attr_t", "pthread.Attr", c.MethStructT(map[string]*types.FuncType{
+			"Init":           c.FuncTT(intT),
+			"Destroy":        c.FuncTT(intT),
+			"SetDetachState": c.FuncTT(intT, intT),
+			"GetDetachState": c.FuncTT(intT, c.PtrT(intT)),
 		}))
-		threadAttrT := types.NamedTGo("pthread_attr_t", "pthread.Attr", c.MethStructT(map[string]*types.FuncType{}))
+		keyT := types.NamedTGo("pthread_key_t", "pthread.Key", types.UintT(4))
 		return &Library{
 			Imports: map[string]string{
-				"sync":    "sync",
 				"pthread": RuntimePrefix + "pthread",
 			},
 			Types: map[string]types.Type{
-				"pthread_t_":          threadT,
-				"pthread_t":           c.PtrT(threadT),
-				"pthread_once_t":      onceT,
-				"pthread_cond_t":      condT,
-				"pthread_condattr_t":  condAttrT,
-				"pthread_attr_t":      threadAttrT,
-				"pthread_mutex_t":     mutexT,
-				"pthread_mutexattr_t": mutexAttrT,
+				"pthread_t_":           threadT,
+				"pthread_t":            c.PtrT(threadT),
+				"pthread_once_t":       onceT,
+				"pthread_cond_t":       condT,
+				"pthread_condattr_t":   condAttrT,
+				"pthread_attr_t":       threadAttrT,
+				"pthread_mutex_t":      mutexT,
+				"pthread_mutexattr_t":  mutexAttrT,
+				"pthread_key_t":        keyT,
+				"pthread_rwlock_t":     rwlockT,
+				"pthread_rwlockattr_t": rwlockAttrT,
 			},
 			Idents: map[string]*types.Ident{
-				"PTHREAD_MUTEX_RECURSIVE": c.NewIdent("PTHREAD_MUTEX_RECURSIVE", "pthread.MUTEX_RECURSIVE", pthread.MUTEX_RECURSIVE, gintT),
-				"pthread_create":          c.NewIdent("pthread_create", "pthread.Create", pthread.Create, c.FuncTT(intT, c.PtrT(c.PtrT(threadT)), c.PtrT(threadAttrT), c.FuncTT(retT, argT), argT)),
-				"pthread_cond_init":       c.NewIdent("pthread_cond_init", "pthread.CondInit", pthread.CondInit, c.FuncTT(intT, c.PtrT(condT), c.PtrT(condAttrT))),
-				"pthread_cond_destroy":    c.NewIdent("pthread_cond_destroy", "pthread.CondFree", pthread.CondFree, c.FuncTT(intT, c.PtrT(condT))),
+				"PTHREAD_MUTEX_NORMAL":          c.NewIdent("PTHREAD_MUTEX_NORMAL", "pthread.MUTEX_NORMAL", pthread.MUTEX_NORMAL, gintT),
+				"PTHREAD_MUTEX_RECURSIVE":       c.NewIdent("PTHREAD_MUTEX_RECURSIVE", "pthread.MUTEX_RECURSIVE", pthread.MUTEX_RECURSIVE, gintT),
+				"PTHREAD_MUTEX_ERRORCHECK":      c.NewIdent("PTHREAD_MUTEX_ERRORCHECK", "pthread.MUTEX_ERRORCHECK", pthread.MUTEX_ERRORCHECK, gintT),
+				"PTHREAD_MUTEX_DEFAULT":         c.NewIdent("PTHREAD_MUTEX_DEFAULT", "pthread.MUTEX_DEFAULT", pthread.MUTEX_DEFAULT, gintT),
+				"PTHREAD_CREATE_JOINABLE":       c.NewIdent("PTHREAD_CREATE_JOINABLE", "pthread.CREATE_JOINABLE", pthread.CREATE_JOINABLE, gintT),
+				"PTHREAD_CREATE_DETACHED":       c.NewIdent("PTHREAD_CREATE_DETACHED", "pthread.CREATE_DETACHED", pthread.CREATE_DETACHED, gintT),
+				"PTHREAD_DESTRUCTOR_ITERATIONS": c.NewIdent("PTHREAD_DESTRUCTOR_ITERATIONS", "pthread.DESTRUCTOR_ITERATIONS", pthread.DESTRUCTOR_ITERATIONS, gintT),
+				"pthread_create":                c.NewIdent("pthread_create", "pthread.Create", pthread.Create, c.FuncTT(intT, c.PtrT(c.PtrT(threadT)), c.PtrT(threadAttrT), c.FuncTT(retT, argT), argT)),
+				"pthread_self":                  c.NewIdent("pthread_self", "pthread.Self", pthread.Self, c.FuncTT(c.PtrT(threadT))),
+				"pthread_equal":                 c.NewIdent("pthread_equal", "pthread.Equal", pthread.Equal, c.FuncTT(intT, c.PtrT(threadT), c.PtrT(threadT))),
+				"pthread_exit":                  c.NewIdent("pthread_exit", "pthread.Exit", pthread.Exit, c.FuncTT(nil, retT)),
+				"pthread_key_create":            c.NewIdent("pthread_key_create", "pthread.KeyCreate", pthread.KeyCreate, c.FuncTT(intT, c.PtrT(keyT), c.FuncTT(nil, argT))),
+				"pthread_key_delete":            c.NewIdent("pthread_key_delete", "pthread.KeyDelete", pthread.KeyDelete, c.FuncTT(intT, keyT)),
+				"pthread_getspecific":           c.NewIdent("pthread_getspecific", "pthread.GetSpecific", pthread.GetSpecific, c.FuncTT(retT, keyT)),
+				"pthread_setspecific":           c.NewIdent("pthread_setspecific", "pthread.SetSpecific", pthread.SetSpecific, c.FuncTT(intT, keyT, argT)),
 			},
 		}
 	})
@@ -8,11 +8,15 @@ const (
 	setjmpH = "setjmp.h"
 )
 
+// SetJumpFunc is a name of the function that setjmp macro expands to.
+//
+// The translator recognizes calls to it and rewrites the rest of the function to a libc.SetJump closure.
+const SetJumpFunc = "_cxgo_setjmp"
+
 func init() {
 	RegisterLibrary(setjmpH, func(c *Env) *Library {
 		gint := c.Go().Int()
 		bufT := types.NamedTGo("jmp_buf", "libc.JumpBuf", c.MethStructT(map[string]*types.FuncType{
-			"SetJump":  c.FuncTT(gint),
 			"LongJump": c.FuncTT(nil, gint),
 		}))
 		return &Library{
@@ -22,6 +26,9 @@ func init() {
 			Imports: map[string]string{
 				"libc": RuntimeLibc,
 			},
+			Idents: map[string]*types.Ident{
+				SetJumpFunc: types.NewIdentGo(SetJumpFunc, "libc.SetJump", c.FuncTT(gint, c.PtrT(bufT))),
+			},
 		}
 	})
 }
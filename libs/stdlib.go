@@ -33,7 +33,7 @@ func init() {
 				"_Exit":    c.Go().OsExitFunc(),
 				"malloc":   c.C().MallocFunc(),
 				"calloc":   c.C().CallocFunc(),
-				"realloc":  c.NewIdent("realloc", "libc.Realloc", libc.Realloc, c.FuncTT(voidPtr, voidPtr, gintT)),
+				"realloc":  c.C().ReallocFunc(),
 				"free":     c.C().FreeFunc(),
 				"atoi":     c.NewIdent("atoi", "libc.Atoi", libc.Atoi, c.FuncTT(gintT, gstrT)),
 				"atol":     c.NewIdent("atol", "libc.Atoi", libc.Atoi, c.FuncTT(gintT, gstrT)),
@@ -20,6 +20,11 @@ func init() {
 				"O_CREAT":  c.NewIdent("O_CREAT", "csys.O_CREAT", csys.O_CREAT, intT),
 				"O_EXCL":   c.NewIdent("O_EXCL", "csys.O_EXCL", csys.O_EXCL, intT),
 				"O_TRUNC":  c.NewIdent("O_TRUNC", "csys.O_TRUNC", csys.O_TRUNC, intT),
+
+				"O_APPEND":   c.NewIdent("O_APPEND", "csys.O_APPEND", csys.O_APPEND, intT),
+				"O_SYNC":     c.NewIdent("O_SYNC", "csys.O_SYNC", csys.O_SYNC, intT),
+				"O_NONBLOCK": c.NewIdent("O_NONBLOCK", "csys.O_NONBLOCK", csys.O_NONBLOCK, intT),
+				"O_ACCMODE":  c.NewIdent("O_ACCMODE", "csys.O_ACCMODE", csys.O_ACCMODE, intT),
 			},
 		}
 	})
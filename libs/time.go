@@ -37,7 +37,7 @@ func init() {
 			{Name: types.NewIdentGo("tm_wday", "WeekDay", intT)},
 			{Name: types.NewIdentGo("tm_yday", "YearDay", intT)},
 			{Name: types.NewIdentGo("tm_isdst", "IsDst", intT)},
-			{Name: types.NewIdentGo("tm_zone", "Timezone", c.PtrT(nil))},
+			{Name: types.NewIdentGo("tm_zone", "Timezone", strT)},
 			{Name: types.NewIdentGo("tm_gmtoff", "GMTOffs", intT)},
 		}))
 		return &Library{
@@ -54,17 +54,33 @@ func init() {
 				"timespec":  tsT,
 			},
 			Idents: map[string]*types.Ident{
-				"time":            c.NewIdent("time", "libc.GetTime", libc.GetTime, c.FuncTT(timeT, c.PtrT(timeT))),
-				"mktime":          c.NewIdent("mktime", "libc.MakeTime", libc.MakeTime, c.FuncTT(timeT, c.PtrT(tmT))),
-				"localtime":       c.NewIdent("localtime", "libc.LocalTime", libc.LocalTime, c.FuncTT(c.PtrT(tmT), c.PtrT(timeT))),
-				"clock":           c.NewIdent("clock", "libc.ClockTicks", libc.ClockTicks, c.FuncTT(clockT)),
-				"clock_getres":    c.NewIdent("clock_getres", "libc.ClockGetRes", libc.ClockGetRes, c.FuncTT(intT, clockT, c.PtrT(tsT))),
-				"clock_settime":   c.NewIdent("clock_settime", "libc.ClockSetTime", libc.ClockSetTime, c.FuncTT(intT, clockT, c.PtrT(tsT))),
-				"clock_gettime":   c.NewIdent("clock_gettime", "libc.ClockGetTime", libc.ClockGetTime, c.FuncTT(intT, clockT, c.PtrT(tsT))),
-				"asctime":         c.NewIdent("asctime", "libc.AscTime", libc.AscTime, c.FuncTT(strT, c.PtrT(tmT))),
-				"CLOCK_REALTIME":  c.NewIdent("CLOCK_REALTIME", "libc.CLOCK_REALTIME", libc.CLOCK_REALTIME, gintT),
-				"CLOCK_MONOTONIC": c.NewIdent("CLOCK_MONOTONIC", "libc.CLOCK_MONOTONIC", libc.CLOCK_MONOTONIC, gintT),
-				"CLOCKS_PER_SEC":  c.NewIdent("CLOCKS_PER_SEC", "libc.CLOCKS_PER_SEC", libc.CLOCKS_PER_SEC, gintT),
+				"time":                     c.NewIdent("time", "libc.GetTime", libc.GetTime, c.FuncTT(timeT, c.PtrT(timeT))),
+				"mktime":                   c.NewIdent("mktime", "libc.MakeTime", libc.MakeTime, c.FuncTT(timeT, c.PtrT(tmT))),
+				"timegm":                   c.NewIdent("timegm", "libc.TimeGM", libc.TimeGM, c.FuncTT(timeT, c.PtrT(tmT))),
+				"localtime":                c.NewIdent("localtime", "libc.LocalTime", libc.LocalTime, c.FuncTT(c.PtrT(tmT), c.PtrT(timeT))),
+				"localtime_r":              c.NewIdent("localtime_r", "libc.LocalTimeR", libc.LocalTimeR, c.FuncTT(c.PtrT(tmT), c.PtrT(timeT), c.PtrT(tmT))),
+				"gmtime":                   c.NewIdent("gmtime", "libc.GMTime", libc.GMTime, c.FuncTT(c.PtrT(tmT), c.PtrT(timeT))),
+				"gmtime_r":                 c.NewIdent("gmtime_r", "libc.GMTimeR", libc.GMTimeR, c.FuncTT(c.PtrT(tmT), c.PtrT(timeT), c.PtrT(tmT))),
+				"difftime":                 c.NewIdent("difftime", "libc.DiffTime", libc.DiffTime, c.FuncTT(types.FloatT(8), timeT, timeT)),
+				"tzset":                    c.NewIdent("tzset", "libc.Tzset", libc.Tzset, c.FuncTT(nil)),
+				"timezone":                 c.NewIdent("timezone", "libc.Timezone", libc.Timezone, longT),
+				"daylight":                 c.NewIdent("daylight", "libc.Daylight", libc.Daylight, intT),
+				"tzname":                   c.NewIdent("tzname", "libc.TZName", libc.TZName, types.ArrayT(strT, 2)),
+				"clock":                    c.NewIdent("clock", "libc.ClockTicks", libc.ClockTicks, c.FuncTT(clockT)),
+				"clock_getres":             c.NewIdent("clock_getres", "libc.ClockGetRes", libc.ClockGetRes, c.FuncTT(intT, clockIDT, c.PtrT(tsT))),
+				"clock_settime":            c.NewIdent("clock_settime", "libc.ClockSetTime", libc.ClockSetTime, c.FuncTT(intT, clockIDT, c.PtrT(tsT))),
+				"clock_gettime":            c.NewIdent("clock_gettime", "libc.ClockGetTime", libc.ClockGetTime, c.FuncTT(intT, clockIDT, c.PtrT(tsT))),
+				"asctime":                  c.NewIdent("asctime", "libc.AscTime", libc.AscTime, c.FuncTT(strT, c.PtrT(tmT))),
+				"asctime_r":                c.NewIdent("asctime_r", "libc.AscTimeR", libc.AscTimeR, c.FuncTT(strT, c.PtrT(tmT), strT)),
+				"ctime":                    c.NewIdent("ctime", "libc.CTime", libc.CTime, c.FuncTT(strT, c.PtrT(timeT))),
+				"ctime_r":                  c.NewIdent("ctime_r", "libc.CTimeR", libc.CTimeR, c.FuncTT(strT, c.PtrT(timeT), strT)),
+				"strftime":                 c.NewIdent("strftime", "libc.StrFTime", libc.StrFTime, c.FuncTT(gintT, strT, gintT, strT, c.PtrT(tmT))),
+				"strptime":                 c.NewIdent("strptime", "libc.StrPTime", libc.StrPTime, c.FuncTT(strT, strT, strT, c.PtrT(tmT))),
+				"CLOCK_REALTIME":           c.NewIdent("CLOCK_REALTIME", "libc.CLOCK_REALTIME", libc.CLOCK_REALTIME, gintT),
+				"CLOCK_MONOTONIC":          c.NewIdent("CLOCK_MONOTONIC", "libc.CLOCK_MONOTONIC", libc.CLOCK_MONOTONIC, gintT),
+				"CLOCK_PROCESS_CPUTIME_ID": c.NewIdent("CLOCK_PROCESS_CPUTIME_ID", "libc.CLOCK_PROCESS_CPUTIME_ID", libc.CLOCK_PROCESS_CPUTIME_ID, gintT),
+				"CLOCK_THREAD_CPUTIME_ID":  c.NewIdent("CLOCK_THREAD_CPUTIME_ID", "libc.CLOCK_THREAD_CPUTIME_ID", libc.CLOCK_THREAD_CPUTIME_ID, gintT),
+				"CLOCKS_PER_SEC":           c.NewIdent("CLOCKS_PER_SEC", "libc.CLOCKS_PER_SEC", libc.CLOCKS_PER_SEC, gintT),
 			},
 		}
 	})
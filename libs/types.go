@@ -93,6 +93,14 @@ func (c *Env) checkType(t1 reflect.Type, t2 types.Type) error {
 			return fmt.Errorf("expected pointer, got: %T", t2)
 		}
 		return c.checkType(t1.Elem(), p.Elem())
+	case reflect.Array:
+		a, ok := t2.(types.ArrayType)
+		if !ok {
+			return fmt.Errorf("expected array, got: %T", t2)
+		} else if a.Len() != t1.Len() {
+			return fmt.Errorf("unexpected array length: %d vs %d", t1.Len(), a.Len())
+		}
+		return c.checkType(t1.Elem(), a.Elem())
 	case reflect.Func:
 		f, ok := t2.(*types.FuncType)
 		if !ok {
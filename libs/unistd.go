@@ -26,16 +26,24 @@ func init() {
 				"cnet":  RuntimePrefix + "cnet",
 			},
 			Idents: map[string]*types.Ident{
-				"creat":       c.NewIdent("creat", "stdio.Create", stdio.Create, c.FuncTT(fdT, strT, modeT)),
-				"open":        c.NewIdent("open", "stdio.Open", stdio.Open, c.VarFuncTT(fdT, strT, intT)),
-				"fcntl":       c.NewIdent("fcntl", "stdio.FDControl", stdio.FDControl, c.VarFuncTT(intT, fdT, intT)),
-				"chdir":       c.NewIdent("chdir", "stdio.Chdir", stdio.Chdir, c.FuncTT(intT, strT)),
-				"rmdir":       c.NewIdent("rmdir", "stdio.Rmdir", stdio.Rmdir, c.FuncTT(intT, strT)),
-				"unlink":      c.NewIdent("unlink", "stdio.Unlink", stdio.Unlink, c.FuncTT(intT, strT)),
-				"access":      c.NewIdent("access", "stdio.Access", stdio.Access, c.FuncTT(intT, strT, intT)),
-				"lseek":       c.NewIdent("lseek", "stdio.Lseek", stdio.Lseek, c.FuncTT(ulongT, fdT, ulongT, intT)),
-				"getcwd":      c.NewIdent("getcwd", "stdio.GetCwd", stdio.GetCwd, c.FuncTT(strT, strT, gintT)),
-				"gethostname": c.NewIdent("gethostname", "cnet.GetHostname", cnet.GetHostname, c.FuncTT(gintT, strT, gintT)),
+				"creat":         c.NewIdent("creat", "stdio.Create", stdio.Create, c.FuncTT(fdT, strT, modeT)),
+				"open":          c.NewIdent("open", "stdio.Open", stdio.Open, c.VarFuncTT(fdT, strT, intT)),
+				"fcntl":         c.NewIdent("fcntl", "stdio.FDControl", stdio.FDControl, c.VarFuncTT(intT, fdT, intT)),
+				"close":         c.NewIdent("close", "stdio.Close", stdio.Close, c.FuncTT(intT, fdT)),
+				"read":          c.NewIdent("read", "stdio.Read", stdio.Read, c.FuncTT(gintT, fdT, c.PtrT(nil), gintT)),
+				"write":         c.NewIdent("write", "stdio.Write", stdio.Write, c.FuncTT(gintT, fdT, c.PtrT(nil), gintT)),
+				"dup":           c.NewIdent("dup", "stdio.Dup", stdio.Dup, c.FuncTT(fdT, fdT)),
+				"dup2":          c.NewIdent("dup2", "stdio.Dup2", stdio.Dup2, c.FuncTT(fdT, fdT, fdT)),
+				"pipe":          c.NewIdent("pipe", "stdio.Pipe", stdio.Pipe, c.FuncTT(intT, c.PtrT(intT))),
+				"getdtablesize": c.NewIdent("getdtablesize", "stdio.GetDTableSize", stdio.GetDTableSize, c.FuncTT(intT)),
+				"chown":         c.NewIdent("chown", "stdio.Chown", stdio.Chown, c.FuncTT(intT, strT, types.UintT(4), types.UintT(4))),
+				"chdir":         c.NewIdent("chdir", "stdio.Chdir", stdio.Chdir, c.FuncTT(intT, strT)),
+				"rmdir":         c.NewIdent("rmdir", "stdio.Rmdir", stdio.Rmdir, c.FuncTT(intT, strT)),
+				"unlink":        c.NewIdent("unlink", "stdio.Unlink", stdio.Unlink, c.FuncTT(intT, strT)),
+				"access":        c.NewIdent("access", "stdio.Access", stdio.Access, c.FuncTT(intT, strT, intT)),
+				"lseek":         c.NewIdent("lseek", "stdio.Lseek", stdio.Lseek, c.FuncTT(ulongT, fdT, ulongT, intT)),
+				"getcwd":        c.NewIdent("getcwd", "stdio.GetCwd", stdio.GetCwd, c.FuncTT(strT, strT, gintT)),
+				"gethostname":   c.NewIdent("gethostname", "cnet.GetHostname", cnet.GetHostname, c.FuncTT(gintT, strT, gintT)),
 			},
 		}
 	})
@@ -0,0 +1,41 @@
+package libs
+
+import (
+	"github.com/gotranspile/cxgo/runtime/stdio"
+	"github.com/gotranspile/cxgo/types"
+)
+
+const (
+	utimeH = "utime.h"
+)
+
+func init() {
+	RegisterLibrary(utimeH, func(c *Env) *Library {
+		timeT := c.GetLibraryType(timeH, "time_t")
+		utimbufT := types.NamedTGo("utimbuf", "csys.UTimBuf", types.StructT([]*types.Field{
+			{Name: types.NewIdentGo("actime", "ATime", timeT)},
+			{Name: types.NewIdentGo("modtime", "MTime", timeT)},
+		}))
+		l := &Library{
+			Imports: map[string]string{
+				"csys":  RuntimePrefix + "csys",
+				"stdio": RuntimePrefix + "stdio",
+			},
+			Types: map[string]types.Type{
+				"utimbuf": utimbufT,
+			},
+			Header: `
+#include <` + timeH + `>
+
+typedef struct utimbuf {
+	time_t actime;
+	time_t modtime;
+} utimbuf;
+`,
+		}
+		l.Declare(
+			c.NewIdent("utime", "stdio.Utime", stdio.Utime, c.FuncTT(types.IntT(4), c.C().String(), c.PtrT(utimbufT))),
+		)
+		return l
+	})
+}
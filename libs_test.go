@@ -206,6 +206,146 @@ func foo() {
 	var p unsafe.Pointer = libc.Malloc(10)
 	_ = p
 }
+`,
+	},
+	{
+		name: "dlopen",
+		src: `
+#include <dlfcn.h>
+
+typedef int (*add_fn)(int, int);
+
+int call(const char* name) {
+	void* h = dlopen("libadd.so", RTLD_NOW);
+	if (!h) {
+		return -1;
+	}
+	add_fn add = (add_fn)dlsym(h, name);
+	if (!add) {
+		dlclose(h);
+		return -2;
+	}
+	int (*sub)(int, int) = dlsym(RTLD_DEFAULT, "sub");
+	int r = add(1, 2) + sub(2, 1);
+	dlclose(h);
+	return r;
+}
+`,
+		exp: `
+type add_fn func(int32, int32) int32
+
+func call(name *byte) int32 {
+	var h unsafe.Pointer = unsafe.Pointer(dlopen.Open("libadd.so", dlopen.RTLD_NOW))
+	if h == nil {
+		return -1
+	}
+	var add add_fn = libc.AsFunc(((*dlopen.Library)(h)).Sym(libc.GoString(name)), (*func(int32, int32) int32)(nil)).(func(int32, int32) int32)
+	if add == nil {
+		((*dlopen.Library)(h)).Close()
+		return -2
+	}
+	var sub func(int32, int32) int32 = libc.AsFunc(((*dlopen.Library)(nil)).Sym("sub"), (*func(int32, int32) int32)(nil)).(func(int32, int32) int32)
+	var r int32 = add(1, 2) + sub(2, 1)
+	((*dlopen.Library)(h)).Close()
+	return r
+}
+`,
+	},
+	{
+		name: "dirent",
+		src: `
+#include <dirent.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <utime.h>
+
+int count_files(const char* path) {
+	DIR* d = opendir(path);
+	if (!d) {
+		return -1;
+	}
+	int n = 0;
+	struct dirent* e;
+	while ((e = readdir(d)) != NULL) {
+		if (strcmp(e->d_name, ".") == 0 || strcmp(e->d_name, "..") == 0) {
+			continue;
+		}
+		if (e->d_type == DT_REG) {
+			n++;
+		}
+	}
+	closedir(d);
+	return n;
+}
+
+long touch(const char* path) {
+	struct stat st;
+	if (stat(path, &st) != 0 || !S_ISREG(st.st_mode)) {
+		mkdir(path, 0750);
+		return -1;
+	}
+	struct utimbuf t;
+	t.actime = st.st_atime;
+	t.modtime = 0;
+	utime(path, &t);
+	chmod(path, st.st_mode & 0644);
+	return st.st_size;
+}
+`,
+		exp: `
+func count_files(path *byte) int32 {
+	var d *stdio.Dir = stdio.OpenDir(libc.GoString(path))
+	if d == nil {
+		return -1
+	}
+	var n int32 = 0
+	var e *stdio.DirEnt
+	for (func() *stdio.DirEnt {
+		e = d.Read()
+		return e
+	}()) != nil {
+		if libc.StrCmp((*byte)(unsafe.Pointer(&e.Name[0])), libc.CString(".")) == 0 || libc.StrCmp((*byte)(unsafe.Pointer(&e.Name[0])), libc.CString("..")) == 0 {
+			continue
+		}
+		if int32(e.Type) == int32(csys.DT_REG) {
+			n++
+		}
+	}
+	d.Close()
+	return n
+}
+func touch(path *byte) int32 {
+	var st csys.StatRes
+	if stdio.Stat(path, &st) != 0 || csys.IsReg(st.Mode) == 0 {
+		stdio.Mkdir(path, 0o750)
+		return -1
+	}
+	var t csys.UTimBuf
+	t.ATime = st.ATim.Sec
+	t.MTime = 0
+	stdio.Utime(path, &t)
+	stdio.Chmod(path, st.Mode&0o644)
+	return int32(uint32(st.Size))
+}
+`,
+	},
+	{
+		name: "pthread mutex types",
+		src: `
+#include <pthread.h>
+
+int types[] = {PTHREAD_MUTEX_NORMAL, PTHREAD_MUTEX_RECURSIVE, PTHREAD_MUTEX_ERRORCHECK, PTHREAD_MUTEX_DEFAULT};
+
+void set_type(pthread_mutexattr_t* attr) {
+	pthread_mutexattr_settype(attr, PTHREAD_MUTEX_ERRORCHECK);
+}
+`,
+		exp: `
+var types [4]int32 = [4]int32{int32(pthread.MUTEX_NORMAL), int32(pthread.MUTEX_RECURSIVE), int32(pthread.MUTEX_ERRORCHECK), int32(pthread.MUTEX_DEFAULT)}
+
+func set_type(attr *pthread.MutexAttr) {
+	attr.SetType(int32(pthread.MUTEX_ERRORCHECK))
+}
 `,
 	},
 }
@@ -326,6 +466,273 @@ void foo() {
 int main() {
 	foo();
 }
+`,
+	},
+	{
+		name: "pthread",
+		src: `
+#include <stdio.h>
+#include <pthread.h>
+
+static pthread_key_t key;
+static int destroyed = 0;
+
+void dtor(void* p) {
+	destroyed += *(int*)p;
+}
+
+void* worker(void* arg) {
+	int* v = (int*)arg;
+	pthread_setspecific(key, v);
+	*(int*)pthread_getspecific(key) *= 2;
+	return arg;
+}
+
+int main() {
+	pthread_t th[3];
+	int vals[3] = {1, 2, 3};
+	pthread_key_create(&key, dtor);
+	for (int i = 0; i < 3; i++) {
+		pthread_create(&th[i], NULL, worker, &vals[i]);
+	}
+	int sum = 0;
+	for (int i = 0; i < 3; i++) {
+		void* ret;
+		pthread_join(th[i], &ret);
+		sum += *(int*)ret;
+	}
+	printf("%d %d %d\n", sum, destroyed, pthread_equal(pthread_self(), pthread_self()) != 0);
+	return 0;
+}
+`,
+	},
+	{
+		name: "pthread mutex",
+		src: `
+#include <stdio.h>
+#include <pthread.h>
+
+static pthread_mutex_t mu;
+static pthread_rwlock_t rw = PTHREAD_RWLOCK_INITIALIZER;
+static pthread_once_t once = PTHREAD_ONCE_INIT;
+static int counter = 0;
+static int inits = 0;
+
+void do_init() {
+	inits++;
+}
+
+void incr(int n) {
+	pthread_mutex_lock(&mu);
+	if (n > 0) {
+		counter++;
+		incr(n - 1);
+	}
+	pthread_mutex_unlock(&mu);
+}
+
+void* worker(void* arg) {
+	pthread_once(&once, do_init);
+	incr(3);
+	pthread_rwlock_rdlock(&rw);
+	pthread_rwlock_unlock(&rw);
+	return NULL;
+}
+
+int main() {
+	pthread_mutexattr_t attr;
+	pthread_mutexattr_init(&attr);
+	pthread_mutexattr_settype(&attr, PTHREAD_MUTEX_RECURSIVE);
+	pthread_mutex_init(&mu, &attr);
+	pthread_mutexattr_destroy(&attr);
+
+	pthread_t th[4];
+	for (int i = 0; i < 4; i++) {
+		pthread_create(&th[i], NULL, worker, NULL);
+	}
+	for (int i = 0; i < 4; i++) {
+		pthread_join(th[i], NULL);
+	}
+	pthread_rwlock_wrlock(&rw);
+	int busy = pthread_rwlock_tryrdlock(&rw) != 0;
+	pthread_rwlock_unlock(&rw);
+	printf("%d %d %d %d\n", counter, inits, busy, pthread_mutex_trylock(&mu));
+	pthread_mutex_unlock(&mu);
+	pthread_mutex_destroy(&mu);
+	return 0;
+}
+`,
+	},
+	{
+		name: "pthread cond",
+		src: `
+#include <stdio.h>
+#include <time.h>
+#include <pthread.h>
+
+static pthread_mutex_t mu = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t cond = PTHREAD_COND_INITIALIZER;
+static int queue = 0;
+static int consumed = 0;
+
+void* consumer(void* arg) {
+	for (int i = 0; i < 10; i++) {
+		pthread_mutex_lock(&mu);
+		while (queue == 0) {
+			pthread_cond_wait(&cond, &mu);
+		}
+		queue--;
+		consumed++;
+		pthread_mutex_unlock(&mu);
+	}
+	return NULL;
+}
+
+int main() {
+	pthread_t th;
+	pthread_create(&th, NULL, consumer, NULL);
+	for (int i = 0; i < 10; i++) {
+		pthread_mutex_lock(&mu);
+		queue++;
+		pthread_cond_signal(&cond);
+		pthread_mutex_unlock(&mu);
+	}
+	pthread_join(th, NULL);
+
+	pthread_condattr_t attr;
+	pthread_cond_t tcond;
+	pthread_condattr_init(&attr);
+	pthread_condattr_setclock(&attr, CLOCK_MONOTONIC);
+	pthread_cond_init(&tcond, &attr);
+	pthread_condattr_destroy(&attr);
+	struct timespec ts;
+	clock_gettime(CLOCK_MONOTONIC, &ts);
+	ts.tv_nsec += 1000000;
+	if (ts.tv_nsec >= 1000000000) {
+		ts.tv_sec++;
+		ts.tv_nsec -= 1000000000;
+	}
+	pthread_mutex_lock(&mu);
+	int timedout = pthread_cond_timedwait(&tcond, &mu, &ts) != 0;
+	pthread_mutex_unlock(&mu);
+	pthread_cond_destroy(&tcond);
+	printf("%d %d %d\n", consumed, queue, timedout);
+	return 0;
+}
+`,
+	}, {
+		name: "fd io",
+		src: `
+#include <stdio.h>
+#include <string.h>
+#include <fcntl.h>
+#include <unistd.h>
+
+int main() {
+	int fds[2];
+	if (pipe(fds) != 0) {
+		printf("pipe failed\n");
+		return 1;
+	}
+	printf("rd: %d, wr: %d\n", (fcntl(fds[0], F_GETFL) & O_ACCMODE) == O_RDONLY, (fcntl(fds[1], F_GETFL) & O_ACCMODE) == O_WRONLY);
+	int w2 = dup(fds[1]);
+	int w3 = fcntl(fds[1], F_DUPFD, 20);
+	printf("dup: %d, dupfd: %d\n", w2 > fds[1], w3 >= 20);
+	write(fds[1], "abc", 3);
+	write(w2, "def", 3);
+	close(fds[1]);
+	close(w2);
+	FILE* f = fdopen(w3, "w");
+	fputs("ghi", f);
+	fclose(f);
+	printf("closed: %d\n", write(w3, "x", 1));
+	char buf[16];
+	int n = 0;
+	for (;;) {
+		int r = read(fds[0], buf+n, sizeof(buf)-1-n);
+		if (r <= 0) break;
+		n += r;
+	}
+	buf[n] = 0;
+	printf("read: %d '%s'\n", n, buf);
+	int r2 = dup2(fds[0], 30);
+	printf("dup2: %d\n", r2 == 30);
+	close(fds[0]);
+	printf("eof: %d\n", read(30, buf, 1));
+	close(30);
+	return 0;
+}
+`,
+	}, {
+		name: "time",
+		src: `
+#define _GNU_SOURCE
+#include <stdio.h>
+#include <string.h>
+#include <time.h>
+
+int main() {
+	char buf[64];
+	time_t t = 1700000000;
+	struct tm tm;
+	gmtime_r(&t, &tm);
+	strftime(buf, sizeof(buf), "%a %d %b %Y %H:%M:%S %z, day %j, week %V", &tm);
+	printf("%s\n", buf);
+	printf("%s", asctime(&tm));
+
+	tm.tm_mday += 20;
+	tm.tm_hour = -1;
+	time_t t2 = timegm(&tm);
+	printf("%d %d %d %d\n", tm.tm_mon, tm.tm_mday, tm.tm_hour, tm.tm_wday);
+	printf("%.0f\n", difftime(t2, t));
+
+	memset(&tm, 0, sizeof(tm));
+	char* end = strptime("1999-03-05 07:05:01 PM!", "%Y-%m-%d %I:%M:%S %p", &tm);
+	printf("%s %d %d %d %d\n", end, tm.tm_hour, tm.tm_wday, tm.tm_yday, (int)timegm(&tm));
+	end = strptime("10:61", "%H:%M", &tm);
+	printf("%s %d\n", end, tm.tm_min);
+	printf("%d\n", strptime("24:00", "%H:%M", &tm) == NULL);
+
+	struct timespec ts;
+	printf("%d\n", clock_gettime(CLOCK_PROCESS_CPUTIME_ID, &ts));
+	printf("%d\n", clock_gettime(CLOCK_REALTIME, &ts));
+	printf("%d\n", ts.tv_sec > t);
+	return 0;
+}
+`,
+	},
+	{
+		name: "pthread exit main",
+		src: `
+#include <stdio.h>
+#include <pthread.h>
+
+static pthread_mutex_t mu = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t cond = PTHREAD_COND_INITIALIZER;
+static int ready = 0;
+
+void* worker(void* arg) {
+	pthread_mutex_lock(&mu);
+	while (!ready) {
+		pthread_cond_wait(&cond, &mu);
+	}
+	pthread_mutex_unlock(&mu);
+	printf("worker %d\n", pthread_equal(pthread_self(), *(pthread_t*)arg) != 0);
+	return NULL;
+}
+
+int main() {
+	static pthread_t th;
+	pthread_create(&th, NULL, worker, &th);
+	printf("main\n");
+	fflush(stdout);
+	pthread_mutex_lock(&mu);
+	ready = 1;
+	pthread_cond_signal(&cond);
+	pthread_mutex_unlock(&mu);
+	pthread_exit(NULL);
+	return 1;
+}
 `,
 	},
 }
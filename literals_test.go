@@ -502,12 +502,14 @@ var vendor [15]MYubyte = [15]MYubyte([]MYubyte("something here\x00"))
 #define MONE (-1)
 int x;
 int y = x - MONE;
+int z = x - (-1);
 `,
 		exp: `
 const MONE = -1
 
 var x int32
-var y int32 = x - (-1)
+var y int32 = x - MONE
+var z int32 = x - (-1)
 `,
 	},
 	{
@@ -2,13 +2,20 @@ package cxgo
 
 import (
 	"fmt"
+	"go/ast"
+	token2 "go/token"
+	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/gotranspile/cxgo/libs"
 	"github.com/gotranspile/cxgo/types"
 
 	"modernc.org/cc/v3"
+	"modernc.org/token"
 )
 
 func (g *translator) convertValue(v cc.Value) Expr {
@@ -43,7 +50,7 @@ func (g *translator) convertMacros(ast *cc.AST) []CDecl {
 			continue
 		}
 		if mc.IsFnLike() {
-			continue // we don't support function macros yet
+			continue // converted from wrapper functions, see macroFuncSource
 		}
 		if len(mc.ReplacementTokens()) == 0 {
 			continue // no value
@@ -58,6 +65,9 @@ func (g *translator) convertMacros(ast *cc.AST) []CDecl {
 		if val := g.evalMacro(mc.m, ast); val != nil {
 			typ := val.CType(nil)
 			id := types.NewIdent(mc.name, typ)
+			if c, ok := g.idents[mc.name]; ok && c.Rename != "" {
+				id.GoName = c.Rename
+			}
 			decls = append(decls, &CVarDecl{Const: true, CVarSpec: CVarSpec{
 				g: g, Type: typ,
 				Names: []*types.Ident{id},
@@ -112,3 +122,610 @@ func evalMacro2(m *cc.Macro, ast *cc.AST) Expr {
 		return nil
 	}
 }
+
+// Object-like macro references
+//
+// The preprocessor substitutes macros before the parser sees the code, but it keeps the position of the macro name
+// for all the tokens of the expansion. Single-token expansions additionally record the macro name (see convMacro).
+// For multi-token expansions, we find all tokens that share the same position, and if an expression covers exactly
+// those tokens, and they match the full expansion of an object-like macro, the expression is replaced with a reference
+// to the constant generated for that macro.
+
+// macroSources are sources defined by cxgo itself. Macros from them are never emitted as Go constants.
+var macroSources = map[string]struct{}{
+	"predef.h":              {},
+	"cxgo_predef.h":         {},
+	"cxgo_config_defines.h": {},
+	macroTypeSource:         {},
+}
+
+// isUserMacro checks if the object-like macro is defined in the user code and will be emitted as a Go constant.
+func (g *translator) isUserMacro(name string, m *cc.Macro) bool {
+	if m.IsFnLike() || g.env.ForceMacro(name) {
+		return false
+	}
+	if isCxgoSource(m.Position().Filename) {
+		return false
+	}
+	return g.evalMacro(m, g.file) != nil
+}
+
+// isCxgoSource checks if the file is generated by cxgo or cc, or is one of cxgo library headers.
+func isCxgoSource(fname string) bool {
+	_, ok := macroSources[fname]
+	return ok || fname == "" || strings.HasPrefix(fname, "<") || strings.HasPrefix(fname, libs.IncludePath)
+}
+
+// macroExpansion returns values of all tokens of a fully expanded object-like macro.
+func macroExpansion(ast *cc.AST, m *cc.Macro, seen map[*cc.Macro]struct{}, out []string) []string {
+	seen[m] = struct{}{}
+	defer delete(seen, m)
+	for _, t := range m.ReplacementTokens() {
+		v := strings.TrimSpace(t.Value.String())
+		if v == "" {
+			continue
+		}
+		if sub, ok := ast.Macros[t.Value]; ok && !sub.IsFnLike() {
+			if _, rec := seen[sub]; !rec {
+				out = macroExpansion(ast, sub, seen, out)
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// macroExpansions returns a map from a full expansion of multi-token user macros to their names.
+// If more than one macro expands to the same tokens, the name is empty.
+func (g *translator) macroExpansions() map[string]string {
+	if g.macroExps != nil {
+		return g.macroExps
+	}
+	g.macroExps = make(map[string]string)
+	for name, m := range g.file.Macros {
+		toks := macroExpansion(g.file, m, make(map[*cc.Macro]struct{}), nil)
+		if len(toks) < 2 || !g.isUserMacro(name.String(), m) {
+			continue
+		}
+		key := strings.Join(toks, " ")
+		if _, ok := g.macroExps[key]; ok {
+			g.macroExps[key] = "" // ambiguous
+		} else {
+			g.macroExps[key] = name.String()
+		}
+	}
+	return g.macroExps
+}
+
+// nodeTokens returns all tokens of a node, in the order they were seen by the parser.
+func nodeTokens(n cc.Node) []*cc.Token {
+	var toks []*cc.Token
+	seen := make(map[int]struct{})
+	cc.Inspect(n, func(n cc.Node, _ bool) bool {
+		if t, ok := n.(*cc.Token); ok && t.Seq() != 0 {
+			if _, dup := seen[t.Seq()]; !dup {
+				seen[t.Seq()] = struct{}{}
+				toks = append(toks, t)
+			}
+		}
+		return true
+	})
+	sort.Slice(toks, func(i, j int) bool {
+		return toks[i].Seq() < toks[j].Seq()
+	})
+	return toks
+}
+
+// macroSites returns a map from macro use sites to all tokens expanded there.
+func (g *translator) macroSites() map[token.Position][]*cc.Token {
+	if g.macroToks != nil {
+		return g.macroToks
+	}
+	g.macroToks = make(map[token.Position][]*cc.Token)
+	for _, t := range nodeTokens(g.file.TranslationUnit) {
+		pos := t.Position()
+		g.macroToks[pos] = append(g.macroToks[pos], t)
+	}
+	for pos, toks := range g.macroToks {
+		if len(toks) < 2 {
+			delete(g.macroToks, pos)
+		}
+	}
+	return g.macroToks
+}
+
+// macroAt returns the name of an object-like macro, if the node was expanded from it.
+// Only multi-token expansions are detected. Single-token ones are handled by convMacro.
+func (g *translator) macroAt(n cc.Node) (string, token.Position) {
+	pos := n.Position()
+	site, ok := g.macroSites()[pos]
+	if !ok || g.macroCur[pos] {
+		return "", pos
+	}
+	toks := nodeTokens(n)
+	if len(toks) != len(site) {
+		return "", pos
+	}
+	vals := make([]string, 0, len(toks))
+	for _, t := range toks {
+		if t.Position() != pos {
+			return "", pos
+		}
+		vals = append(vals, t.Value.String())
+	}
+	return g.macroExpansions()[strings.Join(vals, " ")], pos
+}
+
+// convMacroExpr converts an expression. If the expression was expanded from an object-like macro,
+// it returns a reference to the constant generated for that macro instead.
+func (g *translator) convMacroExpr(n cc.Node, fnc func() Expr) Expr {
+	name, pos := g.macroAt(n)
+	if name == "" {
+		return fnc()
+	}
+	if id, ok := g.macros[name]; ok {
+		return IdentExpr{id}
+	}
+	g.macroCur[pos] = true
+	x := fnc()
+	delete(g.macroCur, pos)
+	typ := x.CType(nil)
+	if k := typ.Kind(); !k.IsInt() && !k.IsFloat() {
+		return x // C macro is an int, while the expression is a bool or a pointer in Go
+	}
+	id := g.newMacroIdent(name, typ)
+	g.macros[name] = id
+	return IdentExpr{id}
+}
+
+// arrayLenExprs returns a map from array types to their length expressions in declarators.
+// Only the types of declared names are recorded (char buf[N][M]), not pointers to arrays or abstract declarators.
+func (g *translator) arrayLenExprs() map[cc.Type]*cc.AssignmentExpression {
+	if g.arrayLens != nil {
+		return g.arrayLens
+	}
+	g.arrayLens = make(map[cc.Type]*cc.AssignmentExpression)
+	cc.Inspect(g.file.TranslationUnit, func(n cc.Node, pre bool) bool {
+		d, ok := n.(*cc.Declarator)
+		if !ok || !pre || d.Pointer != nil {
+			return true
+		}
+		// the outermost array of the type is declared by the innermost direct declarator
+		var lens []*cc.AssignmentExpression
+		dd := d.DirectDeclarator
+		for ; dd != nil && dd.Case == cc.DirectDeclaratorArr; dd = dd.DirectDeclarator {
+			lens = append(lens, dd.AssignmentExpression)
+		}
+		if dd == nil || dd.Case != cc.DirectDeclaratorIdent {
+			return true
+		}
+		t := d.Type()
+		for i := len(lens) - 1; i >= 0 && t != nil && t.Kind() == cc.Array; i-- {
+			if lens[i] != nil {
+				g.arrayLens[t] = lens[i]
+			}
+			t = t.Elem()
+		}
+		return true
+	})
+	return g.arrayLens
+}
+
+// arrayLenMacro returns the constant generated for a macro used as the length of the array type, if any.
+func (g *translator) arrayLenMacro(t cc.Type) *types.Ident {
+	if g.file == nil || t.IsVLA() {
+		return nil
+	}
+	e := g.arrayLenExprs()[t]
+	if e == nil {
+		return nil
+	}
+	if toks := nodeTokens(e); len(toks) != 1 || toks[0].Macro() == 0 {
+		if name, _ := g.macroAt(e); name == "" {
+			return nil
+		}
+	}
+	x, ok := g.convertAssignExpr(e).(IdentExpr)
+	if !ok || g.macros[x.Name] != x.Ident || !x.CType(nil).Kind().IsInt() {
+		return nil
+	}
+	return x.Ident
+}
+
+// Function-like macros
+//
+// C macros are untyped, so the body of a function-like macro cannot be converted directly. Instead, the parser
+// generates a C wrapper function for each macro, using a placeholder integer type for all the arguments:
+//
+//	#define MIN(a, b) ((a) < (b) ? (a) : (b))
+//
+//	__typeof__(MIN((_cxgo_macro_T)0, (_cxgo_macro_T)0)) _cxgo_macro_MIN(_cxgo_macro_T a, _cxgo_macro_T b) {
+//		return MIN(a, b);
+//	}
+//
+// The translator emits the wrapper as a generic Go function named after the macro,
+// with the placeholder type replaced by a type parameter:
+//
+//	func MIN[T libc.Number](a T, b T) T
+//
+// Macros that cannot be type-checked this way are skipped. The translation is only enabled with MacroFuncs.
+
+const (
+	macroFuncPrefix = "_cxgo_macro_"
+	macroTypeName   = "_cxgo_macro_T"
+	macroTypeParam  = "T"
+	macroTypeSource = "cxgo_macro_types.h"
+)
+
+type macroFunc struct {
+	Name string
+	Pos  token.Position
+	Src  cc.Source
+}
+
+// macroFuncSource generates a wrapper function for a function-like macro.
+// The line directive makes the wrapper appear at the macro definition.
+func macroFuncSource(name string, m *cc.Macro, pos token.Position) (*macroFunc, error) {
+	toks := m.ReplacementTokens()
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+	for _, t := range toks {
+		switch t.Value.String() {
+		case "__VA_ARGS__":
+			return nil, fmt.Errorf("variadic macros are not supported")
+		case "#", "##":
+			return nil, fmt.Errorf("stringification and token pasting are not supported")
+		}
+	}
+	var (
+		zeros  []string
+		params []string
+		args   []string
+	)
+	for _, p := range m.Parameters() {
+		zeros = append(zeros, "("+macroTypeName+")0")
+		params = append(params, macroTypeName+" "+p.String())
+		args = append(args, p.String())
+	}
+	sparams := strings.Join(params, ", ")
+	if len(params) == 0 {
+		sparams = "void"
+	}
+	src := fmt.Sprintf("#line %d %q\n__typeof__(%s(%s)) %s%s(%s) { return %s(%s); }\n",
+		pos.Line, pos.Filename,
+		name, strings.Join(zeros, ", "),
+		macroFuncPrefix, name, sparams,
+		name, strings.Join(args, ", "),
+	)
+	return &macroFunc{
+		Name: name,
+		Pos:  pos,
+		Src:  cc.Source{Name: "cxgo_macro_" + name + ".h", Value: src},
+	}, nil
+}
+
+var reMacroFuncDef = regexp.MustCompile(`^\s*#\s*define\s+([A-Za-z_][A-Za-z_0-9]*)\(`)
+
+// findMacroFuncDefs finds positions of function-like macro definitions in the source.
+//
+// CC does not report positions of function-like macros, so we have to find them in the source text.
+func findMacroFuncDefs(src cc.Source) map[string]token.Position {
+	data := readSource(src)
+	out := make(map[string]token.Position)
+	for i, line := range strings.Split(data, "\n") {
+		if sub := reMacroFuncDef.FindStringSubmatch(line); sub != nil {
+			out[sub[1]] = token.Position{Filename: src.Name, Line: i + 1}
+		}
+	}
+	return out
+}
+
+// MacroFuncs enables translation of function-like macros to generic Go functions.
+//
+// Macro bodies can only be type-checked in the context of the file, thus each file that defines
+// function-like macros is parsed a second time with wrapper functions, see macroFuncSource.
+// Macros that cannot be translated are skipped and reported in Skipped.
+type MacroFuncs struct {
+	skipped []MacroFuncSkip
+	seen    map[MacroFuncSkip]struct{}
+}
+
+// MacroFuncSkip describes a function-like macro that was not translated.
+type MacroFuncSkip struct {
+	Name   string         // C name of the macro
+	Where  token.Position // position of the macro definition
+	Reason string
+}
+
+func (s MacroFuncSkip) String() string {
+	return fmt.Sprintf("%s: %s: %s", s.Where, s.Name, s.Reason)
+}
+
+// NewMacroFuncs creates an empty list of skipped function-like macros.
+func NewMacroFuncs() *MacroFuncs {
+	return &MacroFuncs{seen: make(map[MacroFuncSkip]struct{})}
+}
+
+// Skipped returns function-like macros that were not translated.
+// Each macro is reported once, even if it's defined in a header that is included by multiple files.
+func (m *MacroFuncs) Skipped() []MacroFuncSkip {
+	return m.skipped
+}
+
+func (m *MacroFuncs) skip(name string, where token.Position, reason string) {
+	s := MacroFuncSkip{Name: name, Where: where, Reason: reason}
+	if _, ok := m.seen[s]; ok {
+		return
+	}
+	m.seen[s] = struct{}{}
+	m.skipped = append(m.skipped, s)
+}
+
+// sources generates wrapper functions for all function-like macros defined in given sources.
+//
+// Macros defined in project headers are reported as skipped: a header may be included by multiple files,
+// and each of them would get its own copy of the function.
+func (m *MacroFuncs) sources(ast *cc.AST, c ParseConfig) []*macroFunc {
+	var out []*macroFunc
+	inSources := make(map[string]struct{})
+	for _, s := range c.Sources {
+		defs := findMacroFuncDefs(s)
+		for _, name := range sortedMacroDefs(defs) {
+			inSources[name] = struct{}{}
+			pos := defs[name]
+			cm, ok := ast.Macros[cc.String(name)]
+			if !ok || !cm.IsFnLike() {
+				continue // undefined
+			}
+			f, err := macroFuncSource(name, cm, pos)
+			if err != nil {
+				m.skip(name, pos, err.Error())
+				continue
+			}
+			out = append(out, f)
+		}
+	}
+	seen := make(map[string]struct{})
+	for _, s := range c.Sources {
+		for _, h := range findProjectHeaders(s.Name, readSource(s), c, seen) {
+			defs := findMacroFuncDefs(cc.Source{Name: h})
+			for _, name := range sortedMacroDefs(defs) {
+				if _, ok := inSources[name]; ok {
+					continue // redefined in the source
+				}
+				if cm, ok := ast.Macros[cc.String(name)]; !ok || !cm.IsFnLike() {
+					continue // undefined
+				}
+				m.skip(name, defs[name], "macros from headers are not supported")
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Pos.Filename != out[j].Pos.Filename {
+			return out[i].Pos.Filename < out[j].Pos.Filename
+		}
+		return out[i].Pos.Line < out[j].Pos.Line
+	})
+	return out
+}
+
+// sortedMacroDefs returns names of macros in the order of definition.
+func sortedMacroDefs(defs map[string]token.Position) []string {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return defs[names[i]].Line < defs[names[j]].Line
+	})
+	return names
+}
+
+var reIncludeLocal = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"`)
+
+// findProjectHeaders returns paths of headers included with quotes from the source, recursively.
+// Headers that cannot be found in the source directory or include paths are ignored.
+func findProjectHeaders(fname, data string, c ParseConfig, seen map[string]struct{}) []string {
+	var out []string
+	for _, line := range strings.Split(data, "\n") {
+		sub := reIncludeLocal.FindStringSubmatch(line)
+		if sub == nil {
+			continue
+		}
+		path := findInclude(sub[1], filepath.Dir(fname), c)
+		if path == "" {
+			continue
+		}
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, path)
+		out = append(out, findProjectHeaders(path, string(b), c, seen)...)
+	}
+	return out
+}
+
+// findInclude resolves the path of a quoted include the same way the preprocessor does.
+func findInclude(name, dir string, c ParseConfig) string {
+	dirs := append([]string{dir}, c.Includes...)
+	for _, d := range dirs {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(d, name)
+			if !filepath.IsAbs(path) && c.WorkDir != "" {
+				path = filepath.Join(c.WorkDir, path)
+			}
+		}
+		if st, err := os.Stat(path); err == nil && !st.IsDir() {
+			return filepath.Clean(path)
+		}
+	}
+	return ""
+}
+
+// readSource returns the contents of the source, reading it from the disk if necessary.
+func readSource(src cc.Source) string {
+	if src.Value != "" {
+		return src.Value
+	}
+	b, err := os.ReadFile(src.Name)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (m *MacroFuncs) translate(env *libs.Env, c ParseConfig, funcs []*macroFunc) *cc.AST {
+	ast, err := parseWithMacroFuncs(env, c, funcs)
+	if err == nil {
+		return ast
+	}
+	// wrapper is the only code on the line of the macro definition, see macroFuncSource
+	failed := errorLines(err)
+	ok := make([]*macroFunc, 0, len(funcs))
+	for _, f := range funcs {
+		if _, bad := failed[errorLine{f.Pos.Filename, f.Pos.Line}]; bad {
+			m.skip(f.Name, f.Pos, "cannot type-check the body")
+			continue
+		}
+		ok = append(ok, f)
+	}
+	if len(ok) == len(funcs) {
+		m.skipAll(funcs, "the file cannot be parsed with wrapper functions")
+		return nil
+	} else if len(ok) == 0 {
+		return nil
+	}
+	if ast, err = parseWithMacroFuncs(env, c, ok); err != nil {
+		m.skipAll(ok, "the file cannot be parsed with wrapper functions")
+		return nil
+	}
+	return ast
+}
+
+type errorLine struct {
+	file string
+	line int
+}
+
+var reErrorPos = regexp.MustCompile(`^(.+):(\d+):\d+: `)
+
+// errorLines returns source lines reported in the error.
+//
+// CC reports errors as plain text, one error per line, with a position prefix.
+func errorLines(err error) map[errorLine]struct{} {
+	out := make(map[errorLine]struct{})
+	for _, s := range strings.Split(err.Error(), "\n") {
+		sub := reErrorPos.FindStringSubmatch(s)
+		if sub == nil {
+			continue
+		}
+		n, err := strconv.Atoi(sub[2])
+		if err != nil {
+			continue
+		}
+		out[errorLine{sub[1], n}] = struct{}{}
+	}
+	return out
+}
+
+func (m *MacroFuncs) skipAll(funcs []*macroFunc, reason string) {
+	for _, f := range funcs {
+		m.skip(f.Name, f.Pos, reason)
+	}
+}
+
+// parseWithMacroFuncs parses the sources with given wrapper functions.
+func parseWithMacroFuncs(env *libs.Env, c ParseConfig, funcs []*macroFunc) (*cc.AST, error) {
+	cconf, includes, sysIncludes, srcs, err := newCCConfigs(env, c)
+	if err != nil {
+		return nil, err
+	}
+	cconf.PreserveWhiteSpace = true // for comments
+	srcs = append(srcs, cc.Source{Name: macroTypeSource, Value: "typedef long long " + macroTypeName + ";\n"})
+	for _, f := range funcs {
+		srcs = append(srcs, f.Src)
+	}
+	return cc.Translate(cconf, includes, sysIncludes, srcs)
+}
+
+// convertMacroFunc converts a wrapper function generated by macroFuncSource.
+func (g *translator) convertMacroFunc(name string, d *cc.FunctionDefinition, ft *types.FuncType) []CDecl {
+	if !g.inCurFile(d) {
+		return nil
+	}
+	if ft.Return() == nil {
+		// void expressions are usually a result of cc being too permissive (for example, dereferencing an integer)
+		if m := g.conf.MacroFuncs; m != nil {
+			m.skip(name, d.Position(), "body has no value")
+		}
+		return nil
+	}
+	id := g.convertIdentWith(macroFuncPrefix+name, ft, d.Declarator)
+	id.GoName = name
+	if c, ok := g.idents[name]; ok && c.Rename != "" {
+		id.GoName = c.Rename
+	}
+	f := &CFuncDecl{
+		Name: id.Ident,
+		Type: ft,
+		Body: g.convertCompBlockStmt(d.CompoundStatement).In(ft),
+		Range: &Range{
+			Start:     d.Position().Offset,
+			StartLine: d.Position().Line,
+		},
+	}
+	if typ, ok := g.named[macroTypeName]; ok && usesType(ft, typ) {
+		f.TypeParam = typ
+	}
+	return []CDecl{f}
+}
+
+func usesType(ft *types.FuncType, typ types.Type) bool {
+	if ft.Return() == typ {
+		return true
+	}
+	for _, a := range ft.Args() {
+		if a.Type() == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// macroTypeConstraint selects a type parameter constraint for a function generated from the macro.
+// Floating-point types are not allowed if the function uses integer-only operators.
+func macroTypeConstraint(body *ast.BlockStmt) GoExpr {
+	integer := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		var tok token2.Token
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			tok = n.Op
+		case *ast.UnaryExpr:
+			tok = n.Op
+		case *ast.AssignStmt:
+			tok = n.Tok
+		default:
+			return true
+		}
+		switch tok {
+		case token2.REM, token2.AND, token2.OR, token2.XOR, token2.SHL, token2.SHR, token2.AND_NOT,
+			token2.REM_ASSIGN, token2.AND_ASSIGN, token2.OR_ASSIGN, token2.XOR_ASSIGN,
+			token2.SHL_ASSIGN, token2.SHR_ASSIGN, token2.AND_NOT_ASSIGN:
+			integer = true
+		}
+		return !integer
+	})
+	if integer {
+		return ident("libc.Integer")
+	}
+	return ident("libc.Number")
+}
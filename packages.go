@@ -0,0 +1,542 @@
+package cxgo
+
+import (
+	"fmt"
+	token2 "go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// reservedPkgNames are Go package names that are used by generated code already.
+var reservedPkgNames = map[string]struct{}{
+	"libc": {}, "unsafe": {}, "math": {}, "os": {}, "stdio": {}, "cmath": {},
+	"csys": {}, "cnet": {}, "atomic": {}, "unicode": {}, "time": {}, "sync": {},
+}
+
+// Packages maps C source directories to separate Go packages.
+//
+// All C files must be indexed with IndexFile before any of them is translated: a symbol referenced
+// from a different directory must be exported in its own package and qualified at the use site.
+type Packages struct {
+	root   string // absolute path of the C source root
+	module string // Go module path
+	name   string // package name for the root directory
+
+	dirs  []string          // in order of registration
+	names map[string]string // dir -> Go package name
+	taken map[string]string // Go package name -> dir
+
+	defs map[string]*pkgSymbol          // C name -> symbol
+	refs map[string]map[string]struct{} // dir -> C names of global symbols used by it
+
+	resolved bool
+	exported map[string]bool
+	visible  map[string]map[string]struct{} // dir -> foreign symbols that must be qualified
+	deps     map[string]map[string][]string // dir -> dependency dir -> symbols that caused it
+}
+
+type pkgSymbol struct {
+	dir  string
+	uses map[string]struct{} // global symbols used by declarations of this symbol
+}
+
+// NewPackages creates a new mapping of directories in a C source root to Go packages in a given module.
+// The root directory itself is mapped to a package with a given name.
+func NewPackages(root, module, name string) (*Packages, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = "lib"
+	}
+	p := &Packages{
+		root:   root,
+		module: strings.TrimSuffix(module, "/"),
+		name:   name,
+		names:  make(map[string]string),
+		taken:  make(map[string]string),
+		defs:   make(map[string]*pkgSymbol),
+		refs:   make(map[string]map[string]struct{}),
+	}
+	p.addDir(".")
+	return p, nil
+}
+
+// dirOf returns a slash-separated directory of a file relative to the root, or false if it's outside of the root.
+func (p *Packages) dirOf(fname string) (string, bool) {
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(p.root, filepath.Dir(abs))
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+func (p *Packages) addDir(dir string) string {
+	if name, ok := p.names[dir]; ok {
+		return name
+	}
+	name := p.name
+	if dir != "." {
+		name = pkgNameFor(path.Base(dir))
+	}
+	base := name
+	for i := 2; ; i++ {
+		if _, ok := p.taken[name]; !ok {
+			break
+		}
+		name = base + strconv.Itoa(i)
+	}
+	p.dirs = append(p.dirs, dir)
+	p.names[dir] = name
+	p.taken[name] = dir
+	return name
+}
+
+// pkgNameFor converts a directory name to a valid Go package name.
+func pkgNameFor(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			return r
+		}
+		return '_'
+	}, dir)
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "p" + name
+	}
+	if _, ok := reservedPkgNames[name]; ok || token2.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
+// PackageName returns a Go package name for a given C file.
+func (p *Packages) PackageName(fname string) string {
+	dir, ok := p.dirOf(fname)
+	if !ok {
+		return p.name
+	}
+	return p.addDir(dir)
+}
+
+// ImportPath returns a Go import path of the package for a given C file.
+func (p *Packages) ImportPath(fname string) string {
+	dir, ok := p.dirOf(fname)
+	if !ok {
+		return p.module
+	}
+	return p.importPath(dir)
+}
+
+func (p *Packages) importPath(dir string) string {
+	if dir == "." {
+		return p.module
+	}
+	return p.module + "/" + dir
+}
+
+// Index records global symbols defined in a translation unit of a given C file, as well as the ones
+// used by declarations from the file's directory.
+//
+// Functions and variables belong to a directory of the file that defines them, while types and
+// enum constants belong to a directory of the file that declares them.
+func (p *Packages) Index(fname string, tu *cc.AST) {
+	p.resolved = false
+	cur, ok := p.dirOf(fname)
+	if !ok {
+		return
+	}
+	p.addDir(cur)
+	exps := make(map[string]string) // full expansion -> macro name
+	for name, m := range tu.Macros {
+		if m.IsFnLike() || len(m.ReplacementTokens()) == 0 || isCxgoSource(m.Position().Filename) {
+			continue
+		}
+		dir, ok := p.dirOf(m.Position().Filename)
+		if !ok {
+			continue
+		}
+		p.addDef(name.String(), dir)
+		if toks := macroExpansion(tu, m, make(map[*cc.Macro]struct{}), nil); len(toks) >= 2 {
+			exps[strings.Join(toks, " ")] = name.String()
+		}
+	}
+	for it := tu.TranslationUnit; it != nil; it = it.TranslationUnit {
+		d := it.ExternalDeclaration
+		if d == nil {
+			continue
+		}
+		dir, ok := p.dirOf(d.Position().Filename)
+		if !ok {
+			continue // system headers
+		}
+		switch d.Case {
+		case cc.ExternalDeclarationFuncDef:
+			fd := d.FunctionDefinition
+			name := fd.Declarator.Name().String()
+			if strings.HasPrefix(name, macroFuncPrefix) {
+				continue
+			}
+			if !fd.Declarator.IsStatic() {
+				p.addDef(name, dir)
+			}
+			p.addUses(name, tu, fd.DeclarationSpecifiers, fd.Declarator)
+		case cc.ExternalDeclarationDecl:
+			p.indexDecl(tu, d.Declaration, dir)
+		default:
+			continue
+		}
+		if dir == cur {
+			addRef := func(name string) {
+				m := p.refs[cur]
+				if m == nil {
+					m = make(map[string]struct{})
+					p.refs[cur] = m
+				}
+				m[name] = struct{}{}
+			}
+			usedGlobals(tu, d, addRef)
+			usedMacros(d, exps, addRef)
+		}
+	}
+}
+
+func (p *Packages) indexDecl(tu *cc.AST, d *cc.Declaration, dir string) {
+	cc.Inspect(d.DeclarationSpecifiers, func(n cc.Node, enter bool) bool {
+		if !enter {
+			return true
+		}
+		switch n := n.(type) {
+		case *cc.StructOrUnionSpecifier:
+			if n.Case == cc.StructOrUnionSpecifierDef && n.Token.Value != 0 {
+				p.addDef(n.Token.Value.String(), dir)
+				p.addUses(n.Token.Value.String(), tu, n)
+			}
+		case *cc.EnumSpecifier:
+			if n.Case == cc.EnumSpecifierDef && n.Token2.Value != 0 {
+				p.addDef(n.Token2.Value.String(), dir)
+			}
+		case *cc.Enumerator:
+			p.addDef(n.Token.Value.String(), dir)
+		}
+		return true
+	})
+	for il := d.InitDeclaratorList; il != nil; il = il.InitDeclaratorList {
+		dd := il.InitDeclarator.Declarator
+		name := dd.Name().String()
+		if name == "" || dd.IsStatic() {
+			continue
+		}
+		switch {
+		case dd.IsTypedefName:
+			p.addDef(name, dir)
+		case dd.Type() != nil && dd.Type().Kind() == cc.Function:
+			// prototype; defined by the function definition
+		case !dd.IsExtern():
+			p.addDef(name, dir)
+		}
+		p.addUses(name, tu, d.DeclarationSpecifiers, dd)
+	}
+}
+
+func (p *Packages) addDef(name, dir string) {
+	if s, ok := p.defs[name]; ok {
+		s.dir = dir
+		return
+	}
+	p.defs[name] = &pkgSymbol{dir: dir}
+}
+
+func (p *Packages) addUses(name string, tu *cc.AST, nodes ...cc.Node) {
+	s, ok := p.defs[name]
+	if !ok {
+		// prototype seen before the definition
+		s = &pkgSymbol{}
+		p.defs[name] = s
+	}
+	if s.uses == nil {
+		s.uses = make(map[string]struct{})
+	}
+	for _, n := range nodes {
+		usedGlobals(tu, n, func(use string) {
+			if use != name {
+				s.uses[use] = struct{}{}
+			}
+		})
+	}
+}
+
+// usedGlobals calls fnc for names of all file-scope identifiers, types and tags used in a given node.
+func usedGlobals(tu *cc.AST, n cc.Node, fnc func(name string)) {
+	cc.Inspect(n, func(n cc.Node, enter bool) bool {
+		if !enter {
+			return true
+		}
+		switch n := n.(type) {
+		case *cc.PrimaryExpression:
+			if n.Case != cc.PrimaryExpressionIdent && n.Case != cc.PrimaryExpressionEnum {
+				break
+			}
+			if to := n.ResolvedTo(); to != nil && !inScope(tu.Scope, n.Token.Value, to) {
+				break // local variable
+			}
+			fnc(n.Token.Value.String())
+		case *cc.TypeSpecifier:
+			if n.Case == cc.TypeSpecifierTypedefName {
+				fnc(n.Token.Value.String())
+			}
+		case *cc.StructOrUnionSpecifier:
+			if n.Token.Value != 0 {
+				fnc(n.Token.Value.String())
+			}
+		case *cc.EnumSpecifier:
+			if n.Token2.Value != 0 {
+				fnc(n.Token2.Value.String())
+			}
+		}
+		return true
+	})
+}
+
+// usedMacros calls fnc for names of object-like macros expanded in a given node. See macroAt.
+func usedMacros(n cc.Node, exps map[string]string, fnc func(name string)) {
+	sites := make(map[token.Position][]string)
+	for _, t := range nodeTokens(n) {
+		if m := t.Macro(); m != 0 {
+			fnc(m.String())
+			continue
+		}
+		pos := t.Position()
+		sites[pos] = append(sites[pos], t.Value.String())
+	}
+	for _, vals := range sites {
+		if len(vals) >= 2 {
+			if name := exps[strings.Join(vals, " ")]; name != "" {
+				fnc(name)
+			}
+		}
+	}
+}
+
+func inScope(scope cc.Scope, name cc.StringID, node cc.Node) bool {
+	for _, n := range scope[name] {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve decides which symbols must be exported and which packages depend on each other.
+func (p *Packages) resolve() {
+	if p.resolved {
+		return
+	}
+	p.resolved = true
+	p.exported = make(map[string]bool)
+	p.visible = make(map[string]map[string]struct{})
+	p.deps = make(map[string]map[string][]string)
+	for _, dir := range p.dirs {
+		vis := make(map[string]struct{})
+		p.visible[dir] = vis
+		deps := make(map[string][]string)
+		var queue []string
+		for name := range p.refs[dir] {
+			s, ok := p.defs[name]
+			if !ok || s.dir == "" || s.dir == dir {
+				continue
+			}
+			queue = append(queue, name)
+		}
+		// types of foreign functions and variables may appear in the generated code implicitly,
+		// thus packages of symbols used transitively must be imported as well
+		for len(queue) != 0 {
+			name := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			if _, ok := vis[name]; ok {
+				continue
+			}
+			s := p.defs[name]
+			vis[name] = struct{}{}
+			p.exported[name] = true
+			deps[s.dir] = append(deps[s.dir], name)
+			for use := range s.uses {
+				if u, ok := p.defs[use]; ok && u.dir != "" && u.dir != dir {
+					queue = append(queue, use)
+				}
+			}
+		}
+		for _, list := range deps {
+			sort.Strings(list)
+		}
+		if len(deps) != 0 {
+			p.deps[dir] = deps
+		}
+	}
+}
+
+// exportName converts a C name to an exported Go name.
+func exportName(name string) string {
+	s := asExportedName(name)
+	if r, _ := utf8.DecodeRuneInString(s); !unicode.IsUpper(r) {
+		s = "X" + s
+	}
+	return s
+}
+
+// idents returns identifier configs for translating a given file: symbols used by other packages are exported,
+// while symbols from other packages are qualified with the package name.
+func (p *Packages) idents(fname string, list []IdentConfig) []IdentConfig {
+	p.resolve()
+	dir, ok := p.dirOf(fname)
+	if !ok {
+		return list
+	}
+	renames := make(map[string]string)
+	for name := range p.exported {
+		if p.defs[name].dir == dir {
+			renames[name] = ""
+		}
+	}
+	for name := range p.visible[dir] {
+		renames[name] = p.names[p.defs[name].dir] + "."
+	}
+	out := make([]IdentConfig, 0, len(list)+len(renames))
+	for _, c := range list {
+		if pref, ok := renames[c.Name]; ok {
+			delete(renames, c.Name)
+			goname := c.Rename
+			if goname == "" {
+				goname = c.Name
+			}
+			c.Rename = pref + exportName(goname)
+		}
+		out = append(out, c)
+	}
+	names := make([]string, 0, len(renames))
+	for name := range renames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, IdentConfig{Name: name, Rename: renames[name] + exportName(name)})
+	}
+	return out
+}
+
+// addImports registers import paths of all known packages in the environment.
+func (p *Packages) addImports(env *libs.Env) {
+	for _, dir := range p.dirs {
+		env.AddImport(p.names[dir], p.importPath(dir))
+	}
+}
+
+// Cycles returns all groups of packages that depend on each other.
+// Go does not allow import cycles, thus such directories should be merged or refactored.
+func (p *Packages) Cycles() [][]string {
+	p.resolve()
+	// Tarjan's strongly connected components
+	var (
+		index   = make(map[string]int)
+		low     = make(map[string]int)
+		onStack = make(map[string]bool)
+		stack   []string
+		out     [][]string
+		visit   func(dir string)
+	)
+	visit = func(dir string) {
+		index[dir] = len(index)
+		low[dir] = index[dir]
+		stack = append(stack, dir)
+		onStack[dir] = true
+		for _, dep := range p.sortedDeps(dir) {
+			if _, ok := index[dep]; !ok {
+				visit(dep)
+				if low[dep] < low[dir] {
+					low[dir] = low[dep]
+				}
+			} else if onStack[dep] && index[dep] < low[dir] {
+				low[dir] = index[dep]
+			}
+		}
+		if low[dir] != index[dir] {
+			return
+		}
+		var scc []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			scc = append(scc, last)
+			if last == dir {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			out = append(out, scc)
+		}
+	}
+	for _, dir := range p.dirs {
+		if _, ok := index[dir]; !ok {
+			visit(dir)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i][0] < out[j][0]
+	})
+	return out
+}
+
+func (p *Packages) sortedDeps(dir string) []string {
+	var out []string
+	for dep := range p.deps[dir] {
+		out = append(out, dep)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Check returns an error with a report of cyclic dependencies between packages, if there are any.
+func (p *Packages) Check() error {
+	cycles := p.Cycles()
+	if len(cycles) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	buf.WriteString("import cycles between C directories:")
+	for _, scc := range cycles {
+		in := make(map[string]bool, len(scc))
+		for _, dir := range scc {
+			in[dir] = true
+		}
+		buf.WriteString("\n\t" + strings.Join(scc, ", ") + ":")
+		for _, dir := range scc {
+			for _, dep := range p.sortedDeps(dir) {
+				if in[dep] {
+					fmt.Fprintf(&buf, "\n\t\t%s -> %s: %s", dir, dep, strings.Join(p.deps[dir][dep], ", "))
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%s", buf.String())
+}
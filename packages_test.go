@@ -0,0 +1,226 @@
+package cxgo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func writeTestFiles(t testing.TB, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(strings.TrimLeft(data, "\n")), 0644))
+	}
+}
+
+func translatePackages(t testing.TB, src, out string, names []string) *Packages {
+	p, err := NewPackages(src, "example.com/proj", "proj")
+	require.NoError(t, err)
+	for _, name := range names {
+		err = IndexFile(filepath.Join(src, name), libs.NewEnv(types.Config32()), Config{Packages: p})
+		require.NoError(t, err)
+	}
+	if err = p.Check(); err != nil {
+		return p
+	}
+	for _, name := range names {
+		err = Translate(src, filepath.Join(src, name), out, libs.NewEnv(types.Config32()), Config{
+			MaxDecls: -1, Packages: p,
+		})
+		require.NoError(t, err)
+	}
+	return p
+}
+
+func TestPackages(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"geom/point.h": `
+typedef struct point {
+	int x, y;
+} point;
+
+typedef enum { AXIS_X, AXIS_Y } axis;
+
+int point_get(point* p, axis a);
+`,
+		"geom/point.c": `
+#include "point.h"
+
+static int pick(int a, int b, int first) {
+	return first ? a : b;
+}
+
+int point_get(point* p, axis a) {
+	return pick(p->x, p->y, a == AXIS_X);
+}
+`,
+		"util/math.h": `
+#define UTIL_MAX (1 << 8)
+
+extern int scale;
+
+int util_abs(int v);
+`,
+		"util/math.c": `
+#include "math.h"
+
+int scale = 2;
+
+int util_abs(int v) {
+	return v < 0 ? -v : v;
+}
+`,
+		"main.c": `
+#include "geom/point.h"
+#include "util/math.h"
+
+int dist(point* p) {
+	int scale = 1;
+	return util_abs(point_get(p, AXIS_X)) + util_abs(point_get(p, AXIS_Y)) * scale + UTIL_MAX;
+}
+`,
+	})
+	p := translatePackages(t, src, out, []string{"geom/point.c", "util/math.c", "main.c"})
+	require.NoError(t, p.Check())
+	require.Equal(t, "example.com/proj/geom", p.ImportPath(filepath.Join(src, "geom/point.c")))
+	require.Equal(t, "util", p.PackageName(filepath.Join(src, "util/math.c")))
+	require.Equal(t, "math_", pkgNameFor("math"))
+	require.Equal(t, "p3d", pkgNameFor("3D"))
+
+	data, err := os.ReadFile(filepath.Join(out, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package proj
+
+import (
+	"example.com/proj/geom"
+	"example.com/proj/util"
+)
+
+func dist(p *geom.Point) int32 {
+	var scale int32 = 1
+	return util.Util_abs(geom.Point_get(p, geom.Axis(geom.AXIS_X))) + util.Util_abs(geom.Point_get(p, geom.Axis(geom.AXIS_Y)))*scale + util.UTIL_MAX
+}
+`, string(data))
+
+	data, err = os.ReadFile(filepath.Join(out, "util", "math.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package util
+
+const UTIL_MAX = 256
+
+var scale int32 = 2
+
+func Util_abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+`, string(data))
+
+	data, err = os.ReadFile(filepath.Join(out, "geom", "point.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "type Point struct {")
+	require.Contains(t, string(data), "func Point_get(p *Point, a Axis) int32 {")
+	require.Contains(t, string(data), "func pick(")
+
+	cxgo, err := filepath.Abs(".")
+	require.NoError(t, err)
+	gomod := fmt.Sprintf(`module example.com/proj
+go 1.20
+require (
+	github.com/gotranspile/cxgo v0.0.0-local
+)
+replace github.com/gotranspile/cxgo v0.0.0-local => %s`, cxgo)
+	require.NoError(t, os.WriteFile(filepath.Join(out, "go.mod"), []byte(gomod), 0644))
+	goProjectMod(t, out)
+	require.NoError(t, execInDir(out, "go", "build", "./..."))
+}
+
+func TestPackagesCycles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	writeTestFiles(t, src, map[string]string{
+		"a/a.h": `
+int a_get();
+int a_twice();
+`,
+		"a/a.c": `
+#include "a.h"
+#include "../b/b.h"
+
+int a_get() { return 1; }
+int a_twice() { return b_get() * 2; }
+`,
+		"b/b.h": `
+int b_get();
+`,
+		"b/b.c": `
+#include "b.h"
+#include "../a/a.h"
+
+int b_get() { return a_get() + 1; }
+`,
+	})
+	p := translatePackages(t, src, filepath.Join(dir, "go"), []string{"a/a.c", "b/b.c"})
+	require.Equal(t, [][]string{{"a", "b"}}, p.Cycles())
+	err := p.Check()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "a -> b: b_get")
+	require.Contains(t, err.Error(), "b -> a: a_get")
+}
+
+func TestPackagesCyclesTransitive(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	writeTestFiles(t, src, map[string]string{
+		"a/a.h": `
+int a_get();
+int a_val();
+`,
+		"a/a.c": `
+#include "a.h"
+#include "../b/b.h"
+
+int a_get() { return 1; }
+int a_val() { return b_make().v; }
+`,
+		"b/b.h": `
+#include "../c/c.h"
+
+c_t b_make();
+`,
+		"b/b.c": `
+#include "b.h"
+
+c_t b_make() { c_t v = {1}; return v; }
+`,
+		"c/c.h": `
+typedef struct { int v; } c_t;
+int c_get();
+`,
+		"c/c.c": `
+#include "c.h"
+#include "../a/a.h"
+
+int c_get() { return a_get(); }
+`,
+	})
+	p := translatePackages(t, src, filepath.Join(dir, "go"), []string{"a/a.c", "b/b.c", "c/c.c"})
+	require.Equal(t, [][]string{{"a", "b", "c"}}, p.Cycles())
+	err := p.Check()
+	require.Error(t, err)
+	// the type is only used implicitly by a, through the function from b
+	require.Contains(t, err.Error(), "a -> c: c_t")
+}
@@ -17,9 +17,11 @@ import (
 type SourceConfig struct {
 	Predef           string
 	Define           []Define
+	Switches         []Switch
 	Include          []string
 	SysInclude       []string
 	IgnoreIncludeDir bool
+	MacroFuncs       *MacroFuncs
 }
 
 func Parse(c *libs.Env, root, fname string, sconf SourceConfig) (*cc.AST, error) {
@@ -61,6 +63,8 @@ func Parse(c *libs.Env, root, fname string, sconf SourceConfig) (*cc.AST, error)
 		SysIncludes: sys,
 		Predefines:  true,
 		Define:      sconf.Define,
+		Switches:    sconf.Switches,
+		MacroFuncs:  sconf.MacroFuncs,
 	})
 }
 
@@ -84,12 +88,14 @@ type ParseConfig struct {
 	SysIncludes []string
 	Predefines  bool
 	Define      []Define
+	Switches    []Switch
 	Sources     []cc.Source
+	MacroFuncs  *MacroFuncs // translate function-like macros; requires a second pass, see MacroFuncs
 }
 
-func newCCConfigs(env *libs.Env, c ParseConfig) (*cc.Config, []string, []string, []cc.Source) {
+func newCCConfigs(env *libs.Env, c ParseConfig) (*cc.Config, []string, []string, []cc.Source, error) {
 	var srcs []cc.Source
-	if len(c.Define) != 0 {
+	if len(c.Define) != 0 || len(c.Switches) != 0 {
 		var buf bytes.Buffer
 		for _, d := range c.Define {
 			buf.WriteString("#define ")
@@ -100,18 +106,28 @@ func newCCConfigs(env *libs.Env, c ParseConfig) (*cc.Config, []string, []string,
 			}
 			buf.WriteByte('\n')
 		}
+		buf.WriteString(switchesSource(c.Switches))
 		srcs = append(srcs, cc.Source{Name: "cxgo_config_defines.h", Value: buf.String()})
 	}
 	if c.Predefines {
 		srcs = append(srcs, cc.Source{Name: "cxgo_predef.h", Value: fmt.Sprintf(gccPredefine, "int")})
 	}
-	srcs = append(srcs, c.Sources...)
+	for _, src := range c.Sources {
+		if src.Value != "" && len(c.Switches) != 0 {
+			data, _, err := rewriteSwitches(src.Name, []byte(src.Value), switchNames(c.Switches))
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			src.Value = string(data)
+		}
+		srcs = append(srcs, src)
+	}
 	includes := addIncludeOverridePath(c.Includes)
 	sysIncludes := addIncludeOverridePath(c.SysIncludes)
 	cconf := &cc.Config{
 		Config3: cc.Config3{
 			WorkingDir: c.WorkDir,
-			Filesystem: cc.Overlay(cc.LocalFS(), newIncludeFS(env)),
+			Filesystem: cc.Overlay(newSwitchFS(cc.LocalFS(), c.Switches), newIncludeFS(env)),
 		},
 		ABI: libcc.NewABI(env.Env),
 		PragmaHandler: func(p cc.Pragma, toks []cc.Token) {
@@ -148,16 +164,34 @@ func newCCConfigs(env *libs.Env, c ParseConfig) (*cc.Config, []string, []string,
 			}
 		},
 	}
-	return cconf, includes, sysIncludes, srcs
+	return cconf, includes, sysIncludes, srcs, nil
 }
 
 func PreprocessSource(w io.Writer, env *libs.Env, c ParseConfig) error {
-	cconf, includes, sysIncludes, srcs := newCCConfigs(env, c)
-	err := cc.Preprocess(cconf, includes, sysIncludes, srcs, w)
-	return err
+	cconf, includes, sysIncludes, srcs, err := newCCConfigs(env, c)
+	if err != nil {
+		return err
+	}
+	return cc.Preprocess(cconf, includes, sysIncludes, srcs, w)
 }
 
 func ParseSource(env *libs.Env, c ParseConfig) (*cc.AST, error) {
-	cconf, includes, sysIncludes, srcs := newCCConfigs(env, c)
-	return cc.Translate(cconf, includes, sysIncludes, srcs)
+	cconf, includes, sysIncludes, srcs, err := newCCConfigs(env, c)
+	if err != nil {
+		return nil, err
+	}
+	cconf.PreserveWhiteSpace = true // for comments
+	ast, err := cc.Translate(cconf, includes, sysIncludes, srcs)
+	if err != nil {
+		return nil, err
+	}
+	// function-like macros can only be type-checked with wrapper functions, thus we need a second pass
+	if m := c.MacroFuncs; m != nil {
+		if funcs := m.sources(ast, c); len(funcs) != 0 {
+			if ast2 := m.translate(env, c, funcs); ast2 != nil {
+				ast = ast2
+			}
+		}
+	}
+	return ast, nil
 }
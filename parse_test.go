@@ -35,6 +35,10 @@ func (c parseCase) shouldSkip() bool {
 	return c.skip || c.skipExp != ""
 }
 
+func withMacroFuncs(c *Config) {
+	c.MacroFuncs = NewMacroFuncs()
+}
+
 func withIdent(ic IdentConfig) configFunc {
 	return func(c *Config) {
 		c.Idents = append(c.Idents, ic)
@@ -996,10 +1000,15 @@ func runTestTranslateCase(t *testing.T, c parseCase) {
 		f(&econf)
 	}
 	env := libs.NewEnv(econf)
+	tconf := Config{ForwardDecl: true}
+	for _, f := range c.configFuncs {
+		f(&tconf)
+	}
 	ast, err := ParseSource(env, ParseConfig{
 		WorkDir:    "",
 		Predefines: c.builtins,
 		Sources:    srcs,
+		MacroFuncs: tconf.MacroFuncs,
 	})
 	if c.skip {
 		t.SkipNow()
@@ -1007,10 +1016,6 @@ func runTestTranslateCase(t *testing.T, c parseCase) {
 		require.NoError(t, err)
 	}
 
-	tconf := Config{ForwardDecl: true}
-	for _, f := range c.configFuncs {
-		f(&tconf)
-	}
 	decls, err := TranslateAST(fname, ast, env, tconf)
 	require.NoError(t, err)
 
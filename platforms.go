@@ -0,0 +1,177 @@
+package cxgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Platform describes one target of a multi-platform translation.
+type Platform struct {
+	// Name is used as a suffix for Go files with platform-specific declarations, for example "linux" or "windows_amd64".
+	Name string
+	// Build is a Go build constraint for platform-specific files. If not set, it's derived from the Name.
+	Build string
+	// Env is the environment to translate the file with. Different platforms may use different type sizes.
+	Env *libs.Env
+	// Define is a list of additional macros for this platform.
+	Define []Define
+	// Undef is a list of predefined macros to remove for this platform, for example "__linux__".
+	Undef []string
+}
+
+// BuildConstraint returns a Go build constraint expression for the platform.
+func (p Platform) BuildConstraint() string {
+	if p.Build != "" {
+		return p.Build
+	}
+	return strings.ReplaceAll(p.Name, "_", " && ")
+}
+
+// TranslatePlatforms translates the same C file once for each platform and compares the results.
+//
+// Declarations that are the same for all platforms are written to a common Go file. The rest is written to
+// files with a platform name suffix (file_linux.go, file_windows.go, etc) and a corresponding build constraint.
+// Platforms without specific declarations get no file.
+//
+// If any of the declarations are platform-specific, the common file cannot be used on other platforms.
+// Thus, a file with an "_unsupported" suffix is written for them, which intentionally fails to compile.
+func TranslatePlatforms(root, fname, out string, plats []Platform, conf Config) error {
+	if len(plats) == 0 {
+		return errors.New("no platforms defined")
+	}
+	var (
+		pkg, gofile string
+		decls       = make([][]GoDecl, len(plats))
+		texts       = make([][]string, len(plats))
+	)
+	names := make(map[string]struct{})
+	for i, p := range plats {
+		if p.Name == "" {
+			return errors.New("platform name must be set")
+		} else if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("duplicate platform: %q", p.Name)
+		}
+		names[p.Name] = struct{}{}
+		pconf := conf
+		pconf.Define = append(append([]Define{}, conf.Define...), p.Define...)
+		if len(p.Undef) != 0 {
+			var buf strings.Builder
+			for _, name := range p.Undef {
+				buf.WriteString("#undef " + strings.TrimSpace(name) + "\n")
+			}
+			buf.WriteString(conf.Predef)
+			pconf.Predef = buf.String()
+		}
+		var err error
+		pkg, gofile, decls[i], err = translateFile(root, fname, p.Env, pconf)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		texts[i], err = declTexts(decls[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	// count how many copies of each declaration are present on all platforms
+	common := countTexts(texts[0])
+	for _, list := range texts[1:] {
+		cnt := countTexts(list)
+		for s, n := range common {
+			if m := cnt[s]; m < n {
+				common[s] = m
+			}
+		}
+	}
+	var shared []GoDecl
+	left := copyCounts(common)
+	for j, d := range decls[0] {
+		if s := texts[0][j]; left[s] > 0 {
+			left[s]--
+			shared = append(shared, d)
+		}
+	}
+	if err := writeGoFiles(out, pkg, gofile, "", shared, plats[0].Env, conf); err != nil {
+		return err
+	}
+	base := strings.TrimSuffix(gofile, ".go")
+	specific := false
+	for i, p := range plats {
+		var own []GoDecl
+		left = copyCounts(common)
+		for j, d := range decls[i] {
+			if s := texts[i][j]; left[s] > 0 {
+				left[s]--
+				continue
+			}
+			own = append(own, d)
+		}
+		if len(own) == 0 {
+			continue
+		}
+		specific = true
+		if err := writeGoFiles(out, pkg, base+"_"+p.Name+".go", p.BuildConstraint(), own, p.Env, conf); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	if !specific {
+		return nil
+	}
+	var (
+		list  []string
+		build []string
+	)
+	for _, p := range plats {
+		list = append(list, p.Name)
+		build = append(build, "!("+p.BuildConstraint()+")")
+	}
+	// refer to an undefined name that explains the problem in the compiler error
+	undef := staticPrefix(filepath.Base(base)) + "_is_not_translated_for_this_platform"
+	stub := &ast.GenDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{{
+			Text: fmt.Sprintf("// %s is only translated for: %s.", filepath.Base(fname), strings.Join(list, ", ")),
+		}}},
+		Tok: token.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{
+			Names:  []*ast.Ident{ident("_")},
+			Values: []ast.Expr{ident(undef)},
+		}},
+	}
+	return writeGoFiles(out, pkg, base+"_unsupported.go", strings.Join(build, " && "), []GoDecl{stub}, plats[0].Env, conf)
+}
+
+// declTexts prints each declaration separately, so they can be compared between platforms.
+func declTexts(decls []GoDecl) ([]string, error) {
+	out := make([]string, 0, len(decls))
+	var buf bytes.Buffer
+	for _, d := range decls {
+		buf.Reset()
+		if err := PrintGo(&buf, "p", []GoDecl{d}, false); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.String())
+	}
+	return out, nil
+}
+
+func countTexts(list []string) map[string]int {
+	m := make(map[string]int, len(list))
+	for _, s := range list {
+		m[s]++
+	}
+	return m
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	m2 := make(map[string]int, len(m))
+	for s, n := range m {
+		m2[s] = n
+	}
+	return m2
+}
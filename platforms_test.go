@@ -0,0 +1,131 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestTranslatePlatforms(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"sys.c": `
+#ifdef _WIN32
+#define SEP '\\'
+#else
+#define SEP '/'
+#endif
+
+typedef struct {
+	int fd;
+	long size;
+} file_t;
+
+int is_sep(char c) {
+	return c == SEP;
+}
+
+#ifdef __linux__
+int sys_open() { return 3; }
+#endif
+
+int add(int a, int b) {
+	return a + b;
+}
+`,
+	})
+	env := func(target string) *libs.Env {
+		tconf, err := types.ConfigForTarget(target)
+		require.NoError(t, err)
+		return libs.NewEnv(tconf)
+	}
+	plats := []Platform{
+		{Name: "linux_amd64", Env: env("amd64")},
+		{Name: "linux_386", Env: env("386")},
+		{
+			Name: "windows", Build: "windows && amd64",
+			Env:    env("amd64"),
+			Define: []Define{{Name: "_WIN32"}},
+			Undef:  []string{"__linux__"},
+		},
+	}
+	err := TranslatePlatforms(src, filepath.Join(src, "sys.c"), out, plats, Config{Package: "sys", MaxDecls: -1})
+	require.NoError(t, err)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, `package sys
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+func is_sep(c int8) int32 {
+	return libc.BoolToInt(int32(c) == SEP)
+}
+func add(a int32, b int32) int32 {
+	return a + b
+}
+`, read("sys.go"))
+	require.Equal(t, `//go:build linux && amd64
+
+package sys
+
+const SEP = 47
+
+type file_t struct {
+	Fd   int32
+	Size int64
+}
+
+func sys_open() int32 {
+	return 3
+}
+`, read("sys_linux_amd64.go"))
+	require.Contains(t, read("sys_linux_386.go"), "//go:build linux && 386\n")
+	require.Contains(t, read("sys_linux_386.go"), "Size int32\n")
+	require.Contains(t, read("sys_windows.go"), "//go:build windows && amd64\n")
+	require.Contains(t, read("sys_windows.go"), "const SEP = 92\n")
+	require.NotContains(t, read("sys_windows.go"), "sys_open")
+	require.Equal(t, `//go:build !(linux && amd64) && !(linux && 386) && !(windows && amd64)
+
+package sys
+
+// sys.c is only translated for: linux_amd64, linux_386, windows.
+var _ = sys_is_not_translated_for_this_platform
+`, read("sys_unsupported.go"))
+}
+
+func TestTranslatePlatformsCommon(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"add.c": `
+int add(int a, int b) {
+	return a + b;
+}
+`,
+	})
+	plats := []Platform{
+		{Name: "linux", Env: libs.NewEnv(types.Config32())},
+		{Name: "windows", Env: libs.NewEnv(types.Config32())},
+	}
+	err := TranslatePlatforms(src, filepath.Join(src, "add.c"), out, plats, Config{Package: "lib", MaxDecls: -1})
+	require.NoError(t, err)
+	list, err := os.ReadDir(out)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range list {
+		names = append(names, f.Name())
+	}
+	require.Equal(t, []string{"add.go"}, names)
+}
@@ -377,7 +377,7 @@ func (g *translator) cPtrToPtr(typ types.Type, x PtrExpr) PtrExpr {
 	if ptyp.Elem() != nil {
 		if s, ok := types.Unwrap(x.PtrType(nil).Elem()).(*types.StructType); ok {
 			fields := s.Fields()
-			if len(fields) != 0 && types.Same(ptyp.Elem(), fields[0].Type()) {
+			if len(fields) != 0 && !fields[0].Hidden && fields[0].BitField == nil && types.Same(ptyp.Elem(), fields[0].Type()) {
 				return g.cAddr(NewCSelectExpr(x, fields[0].Name))
 			}
 		}
@@ -1083,6 +1083,43 @@ func (e *NewExpr) PtrType(_ types.PtrType) types.PtrType {
 	return e.e.PtrT(e.Elem)
 }
 
+var _ PtrExpr = (*NewArrayExpr)(nil)
+
+// NewArrayExpr allocates a typed C array and returns a pointer to its first element.
+type NewArrayExpr struct {
+	e    *types.Env
+	Elem types.Type
+	Size Expr
+}
+
+func (e *NewArrayExpr) Visit(v Visitor) {
+	v(e.Size)
+}
+
+func (e *NewArrayExpr) CType(_ types.Type) types.Type {
+	return e.e.PtrT(e.Elem)
+}
+
+func (e *NewArrayExpr) AsExpr() GoExpr {
+	return call(&ast.IndexExpr{X: ident("libc.NewArray"), Index: e.Elem.GoType()}, e.Size.AsExpr())
+}
+
+func (e *NewArrayExpr) IsConst() bool {
+	return false
+}
+
+func (e *NewArrayExpr) HasSideEffects() bool {
+	return true
+}
+
+func (e *NewArrayExpr) Uses() []types.Usage {
+	return types.UseRead(e.Size)
+}
+
+func (e *NewArrayExpr) PtrType(_ types.PtrType) types.PtrType {
+	return e.e.PtrT(e.Elem)
+}
+
 var _ Expr = (*MakeExpr)(nil)
 
 type MakeExpr struct {
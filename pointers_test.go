@@ -926,6 +926,51 @@ func foo() {
 }
 `,
 	},
+	{
+		name: "malloc array",
+		src: `
+#include <stdlib.h>
+typedef struct node { struct node* next; } node;
+void foo(int n) {
+	node** a = malloc(n * sizeof(node*));
+	a = realloc(a, sizeof(node*) * 2 * n);
+	node* b = realloc(0, n * sizeof(node));
+	void* c = malloc(n * 4);
+	c = realloc(c, n * 8);
+}
+`,
+		exp: `
+type node struct {
+	Next *node
+}
+
+func foo(n int32) {
+	var a **node = libc.NewArray[*node](int(n))
+	a = libc.ReallocArray(a, int(n*2))
+	var b *node = libc.NewArray[node](int(n))
+	_ = b
+	var c unsafe.Pointer = libc.Malloc(int(n * 4))
+	c = libc.Realloc(c, int(n*8))
+}
+`,
+	},
+	{
+		name: "realloc slice",
+		src: `
+#include <stdlib.h>
+void foo(int* a, int n) {
+	a = realloc(a, n * sizeof(int));
+}
+`,
+		exp: `
+func foo(a []int32, n int32) {
+	a = libc.ReallocSlice(a, int(n))
+}
+`,
+		configFuncs: []configFunc{
+			withIdentField("foo", IdentConfig{Name: "a", Type: HintSlice}),
+		},
+	},
 	{
 		name: "memset sizeof",
 		src: `
@@ -1341,7 +1386,7 @@ int* c = (int*)calloc(n, sizeof(int));
 var n int32
 var a []int32 = make([]int32, int(n))
 var b []int32 = make([]int32, int(n))
-var c *int32 = &make([]int32, int(n))[0]
+var c *int32 = libc.NewArray[int32](int(n))
 `,
 		configFuncs: []configFunc{
 			withIdent(IdentConfig{Name: "a", Type: HintSlice}),
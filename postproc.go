@@ -30,7 +30,11 @@ func (g *translator) flatten(decl []CDecl) {
 			continue
 		}
 		cf := g.NewControlFlow(f.Body.Stmts)
-		f.Body.Stmts = cf.Flatten()
+		if stmts, ok := cf.Structure(); ok {
+			f.Body.Stmts = stmts
+		} else {
+			f.Body.Stmts = cf.Flatten()
+		}
 	}
 }
 
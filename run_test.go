@@ -37,6 +37,23 @@ int main() {
     printf("Result: %d\n", final ); 
     return 0;
 }
+`,
+	},
+	{
+		name: "macro funcs",
+		src: `
+#include <stdio.h>
+
+#define MIN(a, b) ((a) < (b) ? (a) : (b))
+#define SQR(x) ((x)*(x))
+#define HALF(x) ((x)/2)
+#define LOW(x) ((unsigned char)((x) & 0xff))
+
+int main() {
+	int a = 3, b = 5;
+	printf("%d %d %d %d\n", MIN(a, b), SQR(b), HALF(b), LOW(0x1234));
+	return 0;
+}
 `,
 	},
 }
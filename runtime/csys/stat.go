@@ -3,37 +3,189 @@ package csys
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
 
-const modeDir = 1
-
 type Mode int32
 
+// File type and permission bits of Mode.
+const (
+	S_IFMT   = Mode(0170000)
+	S_IFSOCK = Mode(0140000)
+	S_IFLNK  = Mode(0120000)
+	S_IFREG  = Mode(0100000)
+	S_IFBLK  = Mode(0060000)
+	S_IFDIR  = Mode(0040000)
+	S_IFCHR  = Mode(0020000)
+	S_IFIFO  = Mode(0010000)
+
+	S_ISUID = Mode(04000)
+	S_ISGID = Mode(02000)
+	S_ISVTX = Mode(01000)
+)
+
+// Types of directory entries.
+const (
+	DT_UNKNOWN = uint8(0)
+	DT_FIFO    = uint8(1)
+	DT_CHR     = uint8(2)
+	DT_DIR     = uint8(4)
+	DT_BLK     = uint8(6)
+	DT_REG     = uint8(8)
+	DT_LNK     = uint8(10)
+	DT_SOCK    = uint8(12)
+)
+
 func IsDir(mode Mode) int32 {
-	if mode&modeDir != 0 {
+	if mode&S_IFMT == S_IFDIR {
+		return 1
+	}
+	return 0
+}
+
+func IsReg(mode Mode) int32 {
+	if mode&S_IFMT == S_IFREG {
 		return 1
 	}
 	return 0
 }
 
+func IsLink(mode Mode) int32 {
+	if mode&S_IFMT == S_IFLNK {
+		return 1
+	}
+	return 0
+}
+
+// ModeOf converts Go file mode to C mode.
+func ModeOf(m os.FileMode) Mode {
+	mode := Mode(m.Perm())
+	switch {
+	case m&os.ModeDir != 0:
+		mode |= S_IFDIR
+	case m&os.ModeSymlink != 0:
+		mode |= S_IFLNK
+	case m&os.ModeNamedPipe != 0:
+		mode |= S_IFIFO
+	case m&os.ModeSocket != 0:
+		mode |= S_IFSOCK
+	case m&os.ModeCharDevice != 0:
+		mode |= S_IFCHR
+	case m&os.ModeDevice != 0:
+		mode |= S_IFBLK
+	case m.IsRegular():
+		mode |= S_IFREG
+	}
+	if m&os.ModeSetuid != 0 {
+		mode |= S_ISUID
+	}
+	if m&os.ModeSetgid != 0 {
+		mode |= S_ISGID
+	}
+	if m&os.ModeSticky != 0 {
+		mode |= S_ISVTX
+	}
+	return mode
+}
+
+// FileMode converts C mode to Go file mode.
+func (m Mode) FileMode() os.FileMode {
+	mode := os.FileMode(m) & os.ModePerm
+	switch m & S_IFMT {
+	case S_IFDIR:
+		mode |= os.ModeDir
+	case S_IFLNK:
+		mode |= os.ModeSymlink
+	case S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case S_IFSOCK:
+		mode |= os.ModeSocket
+	case S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case S_IFBLK:
+		mode |= os.ModeDevice
+	}
+	if m&S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// DirType returns a type of directory entry for Go file mode.
+func DirType(m os.FileMode) uint8 {
+	switch {
+	case m&os.ModeDir != 0:
+		return DT_DIR
+	case m&os.ModeSymlink != 0:
+		return DT_LNK
+	case m&os.ModeNamedPipe != 0:
+		return DT_FIFO
+	case m&os.ModeSocket != 0:
+		return DT_SOCK
+	case m&os.ModeCharDevice != 0:
+		return DT_CHR
+	case m&os.ModeDevice != 0:
+		return DT_BLK
+	case m.IsRegular():
+		return DT_REG
+	}
+	return DT_UNKNOWN
+}
+
+// StatRes is a file status returned by stat. File times are available in C as st_atime, st_mtime and st_ctime
+// macros that refer to seconds of ATim, MTim and CTim.
 type StatRes struct {
-	Dev       int32
-	Inode     int32
+	Dev       uint64
+	Inode     uint64
 	Mode      Mode
 	Links     int32
 	UID       int32
 	GID       int32
-	RDev      int32
+	RDev      uint64
 	Size      uint64
-	ATime     libc.TimeVal
-	MTime     libc.TimeVal
-	CTime     libc.TimeVal
+	ATim      libc.TimeSpec
+	MTim      libc.TimeSpec
+	CTim      libc.TimeSpec
 	BlockSize int32
-	Blocks    int32
+	Blocks    int64
+}
+
+// UTimBuf is a set of file times for utime.
+type UTimBuf struct {
+	ATime libc.Time
+	MTime libc.Time
 }
 
+func timeSpec(t time.Time) libc.TimeSpec {
+	return libc.TimeSpec{Sec: libc.Time(t.Unix()), NSec: int64(t.Nanosecond())}
+}
+
+// Fill sets all fields from Go file info. Fields that are not available in os.FileInfo are taken from
+// the system-specific data on Linux. On other systems, all times are set to the modification time.
+func (st *StatRes) Fill(fi os.FileInfo) {
+	mt := timeSpec(fi.ModTime())
+	*st = StatRes{
+		Mode:      ModeOf(fi.Mode()),
+		Links:     1,
+		Size:      uint64(fi.Size()),
+		ATim:      mt,
+		MTim:      mt,
+		CTim:      mt,
+		BlockSize: 512,
+		Blocks:    (fi.Size() + 511) / 512,
+	}
+	st.fillSys(fi)
+}
+
+// Deprecated: use stdio.Stat, which supports custom filesystems.
 func Stat(path *byte, dst *StatRes) int32 {
 	name := libc.GoString(path)
 	if name == "" {
@@ -45,24 +197,25 @@ func Stat(path *byte, dst *StatRes) int32 {
 		libc.SetErr(err)
 		return -1
 	}
-	dst.Mode = 0
-	if st.Mode().IsDir() {
-		dst.Mode = modeDir
-	}
-	dst.Size = uint64(st.Size())
-	// TODO: other fields
+	dst.Fill(st)
 	return 0
 }
 
+// Deprecated: use stdio.Chmod, which supports custom filesystems.
 func Chmod(path *byte, mode Mode) int32 {
 	spath := libc.GoString(path)
-	log.Printf("TODO: chmod(%q, 0%o)", spath, mode) // TODO
+	err := os.Chmod(spath, mode.FileMode())
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
 	return 0
 }
 
+// Deprecated: use stdio.Mkdir, which supports custom filesystems.
 func Mkdir(path *byte, mode Mode) int32 {
 	spath := libc.GoString(path)
-	err := os.Mkdir(spath, 0755)
+	err := os.Mkdir(spath, mode.FileMode().Perm())
 	log.Printf("mkdir(%q, %x): %v", spath, mode, err)
 	if err != nil {
 		libc.SetErr(err)
@@ -0,0 +1,35 @@
+//go:build linux
+
+package csys
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func (st *StatRes) fillSys(fi os.FileInfo) {
+	s, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	st.Dev = uint64(s.Dev)
+	st.Inode = uint64(s.Ino)
+	st.Links = int32(s.Nlink)
+	st.UID = int32(s.Uid)
+	st.GID = int32(s.Gid)
+	st.RDev = uint64(s.Rdev)
+	st.ATim = timeSpec(time.Unix(s.Atim.Unix()))
+	st.MTim = timeSpec(time.Unix(s.Mtim.Unix()))
+	st.CTim = timeSpec(time.Unix(s.Ctim.Unix()))
+	st.BlockSize = int32(s.Blksize)
+	st.Blocks = int64(s.Blocks)
+}
+
+// Inode returns an inode number of the file, if it is available.
+func Inode(fi os.FileInfo) uint64 {
+	if s, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(s.Ino)
+	}
+	return 0
+}
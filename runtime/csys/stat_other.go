@@ -0,0 +1,12 @@
+//go:build !linux
+
+package csys
+
+import "os"
+
+func (st *StatRes) fillSys(fi os.FileInfo) {}
+
+// Inode returns an inode number of the file, if it is available.
+func Inode(fi os.FileInfo) uint64 {
+	return 0
+}
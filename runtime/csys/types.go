@@ -1,12 +1,31 @@
 package csys
 
-import "os"
+import (
+	"os"
+	"syscall"
+)
 
 const (
-	O_RDONLY = int32(os.O_RDONLY)
-	O_WRONLY = int32(os.O_WRONLY)
-	O_RDWR   = int32(os.O_RDWR)
-	O_CREAT  = int32(os.O_CREATE)
-	O_EXCL   = int32(os.O_EXCL)
-	O_TRUNC  = int32(os.O_TRUNC)
+	O_RDONLY   = int32(os.O_RDONLY)
+	O_WRONLY   = int32(os.O_WRONLY)
+	O_RDWR     = int32(os.O_RDWR)
+	O_ACCMODE  = O_RDONLY | O_WRONLY | O_RDWR
+	O_CREAT    = int32(os.O_CREATE)
+	O_EXCL     = int32(os.O_EXCL)
+	O_TRUNC    = int32(os.O_TRUNC)
+	O_APPEND   = int32(os.O_APPEND)
+	O_SYNC     = int32(os.O_SYNC)
+	O_NONBLOCK = int32(syscall.O_NONBLOCK)
 )
+
+// Commands for fcntl.
+const (
+	F_DUPFD = int32(iota)
+	F_GETFD
+	F_SETFD
+	F_GETFL
+	F_SETFL
+)
+
+// FD_CLOEXEC is a file descriptor flag for F_GETFD and F_SETFD.
+const FD_CLOEXEC = int32(1)
@@ -1,43 +1,168 @@
+// Package dlopen implements dlopen, dlsym and dlerror on top of a registry of libraries implemented in Go.
+//
+// Go programs cannot load C shared objects, thus libraries must register their symbols in advance:
+//
+//	func init() {
+//		dlopen.Register("libfoo.so", dlopen.Symbols{
+//			"foo_init": foo_init,
+//			"foo_ver":  &foo_ver,
+//		})
+//	}
 package dlopen
 
 import (
 	"fmt"
+	"path"
+	"reflect"
+	"sync"
 	"unsafe"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
 
-// TODO: set correct values
+// Flags of Open. Values are the same as in glibc; they are accepted, but have no effect.
 const (
-	RTLD_LAZY = 1 << iota
-	RTLD_NOW
-	RTLD_GLOBAL
-	RTLD_LOCAL
+	RTLD_LAZY   = 0x1
+	RTLD_NOW    = 0x2
+	RTLD_GLOBAL = 0x100
+	RTLD_LOCAL  = 0
 )
 
+// Symbols maps exported symbol names to Go values: either functions, or pointers to variables.
+type Symbols map[string]any
+
 type Library struct {
 	name string
+	syms map[string]unsafe.Pointer
+	refs int // number of Open calls without a matching Close
+}
+
+var (
+	mu     sync.Mutex
+	libs   = make(map[string]*Library)
+	order  []*Library // in order of registration
+	global = &Library{name: ""}
+	// gerr is the last error, as reported by Error. In C it is kept per thread, but goroutines are not bound
+	// to threads, thus it is shared by the whole process.
+	gerr error
+)
+
+// symAddr converts a Go value to a symbol address, as returned by dlsym.
+func symAddr(name string, v any) unsafe.Pointer {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case unsafe.Pointer:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Func:
+		return libc.FuncAddrUnsafe(v)
+	case reflect.Pointer:
+		return rv.UnsafePointer()
+	}
+	panic(fmt.Errorf("dlopen: unsupported type of symbol %q: %T", name, v))
 }
 
-var gerr error
+// Register adds a library with given symbols to the registry, so it can be opened with Open.
+//
+// The name is usually a file name of the shared object (for example, "libfoo.so"). Open also accepts a full path
+// to the file, if its base name matches the registered one. Registering the same name again adds more symbols
+// to the library.
+func Register(name string, syms Symbols) {
+	if name == "" {
+		panic("dlopen: library name must be set")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	l := libs[name]
+	if l == nil {
+		l = &Library{name: name, syms: make(map[string]unsafe.Pointer, len(syms))}
+		libs[name] = l
+		order = append(order, l)
+	}
+	for sym, v := range syms {
+		l.syms[sym] = symAddr(sym, v)
+	}
+}
 
+// Open finds a library in the registry. An empty name returns a handle that searches all registered libraries.
+//
+// It returns nil if the library is not registered, the error can be retrieved with Error.
 func Open(name string, flags int) *Library {
-	// FIXME: dlopen
-	panic(fmt.Errorf("dlopen(%q, 0x%x)", name, flags))
+	mu.Lock()
+	defer mu.Unlock()
+	if name == "" {
+		return global
+	}
+	l := libs[name]
+	if l == nil {
+		l = libs[path.Base(name)]
+	}
+	if l == nil {
+		gerr = fmt.Errorf("%s: cannot open shared object file: No such file or directory", name)
+		return nil
+	}
+	l.refs++
+	return l
 }
 
+// Sym returns an address of a symbol in the library. Functions can be converted back with libc.AsFunc.
+//
+// Nil library (RTLD_DEFAULT) searches all registered libraries. It returns nil if the symbol is not found,
+// the error can be retrieved with Error.
 func (l *Library) Sym(name string) unsafe.Pointer {
-	// FIXME: dlsym
-	panic(fmt.Errorf("dlsym(%q)", name))
+	mu.Lock()
+	defer mu.Unlock()
+	if l == nil || l == global {
+		for _, l2 := range order {
+			if p, ok := l2.syms[name]; ok {
+				return p
+			}
+		}
+		gerr = fmt.Errorf("undefined symbol: %s", name)
+		return nil
+	}
+	if l.refs <= 0 {
+		gerr = fmt.Errorf("%s: invalid library handle", l.name)
+		return nil
+	}
+	p, ok := l.syms[name]
+	if !ok {
+		gerr = fmt.Errorf("%s: undefined symbol: %s", l.name, name)
+		return nil
+	}
+	return p
 }
 
+// Close releases the library handle returned by Open. Symbols of Go libraries cannot be unloaded,
+// thus addresses returned by Sym remain valid.
 func (l *Library) Close() int {
+	mu.Lock()
+	defer mu.Unlock()
+	if l == global {
+		return 0
+	}
+	if l == nil || l.refs <= 0 {
+		gerr = fmt.Errorf("invalid library handle")
+		return -1
+	}
+	l.refs--
 	return 0
 }
 
+// Error returns a description of the last error that occurred in Open, Sym or Close, and clears it.
+// It returns nil if there were no errors since the last call.
+//
+// Unlike in C, the error is not thread-local: an error from one goroutine can be returned in another one.
 func Error() *byte {
-	if gerr == nil {
+	mu.Lock()
+	err := gerr
+	gerr = nil
+	mu.Unlock()
+	if err == nil {
 		return nil
 	}
-	return libc.CString(gerr.Error())
+	return libc.CString(err.Error())
 }
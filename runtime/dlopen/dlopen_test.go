@@ -0,0 +1,59 @@
+package dlopen
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func dlerror() string {
+	return libc.GoString(Error())
+}
+
+func TestRegistry(t *testing.T) {
+	counter := int32(5)
+	Register("libtest.so", Symbols{
+		"add": func(a, b int32) int32 {
+			return a + b
+		},
+		"counter": &counter,
+	})
+	require.Empty(t, dlerror())
+
+	l := Open("libnone.so", RTLD_NOW)
+	require.Nil(t, l)
+	require.Equal(t, "libnone.so: cannot open shared object file: No such file or directory", dlerror())
+	require.Empty(t, dlerror())
+
+	l = Open("/usr/lib/libtest.so", RTLD_NOW)
+	require.NotNil(t, l)
+
+	p := l.Sym("add")
+	require.NotNil(t, p)
+	add := libc.AsFunc(p, (*func(int32, int32) int32)(nil)).(func(int32, int32) int32)
+	require.Equal(t, int32(3), add(1, 2))
+
+	p = l.Sym("counter")
+	require.Equal(t, unsafe.Pointer(&counter), p)
+	*(*int32)(p) = 7
+	require.Equal(t, int32(7), counter)
+
+	require.Nil(t, l.Sym("sub"))
+	require.Equal(t, "libtest.so: undefined symbol: sub", dlerror())
+
+	// default handle searches all libraries
+	var def *Library
+	require.Equal(t, unsafe.Pointer(&counter), def.Sym("counter"))
+	require.Equal(t, unsafe.Pointer(&counter), Open("", RTLD_NOW).Sym("counter"))
+	require.Nil(t, def.Sym("sub"))
+	require.Equal(t, "undefined symbol: sub", dlerror())
+
+	require.Equal(t, 0, l.Close())
+	require.Nil(t, l.Sym("add"))
+	require.Equal(t, "libtest.so: invalid library handle", dlerror())
+	require.Equal(t, -1, l.Close())
+	require.Equal(t, "invalid library handle", dlerror())
+}
@@ -0,0 +1,12 @@
+//go:build !unix && !windows
+
+package libc
+
+import "time"
+
+const cpuTimeRes = time.Nanosecond
+
+// cpuTime returns the time since the program start, since CPU time is not available on this platform.
+func cpuTime() (time.Duration, error) {
+	return time.Since(clockStart), nil
+}
@@ -0,0 +1,23 @@
+package libc
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const threadCPUTimeRes = time.Nanosecond
+
+// threadCPUTime returns CPU time used by the current OS thread.
+//
+// The calling goroutine is locked to its OS thread, so that consecutive calls measure the same thread.
+func threadCPUTime() (time.Duration, error) {
+	runtime.LockOSThread()
+	var ts syscall.Timespec
+	_, _, e := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, CLOCK_THREAD_CPUTIME_ID, uintptr(unsafe.Pointer(&ts)), 0)
+	if e != 0 {
+		return 0, e
+	}
+	return time.Duration(ts.Nano()), nil
+}
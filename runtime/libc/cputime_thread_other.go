@@ -0,0 +1,12 @@
+//go:build !linux
+
+package libc
+
+import "time"
+
+const threadCPUTimeRes = cpuTimeRes
+
+// threadCPUTime returns CPU time used by the process, since CPU time of a thread is not available on this platform.
+func threadCPUTime() (time.Duration, error) {
+	return cpuTime()
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package libc
+
+import (
+	"syscall"
+	"time"
+)
+
+const cpuTimeRes = time.Microsecond
+
+// cpuTime returns user and system CPU time used by the process.
+func cpuTime() (time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, err
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
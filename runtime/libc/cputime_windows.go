@@ -0,0 +1,24 @@
+package libc
+
+import (
+	"syscall"
+	"time"
+)
+
+const cpuTimeRes = 100 * time.Nanosecond
+
+// cpuTime returns user and kernel CPU time used by the process.
+func cpuTime() (time.Duration, error) {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	if err = syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	ticks := func(t syscall.Filetime) int64 {
+		return int64(t.HighDateTime)<<32 | int64(t.LowDateTime)
+	}
+	return time.Duration(ticks(kernel)+ticks(user)) * 100, nil
+}
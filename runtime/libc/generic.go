@@ -0,0 +1,19 @@
+package libc
+
+// Integer is a constraint that permits Go integer types with at least 32 bits.
+//
+// It is used as a type parameter constraint by functions generated from C function-like macros
+// that use integer-only operators (like % or <<).
+//
+// C promotes smaller integer types to int in arithmetic, thus they are excluded from the constraint.
+// This allows generic code to use constants that do not fit into 8 or 16 bits.
+type Integer interface {
+	~int | ~int32 | ~int64 | ~uint | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Number is a constraint that permits Go integer types with at least 32 bits and floating-point types.
+//
+// It is used as a type parameter constraint by functions generated from C function-like macros.
+type Number interface {
+	Integer | ~float32 | ~float64
+}
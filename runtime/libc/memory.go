@@ -4,7 +4,10 @@ import (
 	"unsafe"
 )
 
-var allocs syncMap[unsafe.Pointer, []byte]
+var (
+	allocs      syncMap[unsafe.Pointer, []byte]
+	typedAllocs syncMap[unsafe.Pointer, any] // []T slices allocated by NewArray
+)
 
 // makePad creates a slice with a given size, but adds padding before and after the slice.
 // It is required to make some unsafe C code work, e.g. indexing elements after the slice end.
@@ -66,9 +69,60 @@ func Realloc(buf unsafe.Pointer, sz int) unsafe.Pointer {
 	return unsafe.Pointer(&p[0])
 }
 
+// NewArray allocates a typed array of n elements and returns a pointer to the first one.
+//
+// Unlike Malloc, the memory is visible to Go GC, thus it's safe to store Go pointers in it.
+func NewArray[T any](n int) *T {
+	if n <= 0 {
+		return nil
+	}
+	arr := make([]T, n)
+	p := &arr[0]
+	typedAllocs.Store(unsafe.Pointer(p), arr)
+	return p
+}
+
+// ReallocArray changes the number of elements of a typed array allocated with NewArray.
+// Existing elements are copied to the new array.
+func ReallocArray[T any](p *T, n int) *T {
+	if p == nil {
+		return NewArray[T](n)
+	}
+	defer Free(unsafe.Pointer(p))
+	if n <= 0 {
+		return nil
+	}
+	p2 := NewArray[T](n)
+	dst := unsafe.Slice(p2, n)
+	if v, ok := typedAllocs.Load(unsafe.Pointer(p)); ok {
+		copy(dst, v.([]T))
+	} else if b, ok := withSize(unsafe.Pointer(p)); ok {
+		// allocated with Malloc
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(p2)), n*int(unsafe.Sizeof(*p))), b)
+	} else {
+		// allocated with new(T)
+		dst[0] = *p
+	}
+	return p2
+}
+
+// ReallocSlice changes the length of a slice, similar to realloc. Existing elements are copied to the new slice.
+func ReallocSlice[T any](s []T, n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	if n <= cap(s) {
+		return s[:n]
+	}
+	s2 := make([]T, n)
+	copy(s2, s)
+	return s2
+}
+
 // Free marks the memory as freed. May be a nop in Go.
 func Free(p unsafe.Pointer) {
 	allocs.Delete(p)
+	typedAllocs.Delete(p)
 }
 
 // ToPointer converts a uintptr to unsafe.Pointer.
@@ -1,9 +1,31 @@
 package libc
 
-import "testing"
+import (
+	"testing"
+	"unsafe"
+)
 
 func TestRealloc(t *testing.T) {
 	p := Malloc(1)
 	p = Realloc(p, 32*1024*1024)
 	Free(p)
 }
+
+func TestReallocArray(t *testing.T) {
+	p := NewArray[int32](2)
+	arr := unsafe.Slice(p, 2)
+	arr[0], arr[1] = 1, 2
+	p = ReallocArray(p, 4)
+	arr = unsafe.Slice(p, 4)
+	if arr[0] != 1 || arr[1] != 2 || arr[2] != 0 {
+		t.Fatal("unexpected values:", arr)
+	}
+	if p = ReallocArray(p, 0); p != nil {
+		t.Fatal("expected nil")
+	}
+
+	s := ReallocSlice([]int32{1, 2}, 3)
+	if len(s) != 3 || s[1] != 2 {
+		t.Fatal("unexpected values:", s)
+	}
+}
@@ -1,15 +1,74 @@
 package libc
 
+import "fmt"
+
+// JumpBuf is an equivalent of C jmp_buf.
+//
+// The translator rewrites setjmp calls to SetJump or SetJumpRet, which run the rest of the C function in a closure.
+// LongJump unwinds the stack to this closure and runs it again with a new value.
 type JumpBuf struct {
-	val int // for non-zero size
+	frame *jumpFrame
+}
+
+// jumpFrame identifies an active SetJump call. It is shared by copies of JumpBuf.
+type jumpFrame struct {
+	_ byte // for non-zero size
 }
 
-func (b *JumpBuf) SetJump() int {
-	// returns 0 on the first call (correct behavior)
-	return b.val
+type jumpPanic struct {
+	frame *jumpFrame
+	val   int
 }
 
+func (p *jumpPanic) Error() string {
+	return fmt.Sprintf("longjmp(%d) to a jmp_buf that is no longer active", p.val)
+}
+
+// LongJump transfers control back to the SetJump call that initialized the buffer.
+// SetJump will see the val as the setjmp return value, or 1, if val is zero.
 func (b *JumpBuf) LongJump(val int) {
-	b.val = val
-	panic("longjmp")
+	if b.frame == nil {
+		panic("longjmp to a jmp_buf not initialized with setjmp")
+	}
+	if val == 0 {
+		val = 1
+	}
+	panic(&jumpPanic{frame: b.frame, val: val})
+}
+
+// SetJump initializes the buffer and calls fnc with zero value.
+// Every time LongJump is called on the buffer while fnc is running, fnc is called again with the value passed to LongJump.
+func SetJump(b *JumpBuf, fnc func(v int)) {
+	SetJumpRet(b, func(v int) struct{} {
+		fnc(v)
+		return struct{}{}
+	})
+}
+
+// SetJumpRet is similar to SetJump, but also returns the result of fnc.
+func SetJumpRet[T any](b *JumpBuf, fnc func(v int) T) T {
+	fr := &jumpFrame{}
+	b.frame = fr
+	v := 0
+	for {
+		ret, jv, ok := runJump(fr, fnc, v)
+		if ok {
+			return ret
+		}
+		b.frame = fr // in case the buffer was overwritten by nested setjmp calls
+		v = jv
+	}
+}
+
+func runJump[T any](fr *jumpFrame, fnc func(v int) T, v int) (ret T, jv int, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if p, ok := r.(*jumpPanic); ok && p.frame == fr {
+				jv = p.val
+				return
+			}
+			panic(r)
+		}
+	}()
+	return fnc(v), 0, true
 }
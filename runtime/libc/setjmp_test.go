@@ -0,0 +1,70 @@
+package libc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetJump(t *testing.T) {
+	var (
+		buf  JumpBuf
+		vals []int
+	)
+	SetJump(&buf, func(v int) {
+		vals = append(vals, v)
+		if v < 3 {
+			buf.LongJump(v + 1)
+		}
+	})
+	require.Equal(t, []int{0, 1, 2, 3}, vals)
+}
+
+func TestSetJumpZero(t *testing.T) {
+	var buf JumpBuf
+	r := SetJumpRet(&buf, func(v int) int {
+		if v == 0 {
+			buf.LongJump(0)
+		}
+		return v
+	})
+	require.Equal(t, 1, r)
+}
+
+func TestSetJumpNested(t *testing.T) {
+	var outer, inner JumpBuf
+	r := SetJumpRet(&outer, func(v int) int {
+		if v != 0 {
+			return v
+		}
+		SetJump(&inner, func(v int) {
+			if v == 0 {
+				inner.LongJump(5)
+			}
+			outer.LongJump(v * 2)
+		})
+		return -1
+	})
+	require.Equal(t, 10, r)
+}
+
+func TestSetJumpCopy(t *testing.T) {
+	var buf JumpBuf
+	r := SetJumpRet(&buf, func(v int) int {
+		if v != 0 {
+			return v
+		}
+		cp := buf
+		cp.LongJump(7)
+		return -1
+	})
+	require.Equal(t, 7, r)
+}
+
+func TestLongJumpInactive(t *testing.T) {
+	var buf JumpBuf
+	SetJump(&buf, func(v int) {})
+	require.PanicsWithError(t, "longjmp(2) to a jmp_buf that is no longer active", func() {
+		buf.LongJump(2)
+	})
+}
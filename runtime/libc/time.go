@@ -1,12 +1,21 @@
 package libc
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 	"unsafe"
 )
 
-const CLOCK_REALTIME = 0
-const CLOCK_MONOTONIC = 1
+const (
+	CLOCK_REALTIME           = 0
+	CLOCK_MONOTONIC          = 1
+	CLOCK_PROCESS_CPUTIME_ID = 2
+	CLOCK_THREAD_CPUTIME_ID  = 3
+)
 
 const CLOCKS_PER_SEC = 1000000 // us
 
@@ -34,35 +43,182 @@ func GetTime(dst *Time) Time {
 	return t
 }
 
+// DiffTime returns the difference between two times in seconds.
+func DiffTime(t1, t0 Time) float64 {
+	return float64(t1) - float64(t0)
+}
+
+// Timezone, Daylight and TZName describe the local time zone.
+//
+// They are only written during the package initialization and by Tzset. Transpiled code reads them without
+// synchronization, thus, as in C, Tzset must not be called concurrently with code that uses these variables.
+var (
+	// Timezone is the offset of the standard time in seconds west of UTC.
+	Timezone int64
+	// Daylight is set to 1 if the time zone has daylight saving time rules.
+	Daylight int32
+	// TZName contains abbreviations of the standard time and the daylight saving time.
+	TZName [2]*byte
+)
+
+var (
+	tzMu    sync.RWMutex // guards tzLocal and tzNames, but not the exported variables
+	tzLocal = time.Local
+	tzNames = make(map[string]*byte)
+)
+
+func init() {
+	setLocation(time.Local)
+}
+
+// localLocation returns the time zone used for local time conversions.
+func localLocation() *time.Location {
+	tzMu.RLock()
+	defer tzMu.RUnlock()
+	return tzLocal
+}
+
+// zoneName returns a C string for a time zone abbreviation. Strings are cached, since tm_zone must remain valid.
+func zoneName(name string) *byte {
+	tzMu.Lock()
+	defer tzMu.Unlock()
+	p, ok := tzNames[name]
+	if !ok {
+		p = CString(name)
+		tzNames[name] = p
+	}
+	return p
+}
+
+// Tzset reloads the local time zone from the TZ environment variable. Unknown time zones are treated as UTC.
+//
+// It updates Timezone, Daylight and TZName without synchronization, see their description.
+func Tzset() {
+	loc := time.Local
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		tz = strings.TrimPrefix(tz, ":")
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			loc = time.UTC
+		}
+	}
+	setLocation(loc)
+}
+
+func setLocation(loc *time.Location) {
+	year := time.Now().Year()
+	std := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	dst := time.Date(year, time.July, 1, 0, 0, 0, 0, loc)
+	if std.IsDST() {
+		// southern hemisphere
+		std, dst = dst, std
+	}
+	stdName, stdOff := std.Zone()
+	dstName, _ := dst.Zone()
+	daylight := int32(0)
+	if dst.IsDST() {
+		daylight = 1
+	}
+	stdp, dstp := zoneName(stdName), zoneName(dstName)
+
+	tzMu.Lock()
+	tzLocal = loc
+	tzMu.Unlock()
+
+	Timezone = -int64(stdOff)
+	Daylight = daylight
+	TZName = [2]*byte{stdp, dstp}
+}
+
+// timeInfo fills the broken-down time from Go time, including time zone information.
+func timeInfo(t time.Time) TimeInfo {
+	name, off := t.Zone()
+	dst := int32(0)
+	if t.IsDST() {
+		dst = 1
+	}
+	return TimeInfo{
+		Sec:      int32(t.Second()),
+		Min:      int32(t.Minute()),
+		Hour:     int32(t.Hour()),
+		Day:      int32(t.Day()),
+		Month:    int32(t.Month()) - 1,
+		Year:     int32(t.Year()) - 1900,
+		WeekDay:  int32(t.Weekday()),
+		YearDay:  int32(t.YearDay()) - 1,
+		IsDst:    dst,
+		Timezone: zoneName(name),
+		GMTOffs:  int32(off),
+	}
+}
+
+// MakeTime converts the local broken-down time to a calendar time. Fields of src are normalized, and tm_wday,
+// tm_yday, tm_isdst, tm_zone and tm_gmtoff are set.
 func MakeTime(src *TimeInfo) Time {
 	if src == nil {
 		return Time(time.Now().Unix())
 	}
-	return Time(time.Date(1900+int(src.Year), time.Month(src.Month), int(src.Day), int(src.Hour), int(src.Min), int(src.Sec), 0, time.Local).Unix())
+	t := src.goTime(localLocation())
+	*src = timeInfo(t)
+	return Time(t.Unix())
+}
+
+// TimeGM is the same as MakeTime, but interprets the broken-down time as UTC.
+func TimeGM(src *TimeInfo) Time {
+	t := src.goTime(time.UTC)
+	*src = timeInfo(t)
+	return Time(t.Unix())
 }
 
 func LocalTime(src *Time) *TimeInfo {
-	t := src.GoTime()
-	return &TimeInfo{
-		Sec:     int32(t.Second()),
-		Min:     int32(t.Minute()),
-		Hour:    int32(t.Hour()),
-		Day:     int32(t.Day()),
-		Month:   int32(t.Month()),
-		Year:    int32(t.Year()) - 1900,
-		WeekDay: int32(t.Weekday()),
-		YearDay: int32(t.YearDay()),
-		// TODO
-		IsDst:    0,
-		Timezone: nil,
-		GMTOffs:  0,
-	}
+	return LocalTimeR(src, new(TimeInfo))
+}
+
+// LocalTimeR converts the calendar time to local broken-down time and stores it in dst.
+func LocalTimeR(src *Time, dst *TimeInfo) *TimeInfo {
+	*dst = timeInfo(src.GoTime().In(localLocation()))
+	return dst
+}
+
+// GMTime converts the calendar time to broken-down time in UTC.
+func GMTime(src *Time) *TimeInfo {
+	return GMTimeR(src, new(TimeInfo))
+}
+
+// GMTimeR converts the calendar time to broken-down time in UTC and stores it in dst.
+func GMTimeR(src *Time, dst *TimeInfo) *TimeInfo {
+	*dst = timeInfo(src.GoTime().UTC())
+	return dst
+}
+
+func ascTime(tm *TimeInfo) string {
+	return fmt.Sprintf("%.3s %.3s%3d %.2d:%.2d:%.2d %d\n",
+		weekDayName(tm.WeekDay), monthName(tm.Month),
+		tm.Day, tm.Hour, tm.Min, tm.Sec, 1900+int(tm.Year))
 }
 
 func AscTime(tm *TimeInfo) *byte {
-	t := tm.GoTime()
-	s := t.Format("Mon Jan _2 15:04:05 2006")
-	return CString(s)
+	return CString(ascTime(tm))
+}
+
+// AscTimeR is the same as AscTime, but writes the string to buf, which must be at least 26 bytes long.
+func AscTimeR(tm *TimeInfo, buf *byte) *byte {
+	s := ascTime(tm)
+	b := unsafe.Slice(buf, len(s)+1)
+	copy(b, s)
+	b[len(s)] = 0
+	return buf
+}
+
+// CTime converts the calendar time to a string in local time, as AscTime does.
+func CTime(t *Time) *byte {
+	return AscTime(LocalTime(t))
+}
+
+// CTimeR is the same as CTime, but writes the string to buf, which must be at least 26 bytes long.
+func CTimeR(t *Time, buf *byte) *byte {
+	var tm TimeInfo
+	return AscTimeR(LocalTimeR(t, &tm), buf)
 }
 
 type TimeVal struct {
@@ -75,6 +231,23 @@ type TimeSpec struct {
 	NSec int64
 }
 
+func timeSpec(d time.Duration) TimeSpec {
+	return TimeSpec{Sec: Time(d / time.Second), NSec: int64(d % time.Second)}
+}
+
+func (ts *TimeSpec) GoTime() time.Time {
+	return time.Unix(int64(ts.Sec), ts.NSec)
+}
+
+// ClockGoTime interprets the time value as a point on a given clock and converts it to Go time.
+func (ts *TimeSpec) ClockGoTime(c ClockID) time.Time {
+	switch c {
+	case CLOCK_MONOTONIC:
+		return clockStart.Add(time.Duration(ts.Sec)*time.Second + time.Duration(ts.NSec))
+	}
+	return ts.GoTime()
+}
+
 type TimeInfo struct {
 	Sec      int32
 	Min      int32
@@ -85,28 +258,98 @@ type TimeInfo struct {
 	WeekDay  int32
 	YearDay  int32
 	IsDst    int32
-	Timezone unsafe.Pointer
+	Timezone *byte
 	GMTOffs  int32
 }
 
+// GoTime interprets the broken-down time as local time and converts it to Go time.
 func (tm *TimeInfo) GoTime() time.Time {
-	return MakeTime(tm).GoTime()
+	return tm.goTime(localLocation())
+}
+
+func (tm *TimeInfo) goTime(loc *time.Location) time.Time {
+	t := time.Date(1900+int(tm.Year), time.Month(tm.Month)+1, int(tm.Day), int(tm.Hour), int(tm.Min), int(tm.Sec), 0, loc)
+	want := tm.IsDst > 0
+	if tm.IsDst < 0 || t.IsDST() == want {
+		return t
+	}
+	// The time is either ambiguous, or DST flag does not match the zone in effect.
+	// As in C, interpret the fields with the offset of the adjacent zone that has the requested DST flag.
+	_, off := t.Zone()
+	start, end := t.ZoneBounds()
+	var adj []time.Time
+	if !start.IsZero() {
+		adj = append(adj, start.Add(-time.Second))
+	}
+	if !end.IsZero() {
+		adj = append(adj, end)
+	}
+	for _, z := range adj {
+		if z.IsDST() != want {
+			continue
+		}
+		_, off2 := z.Zone()
+		return t.Add(time.Duration(off-off2) * time.Second)
+	}
+	return t
 }
 
-func ClockGetRes(c Clock, ts *TimeSpec) int32 {
-	panic("TODO")
+// ClockGetRes returns the resolution of the clock.
+func ClockGetRes(c ClockID, ts *TimeSpec) int32 {
+	var res time.Duration
+	switch {
+	case c == CLOCK_REALTIME || c == CLOCK_MONOTONIC:
+		res = time.Nanosecond
+	case c == CLOCK_PROCESS_CPUTIME_ID:
+		res = cpuTimeRes
+	case c == CLOCK_THREAD_CPUTIME_ID:
+		res = threadCPUTimeRes
+	default:
+		SetErr(syscall.EINVAL)
+		return -1
+	}
+	if ts != nil {
+		*ts = timeSpec(res)
+	}
+	return 0
 }
 
-func ClockSetTime(c Clock, ts *TimeSpec) int32 {
-	panic("TODO")
+// ClockSetTime always fails, since changing the system clock is not supported.
+func ClockSetTime(c ClockID, ts *TimeSpec) int32 {
+	if c == CLOCK_REALTIME {
+		SetErr(syscall.EPERM)
+	} else {
+		SetErr(syscall.EINVAL)
+	}
+	return -1
 }
 
-func ClockGetTime(c Clock, ts *TimeSpec) int32 {
-	switch c {
-	case CLOCK_MONOTONIC:
-		d := time.Since(clockStart)
-		*ts = TimeSpec{Sec: Time(d / time.Second), NSec: int64(d % time.Second)}
+// ClockGetTime returns the current time of the clock. CLOCK_PROCESS_CPUTIME_ID returns CPU time of the process.
+//
+// Goroutines are not bound to OS threads, thus CLOCK_THREAD_CPUTIME_ID locks the calling goroutine to its thread
+// and returns CPU time of that thread. It falls back to CPU time of the process on platforms other than Linux.
+func ClockGetTime(c ClockID, ts *TimeSpec) int32 {
+	switch {
+	case c == CLOCK_REALTIME:
+		now := time.Now()
+		*ts = TimeSpec{Sec: Time(now.Unix()), NSec: int64(now.Nanosecond())}
+		return 0
+	case c == CLOCK_MONOTONIC:
+		*ts = timeSpec(time.Since(clockStart))
+		return 0
+	case c == CLOCK_PROCESS_CPUTIME_ID || c == CLOCK_THREAD_CPUTIME_ID:
+		get := cpuTime
+		if c == CLOCK_THREAD_CPUTIME_ID {
+			get = threadCPUTime
+		}
+		d, err := get()
+		if err != nil {
+			SetErr(err)
+			return -1
+		}
+		*ts = timeSpec(d)
 		return 0
 	}
-	panic("TODO")
+	SetErr(syscall.EINVAL)
+	return -1
 }
@@ -0,0 +1,468 @@
+package libc
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var (
+	weekDayNames = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	monthNames   = [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}
+)
+
+func weekDayName(d int32) string {
+	if d < 0 || int(d) >= len(weekDayNames) {
+		return "?"
+	}
+	return weekDayNames[d]
+}
+
+func monthName(m int32) string {
+	if m < 0 || int(m) >= len(monthNames) {
+		return "?"
+	}
+	return monthNames[m]
+}
+
+// StrFTime formats the broken-down time according to the format and writes the result to dst. It returns the number
+// of bytes written, not including the terminating zero, or 0 if the result does not fit into max bytes.
+//
+// All conversion specifiers of C99 and POSIX are supported, as well as %k, %l, %P and %s from GNU. Conversions are
+// done for the C locale, thus E and O modifiers are ignored.
+func StrFTime(dst *byte, max int, format *byte, tm *TimeInfo) int {
+	b := appendTime(nil, GoString(format), tm)
+	if len(b)+1 > max {
+		return 0
+	}
+	out := unsafe.Slice(dst, len(b)+1)
+	copy(out, b)
+	out[len(b)] = 0
+	return len(b)
+}
+
+func appendNum(b []byte, v, width int, pad byte) []byte {
+	if v < 0 {
+		b = append(b, '-')
+		v = -v
+		width--
+	}
+	s := strconv.Itoa(v)
+	for i := len(s); i < width; i++ {
+		b = append(b, pad)
+	}
+	return append(b, s...)
+}
+
+func hour12(h int32) int {
+	h %= 12
+	if h == 0 {
+		return 12
+	}
+	return int(h)
+}
+
+// isoWeek returns ISO 8601 year and week number of the broken-down time.
+func isoWeek(tm *TimeInfo) (year, week int) {
+	return time.Date(1900+int(tm.Year), time.January, 1+int(tm.YearDay), 12, 0, 0, 0, time.UTC).ISOWeek()
+}
+
+func appendTime(b []byte, format string, tm *TimeInfo) []byte {
+	year := 1900 + int(tm.Year)
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i+1 >= len(format) {
+			b = append(b, c)
+			continue
+		}
+		i++
+		if c = format[i]; (c == 'E' || c == 'O') && i+1 < len(format) {
+			i++
+			c = format[i]
+		}
+		switch c {
+		case 'a':
+			b = append(b, weekDayName(tm.WeekDay)[:3]...)
+		case 'A':
+			b = append(b, weekDayName(tm.WeekDay)...)
+		case 'b', 'h':
+			b = append(b, monthName(tm.Month)[:3]...)
+		case 'B':
+			b = append(b, monthName(tm.Month)...)
+		case 'c':
+			b = appendTime(b, "%a %b %e %H:%M:%S %Y", tm)
+		case 'C':
+			c := year / 100
+			if year < 0 && year%100 != 0 {
+				c--
+			}
+			b = appendNum(b, c, 2, '0')
+		case 'd':
+			b = appendNum(b, int(tm.Day), 2, '0')
+		case 'D', 'x':
+			b = appendTime(b, "%m/%d/%y", tm)
+		case 'e':
+			b = appendNum(b, int(tm.Day), 2, ' ')
+		case 'F':
+			b = appendTime(b, "%Y-%m-%d", tm)
+		case 'g':
+			y, _ := isoWeek(tm)
+			b = appendNum(b, (y%100+100)%100, 2, '0')
+		case 'G':
+			y, _ := isoWeek(tm)
+			b = appendNum(b, y, 0, '0')
+		case 'H':
+			b = appendNum(b, int(tm.Hour), 2, '0')
+		case 'I':
+			b = appendNum(b, hour12(tm.Hour), 2, '0')
+		case 'j':
+			b = appendNum(b, int(tm.YearDay)+1, 3, '0')
+		case 'k':
+			b = appendNum(b, int(tm.Hour), 2, ' ')
+		case 'l':
+			b = appendNum(b, hour12(tm.Hour), 2, ' ')
+		case 'm':
+			b = appendNum(b, int(tm.Month)+1, 2, '0')
+		case 'M':
+			b = appendNum(b, int(tm.Min), 2, '0')
+		case 'n':
+			b = append(b, '\n')
+		case 'p':
+			if tm.Hour >= 12 {
+				b = append(b, "PM"...)
+			} else {
+				b = append(b, "AM"...)
+			}
+		case 'P':
+			if tm.Hour >= 12 {
+				b = append(b, "pm"...)
+			} else {
+				b = append(b, "am"...)
+			}
+		case 'r':
+			b = appendTime(b, "%I:%M:%S %p", tm)
+		case 'R':
+			b = appendTime(b, "%H:%M", tm)
+		case 's':
+			b = strconv.AppendInt(b, tm.goTime(localLocation()).Unix(), 10)
+		case 'S':
+			b = appendNum(b, int(tm.Sec), 2, '0')
+		case 't':
+			b = append(b, '\t')
+		case 'T', 'X':
+			b = appendTime(b, "%H:%M:%S", tm)
+		case 'u':
+			d := int(tm.WeekDay)
+			if d == 0 {
+				d = 7
+			}
+			b = appendNum(b, d, 0, '0')
+		case 'U':
+			b = appendNum(b, (int(tm.YearDay)+7-int(tm.WeekDay))/7, 2, '0')
+		case 'V':
+			_, w := isoWeek(tm)
+			b = appendNum(b, w, 2, '0')
+		case 'w':
+			b = appendNum(b, int(tm.WeekDay), 0, '0')
+		case 'W':
+			b = appendNum(b, (int(tm.YearDay)+7-(int(tm.WeekDay)+6)%7)/7, 2, '0')
+		case 'y':
+			b = appendNum(b, (year%100+100)%100, 2, '0')
+		case 'Y':
+			b = appendNum(b, year, 0, '0')
+		case 'z':
+			off := int(tm.GMTOffs)
+			if off < 0 {
+				b = append(b, '-')
+				off = -off
+			} else {
+				b = append(b, '+')
+			}
+			b = appendNum(b, off/3600, 2, '0')
+			b = appendNum(b, off/60%60, 2, '0')
+		case 'Z':
+			b = append(b, GoString(tm.Timezone)...)
+		case '%':
+			b = append(b, '%')
+		default:
+			b = append(b, '%', c)
+		}
+	}
+	return b
+}
+
+// StrPTime parses the string according to the format and stores the result in tm. Only the fields that are present
+// in the input are set, except tm_wday and tm_yday, which are computed when the date is known.
+//
+// It returns a pointer to the first character that was not parsed, or nil if the string does not match the format.
+func StrPTime(s, format *byte, tm *TimeInfo) *byte {
+	p := &timeParser{s: GoString(s), tm: tm}
+	if !p.parse(GoString(format)) {
+		return nil
+	}
+	p.finish()
+	return (*byte)(unsafe.Add(unsafe.Pointer(s), p.pos))
+}
+
+type timeParser struct {
+	s   string
+	pos int
+	tm  *TimeInfo
+
+	century     int
+	haveCentury bool
+	yy          int
+	haveYY      bool
+	pm          bool
+	havePM      bool
+	have12      bool
+	haveMon     bool
+	haveDay     bool
+	haveYDay    bool
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+func (p *timeParser) skipSpace() {
+	for p.pos < len(p.s) && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// num parses a decimal number with at most digits digits and checks that it is in the [min, max] range.
+// As in glibc, it stops before a digit that would make the number larger than max.
+func (p *timeParser) num(digits, min, max int) (int, bool) {
+	p.skipSpace()
+	neg := false
+	if min < 0 && p.pos < len(p.s) && (p.s[p.pos] == '-' || p.s[p.pos] == '+') {
+		neg = p.s[p.pos] == '-'
+		p.pos++
+	}
+	start := p.pos
+	v := 0
+	for p.pos < len(p.s) && p.pos-start < digits && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' && (p.pos == start || v*10 <= max) {
+		v = v*10 + int(p.s[p.pos]-'0')
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false
+	}
+	if neg {
+		v = -v
+	}
+	return v, v >= min && v <= max
+}
+
+// name parses one of the names or its 3-letter abbreviation, ignoring case.
+func (p *timeParser) name(names []string) (int, bool) {
+	rest := p.s[p.pos:]
+	for i, name := range names {
+		if len(rest) >= len(name) && strings.EqualFold(rest[:len(name)], name) {
+			p.pos += len(name)
+			return i, true
+		}
+	}
+	for i, name := range names {
+		if len(name) > 3 && len(rest) >= 3 && strings.EqualFold(rest[:3], name[:3]) {
+			p.pos += 3
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (p *timeParser) parse(format string) bool {
+	tm := p.tm
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if isSpace(c) {
+			p.skipSpace()
+			continue
+		}
+		if c != '%' || i+1 >= len(format) {
+			if p.pos >= len(p.s) || p.s[p.pos] != c {
+				return false
+			}
+			p.pos++
+			continue
+		}
+		i++
+		if c = format[i]; (c == 'E' || c == 'O') && i+1 < len(format) {
+			i++
+			c = format[i]
+		}
+		var (
+			v  int
+			ok bool
+		)
+		switch c {
+		case 'a', 'A':
+			v, ok = p.name(weekDayNames[:])
+			tm.WeekDay = int32(v)
+		case 'b', 'B', 'h':
+			v, ok = p.name(monthNames[:])
+			tm.Month = int32(v)
+			p.haveMon = true
+		case 'c':
+			ok = p.parse("%a %b %e %H:%M:%S %Y")
+		case 'C':
+			v, ok = p.num(2, 0, 99)
+			p.century, p.haveCentury = v, true
+		case 'd', 'e':
+			v, ok = p.num(2, 1, 31)
+			tm.Day = int32(v)
+			p.haveDay = true
+		case 'D', 'x':
+			ok = p.parse("%m/%d/%y")
+		case 'F':
+			ok = p.parse("%Y-%m-%d")
+		case 'g', 'U', 'V', 'W':
+			// parsed, but not used to compute the date
+			_, ok = p.num(2, 0, 99)
+		case 'G':
+			_, ok = p.num(4, 0, 9999)
+		case 'H', 'k':
+			v, ok = p.num(2, 0, 23)
+			tm.Hour = int32(v)
+			p.have12 = false
+		case 'I', 'l':
+			v, ok = p.num(2, 1, 12)
+			tm.Hour = int32(v % 12)
+			p.have12 = true
+		case 'j':
+			v, ok = p.num(3, 1, 366)
+			tm.YearDay = int32(v - 1)
+			p.haveYDay = true
+		case 'm':
+			v, ok = p.num(2, 1, 12)
+			tm.Month = int32(v - 1)
+			p.haveMon = true
+		case 'M':
+			v, ok = p.num(2, 0, 59)
+			tm.Min = int32(v)
+		case 'n', 't':
+			p.skipSpace()
+			ok = true
+		case 'p', 'P':
+			v, ok = p.name([]string{"AM", "PM"})
+			p.pm, p.havePM = v == 1, true
+		case 'r':
+			ok = p.parse("%I:%M:%S %p")
+		case 'R':
+			ok = p.parse("%H:%M")
+		case 's':
+			var sec int
+			sec, ok = p.num(10, -1<<31, 1<<31-1)
+			if ok {
+				*tm = timeInfo(time.Unix(int64(sec), 0).In(localLocation()))
+				p.haveMon, p.haveDay = true, true
+			}
+		case 'S':
+			v, ok = p.num(2, 0, 61)
+			tm.Sec = int32(v)
+		case 'T', 'X':
+			ok = p.parse("%H:%M:%S")
+		case 'u':
+			v, ok = p.num(1, 1, 7)
+			tm.WeekDay = int32(v % 7)
+		case 'w':
+			v, ok = p.num(1, 0, 6)
+			tm.WeekDay = int32(v)
+		case 'y':
+			v, ok = p.num(2, 0, 99)
+			p.yy, p.haveYY = v, true
+		case 'Y':
+			v, ok = p.num(4, -9999, 9999)
+			tm.Year = int32(v - 1900)
+			p.haveCentury, p.haveYY = false, false
+		case 'z':
+			ok = p.zone()
+		case 'Z':
+			// the abbreviation is consumed, but ignored
+			for p.pos < len(p.s) && (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+				p.pos++
+			}
+			ok = true
+		case '%':
+			ok = p.pos < len(p.s) && p.s[p.pos] == '%'
+			p.pos++
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// zone parses the offset from UTC in the +hh, +hhmm or +hh:mm form, or Z.
+func (p *timeParser) zone() bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == 'Z' {
+		p.pos++
+		p.tm.GMTOffs = 0
+		return true
+	}
+	if p.pos >= len(p.s) || (p.s[p.pos] != '+' && p.s[p.pos] != '-') {
+		return false
+	}
+	neg := p.s[p.pos] == '-'
+	p.pos++
+	h, ok := p.num(2, 0, 99)
+	if !ok {
+		return false
+	}
+	m := 0
+	if p.pos < len(p.s) && p.s[p.pos] == ':' {
+		p.pos++
+		if m, ok = p.num(2, 0, 59); !ok {
+			return false
+		}
+	} else if p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		if m, ok = p.num(2, 0, 59); !ok {
+			return false
+		}
+	}
+	off := h*3600 + m*60
+	if neg {
+		off = -off
+	}
+	p.tm.GMTOffs = int32(off)
+	return true
+}
+
+// finish combines the parsed fields and computes the day of week and the day of year, if the date is known.
+func (p *timeParser) finish() {
+	tm := p.tm
+	if p.have12 && p.havePM && p.pm {
+		tm.Hour += 12
+	}
+	switch {
+	case p.haveCentury && p.haveYY:
+		tm.Year = int32(p.century*100 + p.yy - 1900)
+	case p.haveCentury:
+		tm.Year = int32(p.century*100 - 1900)
+	case p.haveYY:
+		if p.yy < 69 {
+			p.yy += 100
+		}
+		tm.Year = int32(p.yy)
+	}
+	year := 1900 + int(tm.Year)
+	switch {
+	case p.haveYDay && !p.haveMon && !p.haveDay:
+		t := time.Date(year, time.January, 1+int(tm.YearDay), 0, 0, 0, 0, time.UTC)
+		tm.Month = int32(t.Month()) - 1
+		tm.Day = int32(t.Day())
+		tm.WeekDay = int32(t.Weekday())
+	case p.haveMon && p.haveDay:
+		t := time.Date(year, time.Month(tm.Month)+1, int(tm.Day), 0, 0, 0, 0, time.UTC)
+		tm.WeekDay = int32(t.Weekday())
+		tm.YearDay = int32(t.YearDay()) - 1
+	}
+}
@@ -0,0 +1,188 @@
+package libc
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setTestTZ(t testing.TB, tz string) {
+	t.Cleanup(Tzset)
+	t.Setenv("TZ", tz)
+	Tzset()
+}
+
+func strftime(tm *TimeInfo, format string) string {
+	var buf [128]byte
+	n := StrFTime(&buf[0], len(buf), CString(format), tm)
+	return string(buf[:n])
+}
+
+func TestGMTime(t *testing.T) {
+	ts := Time(1700000000)
+	tm := GMTime(&ts)
+	require.Equal(t, TimeInfo{
+		Sec: 20, Min: 13, Hour: 22, Day: 14, Month: 10, Year: 123,
+		WeekDay: 2, YearDay: 317, Timezone: tm.Timezone,
+	}, *tm)
+	require.Equal(t, "UTC", GoString(tm.Timezone))
+
+	tm.Day += 20
+	tm.Hour = -1
+	require.Equal(t, Time(1701645200), TimeGM(tm))
+	require.Equal(t, int32(11), tm.Month)
+	require.Equal(t, int32(3), tm.Day)
+	require.Equal(t, int32(23), tm.Hour)
+	require.Equal(t, "Sun Dec  3 23:13:20 2023\n", GoString(AscTime(tm)))
+}
+
+func TestLocalTime(t *testing.T) {
+	setTestTZ(t, "Europe/Berlin")
+	require.Equal(t, int64(-3600), Timezone)
+	require.Equal(t, int32(1), Daylight)
+	require.Equal(t, "CET", GoString(TZName[0]))
+	require.Equal(t, "CEST", GoString(TZName[1]))
+
+	ts := Time(1690000000) // 2023-07-22 04:26:40 UTC
+	var tm TimeInfo
+	LocalTimeR(&ts, &tm)
+	require.Equal(t, int32(6), tm.Hour)
+	require.Equal(t, int32(1), tm.IsDst)
+	require.Equal(t, int32(7200), tm.GMTOffs)
+	require.Equal(t, "CEST", GoString(tm.Timezone))
+	require.Equal(t, "2023-07-22 06:26:40 +0200 CEST", strftime(&tm, "%F %T %z %Z"))
+
+	// 02:30 on 2023-10-29 happens twice; tm_isdst selects one of them
+	tm = TimeInfo{Year: 123, Month: 9, Day: 29, Hour: 2, Min: 30, IsDst: 1}
+	dst := MakeTime(&tm)
+	require.Equal(t, int32(1), tm.IsDst)
+	tm = TimeInfo{Year: 123, Month: 9, Day: 29, Hour: 2, Min: 30, IsDst: 0}
+	std := MakeTime(&tm)
+	require.Equal(t, int32(0), tm.IsDst)
+	require.Equal(t, Time(3600), std-dst)
+
+	// tm_isdst = -1 lets mktime decide
+	tm = TimeInfo{Year: 123, Month: 0, Day: 15, Hour: 12, IsDst: -1}
+	require.Equal(t, Time(time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC).Unix()), MakeTime(&tm))
+	require.Equal(t, int32(0), tm.IsDst)
+	require.Equal(t, int32(3600), tm.GMTOffs)
+	require.Equal(t, int32(0), tm.WeekDay)
+
+	setTestTZ(t, "no/such/zone")
+	require.Equal(t, int64(0), Timezone)
+	require.Equal(t, int32(0), Daylight)
+}
+
+func TestStrFTime(t *testing.T) {
+	ts := Time(1704067199) // 2023-12-31 23:59:59 UTC
+	tm := GMTime(&ts)
+	for _, c := range []struct {
+		format string
+		exp    string
+	}{
+		{"%a %A %b %B %h", "Sun Sunday Dec December Dec"},
+		{"%c", "Sun Dec 31 23:59:59 2023"},
+		{"%C %y %Y", "20 23 2023"},
+		{"%d %e %j", "31 31 365"},
+		{"%D|%x|%F", "12/31/23|12/31/23|2023-12-31"},
+		{"%H %I %k %l %p %P", "23 11 23 11 PM pm"},
+		{"%M %S %m", "59 59 12"},
+		{"%r|%R|%T|%X", "11:59:59 PM|23:59|23:59:59|23:59:59"},
+		{"%u %w", "7 0"},
+		{"%U %W %V %G %g", "53 52 52 2023 23"},
+		{"%z %Z", "+0000 UTC"},
+		{"%n%t%%|%Ey %OH|%q", "\n\t%|23 23|%q"},
+	} {
+		require.Equal(t, c.exp, strftime(tm, c.format), c.format)
+	}
+
+	ts = Time(1704153600) // 2024-01-02 00:00:00 UTC
+	tm = GMTime(&ts)
+	require.Equal(t, "Tue 00 01 2024 24 01 12 AM", strftime(tm, "%a %U %W %G %g %V %I %p"))
+
+	var buf [8]byte
+	require.Equal(t, 0, StrFTime(&buf[0], len(buf), CString("%Y-%m-%d"), tm))
+	require.Equal(t, 7, StrFTime(&buf[0], len(buf), CString("%Y-%m"), tm))
+	require.Equal(t, "2024-01", GoString(&buf[0]))
+}
+
+func TestStrPTime(t *testing.T) {
+	var tm TimeInfo
+	s := CString("2023-11-14T22:13:20 +0130 rest")
+	end := StrPTime(s, CString("%Y-%m-%dT%H:%M:%S %z"), &tm)
+	require.NotNil(t, end)
+	require.Equal(t, " rest", GoString(end))
+	require.Equal(t, TimeInfo{
+		Sec: 20, Min: 13, Hour: 22, Day: 14, Month: 10, Year: 123,
+		WeekDay: 2, YearDay: 317, GMTOffs: 5400,
+	}, tm)
+
+	tm = TimeInfo{}
+	require.NotNil(t, StrPTime(CString("tuesday,  MAR  5 99  07:05:01 pm"), CString("%A, %b %e %y %r"), &tm))
+	require.Equal(t, TimeInfo{
+		Sec: 1, Min: 5, Hour: 19, Day: 5, Month: 2, Year: 99,
+		WeekDay: 5, YearDay: 63,
+	}, tm)
+
+	tm = TimeInfo{}
+	require.NotNil(t, StrPTime(CString("20 05 060 12 AM"), CString("%C %y %j %I %p"), &tm))
+	require.Equal(t, TimeInfo{
+		Day: 1, Month: 2, Year: 105,
+		WeekDay: 2, YearDay: 59,
+	}, tm)
+
+	tm = TimeInfo{}
+	require.NotNil(t, StrPTime(CString("12/31/23 100%"), CString("%D %j%%"), &tm))
+	require.Equal(t, int32(11), tm.Month)
+	require.Equal(t, int32(364), tm.YearDay)
+
+	require.Nil(t, StrPTime(CString("2023-13-01"), CString("%Y-%m-%d"), &tm))
+	require.Equal(t, "1", GoString(StrPTime(CString("10:61"), CString("%H:%M"), &tm)))
+	require.Equal(t, int32(6), tm.Min)
+	require.Nil(t, StrPTime(CString("24:00"), CString("%H:%M"), &tm))
+	require.Nil(t, StrPTime(CString("Foo"), CString("%a"), &tm))
+	require.Nil(t, StrPTime(CString("12"), CString("%H:%M"), &tm))
+}
+
+func TestClocks(t *testing.T) {
+	var ts TimeSpec
+	require.Equal(t, int32(0), ClockGetTime(CLOCK_REALTIME, &ts))
+	require.WithinDuration(t, time.Now(), ts.GoTime(), time.Second)
+
+	require.Equal(t, int32(0), ClockGetTime(CLOCK_PROCESS_CPUTIME_ID, &ts))
+	cpu1 := ts
+	for i := 0; i < 1e6; i++ {
+		_ = CString("busy")
+	}
+	require.Equal(t, int32(0), ClockGetTime(CLOCK_PROCESS_CPUTIME_ID, &ts))
+	require.True(t, ts.Sec > cpu1.Sec || ts.Sec == cpu1.Sec && ts.NSec >= cpu1.NSec)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var ts TimeSpec
+		require.Equal(t, int32(0), ClockGetTime(CLOCK_THREAD_CPUTIME_ID, &ts))
+		cpu1 := ts
+		for i := 0; i < 1e6; i++ {
+			_ = CString("busy")
+		}
+		require.Equal(t, int32(0), ClockGetTime(CLOCK_THREAD_CPUTIME_ID, &ts))
+		require.True(t, ts.Sec > cpu1.Sec || ts.Sec == cpu1.Sec && ts.NSec > cpu1.NSec)
+		require.Equal(t, int32(0), ClockGetRes(CLOCK_THREAD_CPUTIME_ID, &ts))
+		require.NotZero(t, ts.NSec)
+	}()
+	<-done
+
+	require.Equal(t, int32(0), ClockGetRes(CLOCK_MONOTONIC, &ts))
+	require.Equal(t, TimeSpec{NSec: 1}, ts)
+	require.Equal(t, int32(0), ClockGetRes(CLOCK_PROCESS_CPUTIME_ID, &ts))
+	require.NotZero(t, ts.NSec)
+
+	require.Equal(t, int32(-1), ClockGetTime(42, &ts))
+	require.ErrorIs(t, Error(), syscall.EINVAL)
+	require.Equal(t, int32(-1), ClockGetRes(42, &ts))
+	require.Equal(t, int32(-1), ClockSetTime(CLOCK_REALTIME, &ts))
+	require.ErrorIs(t, Error(), syscall.EPERM)
+}
@@ -0,0 +1,109 @@
+package pthread
+
+import (
+	"sync"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// Cond is a C condition variable.
+//
+// Zero value is a valid condition variable using the realtime clock.
+type Cond struct {
+	mu      sync.Mutex // guards fields below
+	clock   libc.ClockID
+	waiters []chan struct{}
+}
+
+func (c *Cond) Init(attr *CondAttr) int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) != 0 {
+		return libc.EBUSY
+	}
+	c.clock = libc.CLOCK_REALTIME
+	if attr != nil {
+		c.clock = attr.clock
+	}
+	return 0
+}
+
+func (c *Cond) Destroy() int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) != 0 {
+		return libc.EBUSY
+	}
+	return 0
+}
+
+// remove the waiter from the queue. It returns false if the waiter was already woken up.
+func (c *Cond) remove(ch chan struct{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, w := range c.waiters {
+		if w == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cond) wait(m *Mutex, abs *libc.TimeSpec) int32 {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.waiters = append(c.waiters, ch)
+	clock := c.clock
+	c.mu.Unlock()
+	if e := m.CUnlock(); e != 0 {
+		c.remove(ch)
+		return e
+	}
+	var res int32
+	if abs == nil {
+		<-ch
+	} else if !waitUntil(ch, abs.ClockGoTime(clock)) && c.remove(ch) {
+		res = libc.ETIMEDOUT
+	}
+	if e := m.CLock(); e != 0 {
+		return e
+	}
+	return res
+}
+
+// Wait atomically releases the mutex and waits for the condition to be signaled. The mutex is reacquired before returning.
+func (c *Cond) Wait(m *Mutex) int32 {
+	return c.wait(m, nil)
+}
+
+// TimedWait is similar to Wait, but returns ETIMEDOUT if the condition is not signaled before an absolute deadline.
+// The deadline is interpreted according to the clock set in CondAttr.
+func (c *Cond) TimedWait(m *Mutex, abs *libc.TimeSpec) int32 {
+	if abs == nil {
+		return libc.EINVAL
+	}
+	return c.wait(m, abs)
+}
+
+// Signal wakes up one of the waiting threads, if any.
+func (c *Cond) Signal() int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) != 0 {
+		close(c.waiters[0])
+		c.waiters = c.waiters[1:]
+	}
+	return 0
+}
+
+// Broadcast wakes up all waiting threads.
+func (c *Cond) Broadcast() int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.waiters {
+		close(w)
+	}
+	c.waiters = nil
+	return 0
+}
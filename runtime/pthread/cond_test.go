@@ -0,0 +1,118 @@
+package pthread
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func TestCondSignal(t *testing.T) {
+	var (
+		m     Mutex
+		c     Cond
+		ready bool
+	)
+	require.Zero(t, c.Init(nil))
+	waiting := make(chan struct{})
+	done := make(chan int32)
+	go func() {
+		m.CLock()
+		defer m.CUnlock()
+		close(waiting)
+		for !ready {
+			if e := c.Wait(&m); e != 0 {
+				done <- e
+				return
+			}
+		}
+		done <- 0
+	}()
+	<-waiting
+	// the goroutine holds the mutex until it waits on the condition
+	m.CLock()
+	ready = true
+	c.Signal()
+	m.CUnlock()
+	require.Zero(t, <-done)
+	require.Zero(t, c.Destroy())
+}
+
+func TestCondBroadcast(t *testing.T) {
+	var (
+		m Mutex
+		c Cond
+	)
+	const n = 5
+	waiting := make(chan struct{}, n)
+	done := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			m.CLock()
+			waiting <- struct{}{}
+			c.Wait(&m)
+			m.CUnlock()
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-waiting
+	}
+	// all goroutines are either waiting or about to wait, which requires the mutex
+	m.CLock()
+	c.Broadcast()
+	m.CUnlock()
+	for i := 0; i < n; i++ {
+		<-done
+	}
+}
+
+func TestCondTimedWait(t *testing.T) {
+	var (
+		m Mutex
+		c Cond
+	)
+	m.CLock()
+	require.Equal(t, int32(libc.ETIMEDOUT), c.TimedWait(&m, deadlineAfter(5*time.Millisecond)))
+	// mutex must be reacquired
+	require.Equal(t, int32(libc.EBUSY), m.TryLock())
+	m.CUnlock()
+}
+
+func TestCondMonotonicClock(t *testing.T) {
+	var attr CondAttr
+	attr.Init()
+	require.Zero(t, attr.SetClock(libc.CLOCK_MONOTONIC))
+	var clock libc.ClockID
+	attr.GetClock(&clock)
+	require.Equal(t, libc.ClockID(libc.CLOCK_MONOTONIC), clock)
+
+	var (
+		m  Mutex
+		c  Cond
+		ts libc.TimeSpec
+	)
+	c.Init(&attr)
+	libc.ClockGetTime(libc.CLOCK_MONOTONIC, &ts)
+	ts.NSec += int64(5 * time.Millisecond)
+	start := time.Now()
+	m.CLock()
+	require.Equal(t, int32(libc.ETIMEDOUT), c.TimedWait(&m, &ts))
+	m.CUnlock()
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestCondErrorCheck(t *testing.T) {
+	var attr MutexAttr
+	attr.Init()
+	attr.SetType(MUTEX_ERRORCHECK)
+	var (
+		m Mutex
+		c Cond
+	)
+	m.Init(&attr)
+	require.Equal(t, int32(libc.EPERM), c.Wait(&m))
+	require.Zero(t, c.Destroy())
+}
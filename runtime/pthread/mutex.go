@@ -0,0 +1,288 @@
+package pthread
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// goDeadline converts an optional absolute C time to Go time. Zero time means no deadline.
+func goDeadline(ts *libc.TimeSpec) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.GoTime()
+}
+
+// waitUntil blocks until the channel is closed or an absolute deadline is reached.
+// It returns false on timeout. Zero deadline means no timeout.
+func waitUntil(ch <-chan struct{}, deadline time.Time) bool {
+	if deadline.IsZero() {
+		<-ch
+		return true
+	}
+	d := time.Until(deadline)
+	if d <= 0 {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ch:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Mutex is a C mutex. It supports normal, recursive and error-checking types.
+//
+// Zero value is a valid unlocked mutex of the default type.
+type Mutex struct {
+	mu     sync.Mutex // guards fields below
+	typ    int32
+	locked bool
+	owner  uint64 // goroutine id; only set for recursive and error-checking mutexes
+	count  int32
+	wake   chan struct{} // closed when the mutex is released
+}
+
+func (m *Mutex) Init(attr *MutexAttr) int32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked {
+		return libc.EBUSY
+	}
+	m.typ = MUTEX_DEFAULT
+	if attr != nil {
+		m.typ = attr.typ
+	}
+	return 0
+}
+
+func (m *Mutex) Destroy() int32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked {
+		return libc.EBUSY
+	}
+	return 0
+}
+
+// tryLock attempts to acquire the mutex. If it's already locked, it returns a channel to wait on.
+func (m *Mutex) tryLock(self uint64) (<-chan struct{}, int32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked {
+		m.locked = true
+		m.owner = self
+		m.count = 1
+		return nil, 0
+	}
+	if self != 0 && m.owner == self {
+		switch m.typ {
+		case MUTEX_RECURSIVE:
+			m.count++
+			return nil, 0
+		case MUTEX_ERRORCHECK:
+			return nil, libc.EDEADLK
+		}
+	}
+	if m.wake == nil {
+		m.wake = make(chan struct{})
+	}
+	return m.wake, libc.EBUSY
+}
+
+func (m *Mutex) lock(deadline time.Time) int32 {
+	var self uint64
+	if m.typ != MUTEX_NORMAL {
+		self = goID()
+	}
+	for {
+		wake, e := m.tryLock(self)
+		if wake == nil {
+			return e
+		}
+		if !waitUntil(wake, deadline) {
+			return libc.ETIMEDOUT
+		}
+	}
+}
+
+// CLock acquires the mutex, blocking if necessary.
+func (m *Mutex) CLock() int32 {
+	return m.lock(time.Time{})
+}
+
+// TryLock acquires the mutex without blocking. It returns EBUSY if the mutex is held by someone else.
+func (m *Mutex) TryLock() int32 {
+	var self uint64
+	if m.typ != MUTEX_NORMAL {
+		self = goID()
+	}
+	_, e := m.tryLock(self)
+	if e == libc.EDEADLK {
+		return libc.EBUSY
+	}
+	return e
+}
+
+// TimedLock acquires the mutex, or returns ETIMEDOUT if it cannot be acquired before an absolute deadline.
+func (m *Mutex) TimedLock(t *libc.TimeSpec) int32 {
+	return m.lock(goDeadline(t))
+}
+
+// CUnlock releases the mutex. Recursive mutexes are released after a matching number of unlocks.
+func (m *Mutex) CUnlock() int32 {
+	var self uint64
+	if m.typ != MUTEX_NORMAL {
+		self = goID()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked || (self != 0 && m.owner != self) {
+		if m.typ == MUTEX_NORMAL {
+			panic("unlock of unlocked mutex")
+		}
+		return libc.EPERM
+	}
+	m.count--
+	if m.count > 0 {
+		return 0
+	}
+	m.locked = false
+	m.owner = 0
+	if m.wake != nil {
+		close(m.wake)
+		m.wake = nil
+	}
+	return 0
+}
+
+// Lock implements sync.Locker.
+func (m *Mutex) Lock() {
+	m.CLock()
+}
+
+// Unlock implements sync.Locker.
+func (m *Mutex) Unlock() {
+	m.CUnlock()
+}
+
+// RWLock is a C read-write lock.
+//
+// Zero value is a valid unlocked lock.
+type RWLock struct {
+	mu      sync.Mutex // guards fields below
+	readers int32
+	writer  bool
+	wake    chan struct{} // closed when the lock state changes
+}
+
+func (l *RWLock) Init(attr *RWLockAttr) int32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.readers != 0 || l.writer {
+		return libc.EBUSY
+	}
+	return 0
+}
+
+func (l *RWLock) Destroy() int32 {
+	return l.Init(nil)
+}
+
+func (l *RWLock) tryLock(write bool) <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if write && !l.writer && l.readers == 0 {
+		l.writer = true
+		return nil
+	} else if !write && !l.writer {
+		l.readers++
+		return nil
+	}
+	if l.wake == nil {
+		l.wake = make(chan struct{})
+	}
+	return l.wake
+}
+
+func (l *RWLock) lock(write bool, deadline time.Time) int32 {
+	for {
+		wake := l.tryLock(write)
+		if wake == nil {
+			return 0
+		}
+		if !waitUntil(wake, deadline) {
+			return libc.ETIMEDOUT
+		}
+	}
+}
+
+// RdLock acquires the lock for reading.
+func (l *RWLock) RdLock() int32 {
+	return l.lock(false, time.Time{})
+}
+
+// TryRdLock acquires the lock for reading without blocking. It returns EBUSY if the lock is held by a writer.
+func (l *RWLock) TryRdLock() int32 {
+	if l.tryLock(false) != nil {
+		return libc.EBUSY
+	}
+	return 0
+}
+
+// TimedRdLock acquires the lock for reading, or returns ETIMEDOUT if it cannot be acquired before an absolute deadline.
+func (l *RWLock) TimedRdLock(t *libc.TimeSpec) int32 {
+	return l.lock(false, goDeadline(t))
+}
+
+// WrLock acquires the lock for writing.
+func (l *RWLock) WrLock() int32 {
+	return l.lock(true, time.Time{})
+}
+
+// TryWrLock acquires the lock for writing without blocking. It returns EBUSY if the lock is held by anyone.
+func (l *RWLock) TryWrLock() int32 {
+	if l.tryLock(true) != nil {
+		return libc.EBUSY
+	}
+	return 0
+}
+
+// TimedWrLock acquires the lock for writing, or returns ETIMEDOUT if it cannot be acquired before an absolute deadline.
+func (l *RWLock) TimedWrLock(t *libc.TimeSpec) int32 {
+	return l.lock(true, goDeadline(t))
+}
+
+// CUnlock releases the lock held either for reading or for writing.
+func (l *RWLock) CUnlock() int32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer {
+		l.writer = false
+	} else if l.readers > 0 {
+		l.readers--
+	} else {
+		return libc.EPERM
+	}
+	if l.wake != nil && l.readers == 0 {
+		close(l.wake)
+		l.wake = nil
+	}
+	return 0
+}
+
+// Once is a C one-time initialization control.
+type Once struct {
+	once sync.Once
+}
+
+// CDo calls the function only once, regardless of how many times it is called for the same Once.
+func (o *Once) CDo(fnc func()) int32 {
+	o.once.Do(fnc)
+	return 0
+}
@@ -0,0 +1,129 @@
+package pthread
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func deadlineAfter(d time.Duration) *libc.TimeSpec {
+	t := time.Now().Add(d)
+	return &libc.TimeSpec{Sec: libc.Time(t.Unix()), NSec: int64(t.Nanosecond())}
+}
+
+func TestMutexNormal(t *testing.T) {
+	var m Mutex
+	require.Zero(t, m.Init(nil))
+	require.Zero(t, m.CLock())
+	require.Equal(t, int32(libc.EBUSY), m.TryLock())
+	require.Equal(t, int32(libc.ETIMEDOUT), m.TimedLock(deadlineAfter(5*time.Millisecond)))
+	require.Equal(t, int32(libc.EBUSY), m.Destroy())
+	require.Zero(t, m.CUnlock())
+	require.Zero(t, m.TryLock())
+	require.Zero(t, m.CUnlock())
+	require.Zero(t, m.Destroy())
+}
+
+func TestMutexRecursive(t *testing.T) {
+	var attr MutexAttr
+	attr.Init()
+	require.Zero(t, attr.SetType(MUTEX_RECURSIVE))
+	var m Mutex
+	require.Zero(t, m.Init(&attr))
+	require.Zero(t, m.CLock())
+	require.Zero(t, m.CLock())
+	require.Zero(t, m.TryLock())
+
+	// the thread reports results of the checks before blocking on the lock held by this thread
+	checked := make(chan [2]int32, 1)
+	var th *Thread
+	Create(&th, nil, func(_ unsafe.Pointer) unsafe.Pointer {
+		checked <- [2]int32{m.TryLock(), m.CUnlock()}
+		m.CLock()
+		m.CUnlock()
+		return nil
+	}, nil)
+	require.Equal(t, [2]int32{libc.EBUSY, libc.EPERM}, <-checked)
+	require.Zero(t, m.CUnlock())
+	require.Zero(t, m.CUnlock())
+	require.Zero(t, m.CUnlock())
+	require.Zero(t, th.Join(nil))
+	require.Equal(t, int32(libc.EPERM), m.CUnlock())
+}
+
+func TestMutexErrorCheck(t *testing.T) {
+	var attr MutexAttr
+	attr.Init()
+	require.Zero(t, attr.SetType(MUTEX_ERRORCHECK))
+	var m Mutex
+	m.Init(&attr)
+	require.Equal(t, int32(libc.EPERM), m.CUnlock())
+	require.Zero(t, m.CLock())
+	require.Equal(t, int32(libc.EDEADLK), m.CLock())
+	require.Equal(t, int32(libc.EBUSY), m.TryLock())
+	require.Zero(t, m.CUnlock())
+}
+
+func TestMutexContention(t *testing.T) {
+	var (
+		m   Mutex
+		wg  sync.WaitGroup
+		cnt int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.CLock()
+				cnt++
+				m.CUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1000, cnt)
+}
+
+func TestRWLock(t *testing.T) {
+	var l RWLock
+	require.Zero(t, l.Init(nil))
+	require.Zero(t, l.RdLock())
+	require.Zero(t, l.TryRdLock())
+	require.Equal(t, int32(libc.EBUSY), l.TryWrLock())
+	require.Equal(t, int32(libc.ETIMEDOUT), l.TimedWrLock(deadlineAfter(5*time.Millisecond)))
+
+	locked := make(chan struct{})
+	go func() {
+		l.WrLock()
+		close(locked)
+	}()
+	require.Zero(t, l.CUnlock())
+	select {
+	case <-locked:
+		t.Fatal("write lock acquired while reading")
+	case <-time.After(5 * time.Millisecond):
+	}
+	require.Zero(t, l.CUnlock())
+	<-locked
+	require.Equal(t, int32(libc.EBUSY), l.TryRdLock())
+	require.Zero(t, l.CUnlock())
+	require.Equal(t, int32(libc.EPERM), l.CUnlock())
+	require.Zero(t, l.Destroy())
+}
+
+func TestOnce(t *testing.T) {
+	var (
+		o   Once
+		cnt int
+	)
+	for i := 0; i < 3; i++ {
+		require.Zero(t, o.CDo(func() { cnt++ }))
+	}
+	require.Equal(t, 1, cnt)
+}
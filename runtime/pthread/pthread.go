@@ -1,32 +1,47 @@
 package pthread
 
 import (
-	"sync"
-	"unsafe"
-
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
 
-const MUTEX_RECURSIVE = 1
+const (
+	MUTEX_NORMAL     = 0
+	MUTEX_RECURSIVE  = 1
+	MUTEX_ERRORCHECK = 2
+	MUTEX_DEFAULT    = MUTEX_NORMAL
+)
+
+const (
+	CREATE_JOINABLE = 0
+	CREATE_DETACHED = 1
+)
 
 type Attr struct {
-	_ int
+	detach int32
 }
 
-func Create(th **Thread, attr *Attr, start func(unsafe.Pointer) unsafe.Pointer, arg unsafe.Pointer) int32 {
-	panic("TODO")
+func (a *Attr) Init() int32 {
+	a.detach = CREATE_JOINABLE
+	return 0
 }
 
-type Thread struct {
-	_ int
+func (a *Attr) Destroy() int32 {
+	return 0
 }
 
-func (th *Thread) Join(ret unsafe.Pointer) int32 {
-	panic("TODO")
+func (a *Attr) SetDetachState(state int32) int32 {
+	switch state {
+	case CREATE_JOINABLE, CREATE_DETACHED:
+	default:
+		return libc.EINVAL
+	}
+	a.detach = state
+	return 0
 }
 
-func (th *Thread) TimedJoinNP(ret unsafe.Pointer, abs *libc.TimeSpec) int32 {
-	panic("TODO")
+func (a *Attr) GetDetachState(state *int32) int32 {
+	*state = a.detach
+	return 0
 }
 
 type MutexAttr struct {
@@ -34,67 +49,67 @@ type MutexAttr struct {
 }
 
 func (m *MutexAttr) Init() int32 {
-	m.typ = 0
+	m.typ = MUTEX_DEFAULT
 	return 0
 }
 
 func (m *MutexAttr) SetType(typ int32) int32 {
+	switch typ {
+	case MUTEX_NORMAL, MUTEX_RECURSIVE, MUTEX_ERRORCHECK:
+	default:
+		return libc.EINVAL
+	}
 	m.typ = typ
 	return 0
 }
 
-func (m *MutexAttr) Destroy() int32 {
-	m.typ = 0
+func (m *MutexAttr) GetType(typ *int32) int32 {
+	*typ = m.typ
 	return 0
 }
 
-type Mutex struct {
-	*sync.Mutex
-}
-
-func (m *Mutex) Init(attr *MutexAttr) int32 {
-	if attr.typ == MUTEX_RECURSIVE {
-		// FIXME
-	}
-	m.Mutex = new(sync.Mutex)
+func (m *MutexAttr) Destroy() int32 {
+	m.typ = MUTEX_DEFAULT
 	return 0
 }
 
-func (m *Mutex) Destroy() int32 {
-	m.Mutex = nil
-	return 0
+type RWLockAttr struct {
+	_ int
 }
 
-func (m *Mutex) CLock() int32 {
-	m.Mutex.Lock()
+func (a *RWLockAttr) Init() int32 {
 	return 0
 }
 
-func (m *Mutex) TryLock() int32 {
-	panic("TODO")
+func (a *RWLockAttr) Destroy() int32 {
+	return 0
 }
 
-func (m *Mutex) TimedLock(t *libc.TimeSpec) int32 {
-	panic("TODO")
+type CondAttr struct {
+	clock libc.ClockID
 }
 
-func (m *Mutex) CUnlock() int32 {
-	m.Mutex.Unlock()
+func (a *CondAttr) Init() int32 {
+	a.clock = libc.CLOCK_REALTIME
 	return 0
 }
 
-type CondAttr struct {
-	// FIXME
+func (a *CondAttr) Destroy() int32 {
+	return 0
 }
 
-func CondInit(c *sync.Cond, attr *CondAttr) int32 {
-	if attr != nil {
-		panic("TODO")
+// SetClock sets the clock used to interpret deadlines in Cond.TimedWait.
+func (a *CondAttr) SetClock(clock libc.ClockID) int32 {
+	switch clock {
+	case libc.CLOCK_REALTIME, libc.CLOCK_MONOTONIC:
+	default:
+		return libc.EINVAL
 	}
-	*c = *sync.NewCond(nil)
+	a.clock = clock
 	return 0
 }
 
-func CondFree(c *sync.Cond) int32 {
+func (a *CondAttr) GetClock(clock *libc.ClockID) int32 {
+	*clock = a.clock
 	return 0
 }
@@ -0,0 +1,310 @@
+package pthread
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// DESTRUCTOR_ITERATIONS is the number of attempts made to destroy thread-specific data on thread exit.
+const DESTRUCTOR_ITERATIONS = 4
+
+// mainGoID is the id of the main goroutine.
+const mainGoID = 1
+
+var (
+	lastThreadID uint64
+	// threads maps goroutine id to *Thread. It contains threads started by Create, as well as other goroutines
+	// that called Self or have thread-specific data.
+	threads sync.Map
+	// running tracks threads started by Create that have not exited yet.
+	running sync.WaitGroup
+)
+
+// Thread is a C thread backed by a goroutine.
+type Thread struct {
+	id       uint64
+	gid      uint64 // goroutine id; set when the thread starts running
+	created  bool   // started by Create, as opposed to an adopted goroutine
+	pinned   bool   // adopted goroutine called Self, thus must be tracked until exit
+	detached int32
+	done     chan struct{}
+	ret      unsafe.Pointer
+	specific map[Key]unsafe.Pointer // only accessed from the thread itself
+}
+
+func newThread(created bool) *Thread {
+	return &Thread{
+		id:      atomic.AddUint64(&lastThreadID, 1),
+		created: created,
+		done:    make(chan struct{}),
+	}
+}
+
+// threadExit is used to unwind the thread's stack in Exit.
+type threadExit struct {
+	ret unsafe.Pointer
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goID returns an ID of the current goroutine. It is relatively expensive, thus threads cache it in Thread.gid.
+func goID() uint64 {
+	// goroutine 123 [running]:
+	var buf [32]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], goroutinePrefix)
+	var id uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	if id == 0 {
+		panic("cannot get goroutine id")
+	}
+	return id
+}
+
+// Create starts a new thread running the start function in a separate goroutine.
+func Create(th **Thread, attr *Attr, start func(unsafe.Pointer) unsafe.Pointer, arg unsafe.Pointer) int32 {
+	if th == nil || start == nil {
+		return libc.EINVAL
+	}
+	t := newThread(true)
+	if attr != nil && attr.detach == CREATE_DETACHED {
+		t.detached = 1
+	}
+	*th = t
+	running.Add(1)
+	go t.run(start, arg)
+	return 0
+}
+
+func (th *Thread) run(start func(unsafe.Pointer) unsafe.Pointer, arg unsafe.Pointer) {
+	th.gid = goID()
+	threads.Store(th.gid, th)
+	defer func() {
+		r := recover()
+		if e, ok := r.(threadExit); ok {
+			th.ret = e.ret
+			r = nil
+		}
+		th.destroySpecific()
+		threads.Delete(th.gid)
+		close(th.done)
+		running.Done()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	th.ret = start(arg)
+}
+
+// Self returns the current thread.
+//
+// Goroutines not started by Create are adopted as threads. Once Self is called, the goroutine stays registered,
+// so that Self always returns the same value for it. There is no way to detect when a goroutine exits, thus
+// the registration is only released when it calls Exit. Adopted goroutines cannot be joined.
+func Self() *Thread {
+	th, ok := current()
+	if !th.created && !th.pinned {
+		th.pinned = true
+		if !ok {
+			threads.Store(th.gid, th)
+		}
+	}
+	return th
+}
+
+// current returns the current thread without registering adopted goroutines.
+// The second value reports whether the thread is registered.
+func current() (*Thread, bool) {
+	gid := goID()
+	if v, ok := threads.Load(gid); ok {
+		return v.(*Thread), true
+	}
+	th := newThread(false)
+	th.gid = gid
+	return th, false
+}
+
+// Equal compares two threads. It returns non-zero if threads are the same.
+func Equal(a, b *Thread) int32 {
+	// threads started by Create are unique; gid is only set before publishing for adopted ones
+	if a == b || (a != nil && b != nil && !a.created && !b.created && a.gid == b.gid) {
+		return 1
+	}
+	return 0
+}
+
+// Exit terminates the current thread. The value is made available to Join.
+//
+// If called from the main goroutine, it waits for all threads started by Create to exit, and then exits the process
+// with status 0, as C does when the last thread terminates.
+func Exit(ret unsafe.Pointer) {
+	th, _ := current()
+	if th.created {
+		panic(threadExit{ret: ret})
+	}
+	// adopted goroutine (including the main one); there is no one to collect the result
+	th.ret = ret
+	th.destroySpecific()
+	threads.Delete(th.gid)
+	close(th.done)
+	if th.gid == mainGoID {
+		running.Wait()
+		os.Exit(0)
+	}
+	runtime.Goexit()
+}
+
+// Detach marks the thread as detached. Its resources are released automatically on exit.
+func (th *Thread) Detach() int32 {
+	if th == nil {
+		return libc.ESRCH
+	}
+	if !atomic.CompareAndSwapInt32(&th.detached, 0, 1) {
+		return libc.EINVAL
+	}
+	return 0
+}
+
+func (th *Thread) canJoin() int32 {
+	if th == nil {
+		return libc.ESRCH
+	} else if cur, _ := current(); Equal(th, cur) != 0 {
+		return libc.EDEADLK
+	} else if !th.created || atomic.LoadInt32(&th.detached) != 0 {
+		// adopted goroutines are not joinable, since there is no way to wait for them
+		return libc.EINVAL
+	}
+	return 0
+}
+
+// Join waits for the thread to exit and stores its return value to ret, if it's not nil.
+func (th *Thread) Join(ret *unsafe.Pointer) int32 {
+	if e := th.canJoin(); e != 0 {
+		return e
+	}
+	<-th.done
+	if ret != nil {
+		*ret = th.ret
+	}
+	return 0
+}
+
+// TimedJoinNP is similar to Join, but returns ETIMEDOUT if the thread has not exited before an absolute deadline.
+func (th *Thread) TimedJoinNP(ret *unsafe.Pointer, abs *libc.TimeSpec) int32 {
+	if e := th.canJoin(); e != 0 {
+		return e
+	}
+	if !waitUntil(th.done, goDeadline(abs)) {
+		return libc.ETIMEDOUT
+	}
+	if ret != nil {
+		*ret = th.ret
+	}
+	return 0
+}
+
+// Key identifies thread-specific data.
+type Key uint32
+
+var keys struct {
+	sync.RWMutex
+	last  Key
+	dtors map[Key]func(unsafe.Pointer)
+}
+
+// KeyCreate allocates a new key for thread-specific data. The destructor, if set, is called on thread exit
+// for each non-nil value associated with the key.
+func KeyCreate(key *Key, dtor func(unsafe.Pointer)) int32 {
+	keys.Lock()
+	defer keys.Unlock()
+	if keys.dtors == nil {
+		keys.dtors = make(map[Key]func(unsafe.Pointer))
+	}
+	keys.last++
+	k := keys.last
+	keys.dtors[k] = dtor
+	*key = k
+	return 0
+}
+
+// KeyDelete releases the key. Destructors are not called for values associated with it.
+func KeyDelete(key Key) int32 {
+	keys.Lock()
+	defer keys.Unlock()
+	if _, ok := keys.dtors[key]; !ok {
+		return libc.EINVAL
+	}
+	delete(keys.dtors, key)
+	return 0
+}
+
+func validKey(key Key) (func(unsafe.Pointer), bool) {
+	keys.RLock()
+	defer keys.RUnlock()
+	dtor, ok := keys.dtors[key]
+	return dtor, ok
+}
+
+// GetSpecific returns a value associated with the key for the current thread.
+func GetSpecific(key Key) unsafe.Pointer {
+	if _, ok := validKey(key); !ok {
+		return nil
+	}
+	th, _ := current()
+	return th.specific[key]
+}
+
+// SetSpecific associates a value with the key for the current thread.
+func SetSpecific(key Key, val unsafe.Pointer) int32 {
+	if _, ok := validKey(key); !ok {
+		return libc.EINVAL
+	}
+	th, _ := current()
+	if val == nil {
+		delete(th.specific, key)
+		if len(th.specific) == 0 && !th.created && !th.pinned {
+			// adopted goroutine no longer needs to be tracked
+			th.specific = nil
+			threads.Delete(th.gid)
+		}
+		return 0
+	}
+	if th.specific == nil {
+		th.specific = make(map[Key]unsafe.Pointer)
+		if !th.created {
+			threads.Store(th.gid, th)
+		}
+	}
+	th.specific[key] = val
+	return 0
+}
+
+func (th *Thread) destroySpecific() {
+	for i := 0; i < DESTRUCTOR_ITERATIONS && len(th.specific) != 0; i++ {
+		called := false
+		for k, v := range th.specific {
+			delete(th.specific, k)
+			if v == nil {
+				continue
+			}
+			if dtor, ok := validKey(k); ok && dtor != nil {
+				dtor(v)
+				called = true
+			}
+		}
+		if !called {
+			break
+		}
+	}
+	th.specific = nil
+}
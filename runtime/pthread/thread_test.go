@@ -0,0 +1,167 @@
+package pthread
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func TestThreadJoin(t *testing.T) {
+	v := 1
+	var th *Thread
+	e := Create(&th, nil, func(arg unsafe.Pointer) unsafe.Pointer {
+		*(*int)(arg) = 2
+		return arg
+	}, unsafe.Pointer(&v))
+	require.Zero(t, e)
+	var ret unsafe.Pointer
+	require.Zero(t, th.Join(&ret))
+	require.Equal(t, unsafe.Pointer(&v), ret)
+	require.Equal(t, 2, v)
+}
+
+func TestThreadExit(t *testing.T) {
+	v := 1
+	var th *Thread
+	Create(&th, nil, func(arg unsafe.Pointer) unsafe.Pointer {
+		Exit(unsafe.Pointer(&v))
+		panic("unreachable")
+	}, nil)
+	var ret unsafe.Pointer
+	require.Zero(t, th.Join(&ret))
+	require.Equal(t, unsafe.Pointer(&v), ret)
+}
+
+func TestThreadDetached(t *testing.T) {
+	var attr Attr
+	attr.Init()
+	require.Zero(t, attr.SetDetachState(CREATE_DETACHED))
+	done := make(chan struct{})
+	var th *Thread
+	Create(&th, &attr, func(arg unsafe.Pointer) unsafe.Pointer {
+		close(done)
+		return nil
+	}, nil)
+	<-done
+	require.Equal(t, int32(libc.EINVAL), th.Join(nil))
+	require.Equal(t, int32(libc.EINVAL), th.Detach())
+}
+
+func TestThreadTimedJoin(t *testing.T) {
+	stop := make(chan struct{})
+	var th *Thread
+	Create(&th, nil, func(arg unsafe.Pointer) unsafe.Pointer {
+		<-stop
+		return nil
+	}, nil)
+	deadline := time.Now().Add(10 * time.Millisecond)
+	ts := &libc.TimeSpec{Sec: libc.Time(deadline.Unix()), NSec: int64(deadline.Nanosecond())}
+	require.Equal(t, int32(libc.ETIMEDOUT), th.TimedJoinNP(nil, ts))
+	close(stop)
+	require.Zero(t, th.Join(nil))
+}
+
+func TestThreadSelf(t *testing.T) {
+	self := Self()
+	require.NotZero(t, Equal(self, Self()))
+	require.Equal(t, int32(libc.EDEADLK), self.Join(nil))
+
+	var th, inner *Thread
+	Create(&th, nil, func(arg unsafe.Pointer) unsafe.Pointer {
+		inner = Self()
+		return nil
+	}, nil)
+	th.Join(nil)
+	require.Equal(t, th, inner)
+	require.Zero(t, Equal(self, th))
+}
+
+func TestThreadSpecific(t *testing.T) {
+	var destroyed []int
+	var key Key
+	require.Zero(t, KeyCreate(&key, func(p unsafe.Pointer) {
+		destroyed = append(destroyed, *(*int)(p))
+	}))
+	defer KeyDelete(key)
+
+	v1, v2 := 1, 2
+	require.Zero(t, SetSpecific(key, unsafe.Pointer(&v1)))
+	var th *Thread
+	Create(&th, nil, func(arg unsafe.Pointer) unsafe.Pointer {
+		if GetSpecific(key) != nil {
+			panic("unexpected value")
+		}
+		SetSpecific(key, unsafe.Pointer(&v2))
+		return GetSpecific(key)
+	}, nil)
+	var ret unsafe.Pointer
+	th.Join(&ret)
+	require.Equal(t, unsafe.Pointer(&v2), ret)
+	require.Equal(t, []int{2}, destroyed)
+	require.Equal(t, unsafe.Pointer(&v1), GetSpecific(key))
+}
+
+func countThreads() int {
+	n := 0
+	threads.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func TestThreadAdopted(t *testing.T) {
+	var key Key
+	require.Zero(t, KeyCreate(&key, nil))
+	defer KeyDelete(key)
+	var attr MutexAttr
+	attr.Init()
+	attr.SetType(MUTEX_RECURSIVE)
+	var m Mutex
+	m.Init(&attr)
+
+	before := countThreads()
+	done := make(chan int)
+	go func() {
+		m.CLock()
+		m.CLock()
+		m.CUnlock()
+		m.CUnlock()
+		_ = GetSpecific(key)
+		n := countThreads()
+		v := 1
+		SetSpecific(key, unsafe.Pointer(&v))
+		n += countThreads()
+		SetSpecific(key, nil)
+		done <- n
+	}()
+	// not registered until it has thread-specific data
+	require.Equal(t, 2*before+1, <-done)
+	require.Equal(t, before, countThreads())
+}
+
+func TestThreadSelfAdopted(t *testing.T) {
+	before := countThreads()
+	self := make(chan *Thread)
+	stop := make(chan struct{})
+	go func() {
+		th := Self()
+		if th != Self() {
+			panic("unexpected thread")
+		}
+		self <- th
+		<-stop
+		Exit(nil)
+	}()
+	th := <-self
+	require.Equal(t, before+1, countThreads())
+	require.Equal(t, int32(libc.EINVAL), th.Join(nil))
+	require.Equal(t, int32(libc.ESRCH), (*Thread)(nil).Join(nil))
+	close(stop)
+	<-th.done
+	require.Equal(t, before, countThreads())
+}
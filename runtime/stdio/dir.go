@@ -0,0 +1,116 @@
+package stdio
+
+import (
+	"os"
+	"path"
+	"syscall"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// DirEnt is a directory entry returned by readdir.
+type DirEnt struct {
+	Ino  uint64
+	Type uint8
+	Name [256]byte
+}
+
+// Dir is an open directory stream.
+//
+// Entries are read when the directory is opened or rewound, thus the stream does not see files
+// that were created or deleted after that. Special entries "." and ".." are always returned first.
+type Dir struct {
+	fs   *filesystem
+	name string
+	ents []os.DirEntry
+	pos  int
+	ent  DirEnt
+}
+
+// OpenDir opens a directory stream. It returns nil if the path is not a directory.
+func OpenDir(name string) *Dir {
+	d := &Dir{fs: defaultFS, name: name}
+	if err := d.load(); err != nil {
+		libc.SetErr(err)
+		return nil
+	}
+	return d
+}
+
+func (d *Dir) load() error {
+	ents, err := d.fs.fs.ReadDir(d.name)
+	if err != nil {
+		return err
+	}
+	d.ents = ents
+	d.pos = 0
+	return nil
+}
+
+// Read returns the next directory entry, or nil at the end of the stream. The entry is overwritten by the next call.
+func (d *Dir) Read() *DirEnt {
+	if d == nil {
+		libc.SetErr(syscall.EBADF)
+		return nil
+	}
+	var (
+		name string
+		ino  uint64
+		typ  = csys.DT_DIR
+	)
+	switch i := d.pos - 2; {
+	case i >= len(d.ents):
+		return nil
+	case i < 0:
+		name = "."
+		if d.pos == 1 {
+			name = ".."
+		}
+		if st, err := d.fs.fs.Stat(path.Join(d.name, name)); err == nil {
+			ino = csys.Inode(st)
+		}
+	default:
+		e := d.ents[i]
+		name = e.Name()
+		typ = csys.DirType(e.Type())
+		if st, err := e.Info(); err == nil {
+			ino = csys.Inode(st)
+		}
+	}
+	d.pos++
+	d.ent = DirEnt{Ino: ino, Type: typ}
+	copy(d.ent.Name[:len(d.ent.Name)-1], name)
+	return &d.ent
+}
+
+// TellDir returns the current position in the stream.
+func (d *Dir) TellDir() int64 {
+	return int64(d.pos)
+}
+
+// SeekDir sets the position in the stream, as returned by TellDir.
+func (d *Dir) SeekDir(pos int64) {
+	if pos < 0 {
+		pos = 0
+	}
+	d.pos = int(pos)
+}
+
+// Rewind resets the position to the beginning of the stream and reads the directory again.
+func (d *Dir) Rewind() {
+	if err := d.load(); err != nil {
+		d.ents = nil
+		d.pos = 0
+	}
+}
+
+func (d *Dir) Close() int32 {
+	if d == nil {
+		libc.SetErr(syscall.EBADF)
+		return -1
+	}
+	d.ents = nil
+	d.pos = 0
+	return 0
+}
@@ -0,0 +1,60 @@
+package stdio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func readDirNames(d *Dir) map[string]uint8 {
+	names := make(map[string]uint8)
+	for e := d.Read(); e != nil; e = d.Read() {
+		names[libc.GoString(&e.Name[0])] = e.Type
+	}
+	return names
+}
+
+func TestDir(t *testing.T) {
+	mfs := NewMemFS()
+	setTestFS(t, mfs)
+	require.NoError(t, mfs.MkdirAll("/data/sub", 0755))
+	require.NoError(t, mfs.WriteFile("/data/a.txt", []byte("a"), 0644))
+
+	require.Nil(t, OpenDir("/data/a.txt"))
+	require.Nil(t, OpenDir("/none"))
+
+	d := OpenDir("/data")
+	require.NotNil(t, d)
+	require.Equal(t, map[string]uint8{
+		".":     csys.DT_DIR,
+		"..":    csys.DT_DIR,
+		"a.txt": csys.DT_REG,
+		"sub":   csys.DT_DIR,
+	}, readDirNames(d))
+	require.Nil(t, d.Read())
+
+	d.SeekDir(2)
+	e := d.Read()
+	require.NotNil(t, e)
+	require.Equal(t, "a.txt", libc.GoString(&e.Name[0]))
+	pos := d.TellDir()
+	e = d.Read()
+	require.Equal(t, "sub", libc.GoString(&e.Name[0]))
+	d.SeekDir(pos)
+	e = d.Read()
+	require.Equal(t, "sub", libc.GoString(&e.Name[0]))
+
+	require.Equal(t, int32(0), Mkdir(libc.CString("/data/new"), 0755))
+	d.Rewind()
+	require.Len(t, readDirNames(d), 5)
+	require.Equal(t, int32(0), d.Close())
+
+	require.Equal(t, int32(0), Rmdir(libc.CString("/data/new")))
+	var st csys.StatRes
+	require.Equal(t, int32(0), Stat(libc.CString("/data/a.txt"), &st))
+	require.Equal(t, csys.S_IFREG|0644, st.Mode)
+	require.Equal(t, uint64(1), st.Size)
+}
@@ -0,0 +1,279 @@
+package stdio
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// badFD is returned instead of a file descriptor on errors. It converts to -1 when assigned to C int.
+const badFD = ^uintptr(0)
+
+// OPEN_MAX is the size of the file descriptor table. File descriptors are always less than this value.
+const OPEN_MAX = 1024
+
+const (
+	accMode = os.O_RDONLY | os.O_WRONLY | os.O_RDWR
+	// setFlags is a set of flags that can be changed with F_SETFL.
+	setFlags = os.O_APPEND | syscall.O_NONBLOCK
+)
+
+// fileDesc is an open file description. It is shared by all file descriptors created with dup and by FILE streams
+// opened with fdopen, thus they share the file offset and status flags.
+type fileDesc struct {
+	file FileI
+
+	mu    sync.Mutex
+	flags int // os.O_* flags; same values as csys.O_*
+	refs  int // number of file descriptors that refer to this description
+}
+
+var _ FileI = (*fileDesc)(nil)
+
+func newFileDesc(f FileI, flags int) *fileDesc {
+	return &fileDesc{file: f, flags: flags}
+}
+
+func (d *fileDesc) getFlags() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.flags
+}
+
+func (d *fileDesc) setFlags(flags int) {
+	d.mu.Lock()
+	d.flags = d.flags&^setFlags | flags&setFlags
+	d.mu.Unlock()
+}
+
+func (d *fileDesc) canRead() bool {
+	return d.getFlags()&accMode != os.O_WRONLY
+}
+
+func (d *fileDesc) Fd() uintptr {
+	return d.file.Fd()
+}
+
+func (d *fileDesc) Name() string {
+	return d.file.Name()
+}
+
+func (d *fileDesc) Sync() error {
+	return d.file.Sync()
+}
+
+func (d *fileDesc) Read(p []byte) (int, error) {
+	if !d.canRead() {
+		return 0, syscall.EBADF
+	}
+	return d.file.Read(p)
+}
+
+func (d *fileDesc) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.flags&accMode == os.O_RDONLY {
+		return 0, syscall.EBADF
+	}
+	// O_APPEND is emulated, since it cannot be changed on an open file otherwise
+	if d.flags&os.O_APPEND != 0 {
+		if _, err := d.file.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	}
+	return d.file.Write(p)
+}
+
+func (d *fileDesc) Seek(off int64, whence int) (int64, error) {
+	return d.file.Seek(off, whence)
+}
+
+// Close closes the underlying file. It must only be called when there are no file descriptors left.
+func (d *fileDesc) Close() error {
+	return d.file.Close()
+}
+
+// closedFile replaces the file of a FILE stream when its file descriptor is closed. All operations fail with EBADF.
+type closedFile struct{}
+
+var _ FileI = closedFile{}
+
+func (closedFile) Fd() uintptr                    { return badFD }
+func (closedFile) Name() string                   { return "" }
+func (closedFile) Sync() error                    { return syscall.EBADF }
+func (closedFile) Read([]byte) (int, error)       { return 0, syscall.EBADF }
+func (closedFile) Write([]byte) (int, error)      { return 0, syscall.EBADF }
+func (closedFile) Seek(int64, int) (int64, error) { return 0, syscall.EBADF }
+func (closedFile) Close() error                   { return syscall.EBADF }
+
+// fdEntry is an entry in the file descriptor table.
+type fdEntry struct {
+	desc    *fileDesc
+	cloexec bool
+}
+
+// allocFD installs the file description to the lowest free file descriptor that is not less than min.
+// It fails with EMFILE if the table is full. It must be called with the lock held.
+func (fs *filesystem) allocFD(d *fileDesc, min uintptr) (uintptr, error) {
+	fd := min
+	for ; fd < uintptr(len(fs.fds)); fd++ {
+		if fs.fds[fd] == nil {
+			break
+		}
+	}
+	if fd >= OPEN_MAX {
+		return badFD, syscall.EMFILE
+	}
+	fs.setFD(fd, d)
+	return fd, nil
+}
+
+// setFD installs the file description to a given file descriptor, which must be free and less than OPEN_MAX.
+// It must be called with the lock held.
+func (fs *filesystem) setFD(fd uintptr, d *fileDesc) {
+	for uintptr(len(fs.fds)) <= fd {
+		fs.fds = append(fs.fds, nil)
+	}
+	d.mu.Lock()
+	d.refs++
+	d.mu.Unlock()
+	fs.fds[fd] = &fdEntry{desc: d}
+}
+
+// entry returns the file descriptor table entry. It must be called with the lock held.
+func (fs *filesystem) entry(fd uintptr) *fdEntry {
+	if fd >= uintptr(len(fs.fds)) {
+		return nil
+	}
+	return fs.fds[fd]
+}
+
+// descByFD returns an open file description for the file descriptor.
+func (fs *filesystem) descByFD(fd uintptr) (*fileDesc, error) {
+	fs.RLock()
+	e := fs.entry(fd)
+	fs.RUnlock()
+	if e == nil {
+		return nil, syscall.EBADF
+	}
+	return e.desc, nil
+}
+
+// releaseFD removes the file descriptor from the table, and marks the FILE stream associated with it as closed.
+// It returns the open file description, if it must be closed. It must be called with the lock held.
+func (fs *filesystem) releaseFD(fd uintptr) (*fileDesc, error) {
+	e := fs.entry(fd)
+	if e == nil {
+		return nil, syscall.EBADF
+	}
+	fs.fds[fd] = nil
+	if f := fs.byFD[fd]; f != nil {
+		// the stream must not refer to the descriptor, since it may be reused
+		f.file = closedFile{}
+		delete(fs.byFD, fd)
+	}
+	d := e.desc
+	d.mu.Lock()
+	d.refs--
+	last := d.refs == 0
+	d.mu.Unlock()
+	if !last {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// closeFD closes the file descriptor. The file is closed when the last descriptor referring to it is closed.
+func (fs *filesystem) closeFD(fd uintptr) error {
+	fs.Lock()
+	d, err := fs.releaseFD(fd)
+	fs.Unlock()
+	if err != nil || d == nil {
+		return err
+	}
+	return d.Close()
+}
+
+// openFD opens a file and allocates a new file descriptor for it.
+func (fs *filesystem) openFD(path string, flags int, mode os.FileMode) (uintptr, error) {
+	d, err := fs.openDesc(path, flags, mode)
+	if err != nil {
+		return badFD, err
+	}
+	fs.Lock()
+	fd, err := fs.allocFD(d, 0)
+	fs.Unlock()
+	if err != nil {
+		_ = d.Close()
+		return badFD, err
+	}
+	return fd, nil
+}
+
+func (fs *filesystem) openDesc(path string, flags int, mode os.FileMode) (*fileDesc, error) {
+	f, err := fs.fs.Open(path, flags&^os.O_APPEND, mode)
+	if err != nil {
+		return nil, err
+	}
+	return newFileDesc(f, flags), nil
+}
+
+// dupFD duplicates the file descriptor to the lowest free one that is not less than min.
+func (fs *filesystem) dupFD(fd uintptr, min uintptr) (uintptr, error) {
+	if min >= OPEN_MAX {
+		return badFD, syscall.EINVAL
+	}
+	fs.Lock()
+	defer fs.Unlock()
+	e := fs.entry(fd)
+	if e == nil {
+		return badFD, syscall.EBADF
+	}
+	return fs.allocFD(e.desc, min)
+}
+
+// dup2FD duplicates the file descriptor to a given one. If fd2 is open, it is closed first.
+func (fs *filesystem) dup2FD(fd, fd2 uintptr) (uintptr, error) {
+	if fd2 >= OPEN_MAX {
+		// also covers negative descriptors
+		return badFD, syscall.EBADF
+	}
+	fs.Lock()
+	e := fs.entry(fd)
+	if e == nil {
+		fs.Unlock()
+		return badFD, syscall.EBADF
+	}
+	if fd == fd2 {
+		fs.Unlock()
+		return fd2, nil
+	}
+	var old *fileDesc
+	if fs.entry(fd2) != nil {
+		old, _ = fs.releaseFD(fd2)
+	}
+	fs.setFD(fd2, e.desc)
+	fs.Unlock()
+	if old != nil {
+		// errors are ignored, as in dup2
+		_ = old.Close()
+	}
+	return fd2, nil
+}
+
+// ctlInt converts an optional integer argument of open or fcntl.
+func ctlInt(ctls []interface{}) (int64, bool) {
+	if len(ctls) == 0 || ctls[0] == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(ctls[0])
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
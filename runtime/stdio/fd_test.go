@@ -0,0 +1,149 @@
+package stdio
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func writeStr(fd uintptr, s string) int {
+	b := []byte(s)
+	return Write(fd, unsafe.Pointer(&b[0]), len(b))
+}
+
+func TestFDTable(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.txt")
+
+	fd := Open(libc.CString(name), csys.O_CREAT|csys.O_WRONLY|csys.O_TRUNC, csys.Mode(0600))
+	require.NotEqual(t, badFD, fd)
+	require.True(t, fd > 2)
+	st, err := os.Stat(name)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	require.Equal(t, 3, writeStr(fd, "abc"))
+	require.Equal(t, int32(os.O_WRONLY), FDControl(fd, csys.F_GETFL)&csys.O_ACCMODE)
+
+	// reading from a write-only descriptor
+	var buf [8]byte
+	require.Equal(t, -1, Read(fd, unsafe.Pointer(&buf[0]), len(buf)))
+
+	// duplicates share the offset
+	fd2 := Dup(fd)
+	require.NotEqual(t, badFD, fd2)
+	require.Equal(t, 3, writeStr(fd2, "def"))
+	require.Equal(t, uint64(6), Lseek(fd, 0, SEEK_CUR))
+
+	// append is applied to all duplicates
+	require.Equal(t, uint64(0), Lseek(fd, 0, SEEK_SET))
+	require.Equal(t, int32(0), FDControl(fd2, csys.F_SETFL, csys.O_APPEND))
+	require.Equal(t, 1, writeStr(fd, "g"))
+
+	fd3 := uintptr(FDControl(fd, csys.F_DUPFD, 10))
+	require.Equal(t, uintptr(10), fd3)
+	require.Equal(t, fd3, Dup2(fd, fd3))
+	require.Equal(t, int32(0), Close(fd))
+	require.Equal(t, int32(0), Close(fd2))
+	require.Equal(t, int32(-1), Close(fd2))
+
+	// stream shares the descriptor
+	f := FDOpen(fd3, "a")
+	require.NotNil(t, f)
+	require.Equal(t, fd3, f.FileNo())
+	require.Nil(t, FDOpen(fd3, "r"))
+	require.Equal(t, int64(1), f.PutS(libc.CString("h")))
+	require.Equal(t, int32(0), f.Close())
+	require.Equal(t, int32(-1), Close(fd3))
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	require.Equal(t, "abcdefgh", string(data))
+
+	// lowest descriptor is reused
+	fd = Open(libc.CString(name), csys.O_RDONLY)
+	require.NotEqual(t, badFD, fd)
+	f = FDOpen(fd, "r")
+	require.NotNil(t, f)
+	n := Read(fd, unsafe.Pointer(&buf[0]), 2)
+	require.Equal(t, 2, n)
+	require.Equal(t, 'c', rune(f.GetC()))
+
+	// reopen preserves the descriptor
+	name2 := filepath.Join(dir, "b.txt")
+	require.Equal(t, 0, Rename(name, name2))
+	f2 := FReOpen(name2, "r+", f)
+	require.True(t, f == f2)
+	require.Equal(t, fd, f.FileNo())
+	require.Equal(t, 'a', rune(f.GetC()))
+
+	// closing the descriptor closes the stream, even if the descriptor is reused
+	require.Equal(t, int32(0), Close(fd))
+	fd2 = Open(libc.CString(name2), csys.O_RDONLY)
+	require.Equal(t, fd, fd2)
+	require.Equal(t, -1, f.GetC())
+	require.Equal(t, int32(EOF), f.Close())
+	require.Nil(t, FReOpen("", "r", f))
+	require.Equal(t, 1, Read(fd2, unsafe.Pointer(&buf[0]), 1))
+	require.Equal(t, int32(0), Close(fd2))
+
+	require.Equal(t, 0, Remove(name2))
+	require.Equal(t, -1, Remove(name2))
+	require.Equal(t, badFD, Open(libc.CString(name2), csys.O_RDONLY))
+}
+
+func TestPipe(t *testing.T) {
+	var fds [2]int32
+	require.Equal(t, int32(0), Pipe(&fds[0]))
+	r, w := uintptr(fds[0]), uintptr(fds[1])
+	require.Equal(t, int32(os.O_RDONLY), FDControl(r, csys.F_GETFL)&csys.O_ACCMODE)
+	require.Equal(t, int32(os.O_WRONLY), FDControl(w, csys.F_GETFL)&csys.O_ACCMODE)
+
+	require.Equal(t, int32(0), FDControl(w, csys.F_GETFD))
+	require.Equal(t, int32(0), FDControl(w, csys.F_SETFD, csys.FD_CLOEXEC))
+	require.Equal(t, csys.FD_CLOEXEC, FDControl(w, csys.F_GETFD))
+
+	require.Equal(t, 5, writeStr(w, "hello"))
+	require.Equal(t, int32(0), Close(w))
+
+	var buf [8]byte
+	require.Equal(t, 5, Read(r, unsafe.Pointer(&buf[0]), len(buf)))
+	require.Equal(t, "hello", string(buf[:5]))
+	require.Equal(t, 0, Read(r, unsafe.Pointer(&buf[0]), len(buf)))
+	require.Equal(t, int32(0), Close(r))
+}
+
+func TestFDLimit(t *testing.T) {
+	require.Equal(t, int32(OPEN_MAX), GetDTableSize())
+
+	fd := Dup(1)
+	require.NotEqual(t, badFD, fd)
+	defer Close(fd)
+
+	neg := int32(-2)
+	require.Equal(t, badFD, Dup2(fd, uintptr(neg)))
+	require.ErrorIs(t, libc.Error(), syscall.EBADF)
+	require.Equal(t, badFD, Dup2(fd, 1<<30))
+	require.ErrorIs(t, libc.Error(), syscall.EBADF)
+	require.Equal(t, badFD, Dup2(fd, OPEN_MAX))
+	require.ErrorIs(t, libc.Error(), syscall.EBADF)
+
+	require.Equal(t, int32(-1), FDControl(fd, csys.F_DUPFD, int64(1)<<40))
+	require.ErrorIs(t, libc.Error(), syscall.EINVAL)
+	require.Equal(t, int32(-1), FDControl(fd, csys.F_DUPFD, int32(OPEN_MAX)))
+	require.ErrorIs(t, libc.Error(), syscall.EINVAL)
+
+	// the last descriptor is still available, but the table is full after it
+	last := Dup2(fd, OPEN_MAX-1)
+	require.Equal(t, uintptr(OPEN_MAX-1), last)
+	require.Equal(t, int32(-1), FDControl(fd, csys.F_DUPFD, int32(OPEN_MAX-1)))
+	require.ErrorIs(t, libc.Error(), syscall.EMFILE)
+	require.Equal(t, int32(0), Close(last))
+}
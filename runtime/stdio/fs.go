@@ -1,10 +1,11 @@
 package stdio
 
 import (
-	"errors"
 	"io"
 	"os"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
@@ -25,10 +26,16 @@ type Filesystem interface {
 	Stdin() FileI
 	Getwd() (string, error)
 	Chdir(path string) error
+	Mkdir(path string, perm os.FileMode) error
 	Rmdir(path string) error
+	ReadDir(path string) ([]os.DirEntry, error)
 	Unlink(path string) error
+	Rename(from, to string) error
 	Open(path string, flag int, mode os.FileMode) (FileI, error)
 	Stat(path string) (os.FileInfo, error)
+	Chmod(path string, mode os.FileMode) error
+	Chown(path string, uid, gid int) error
+	Chtimes(path string, atime, mtime time.Time) error
 }
 
 var defaultFS *filesystem
@@ -41,11 +48,11 @@ func SetFS(fs Filesystem) {
 	if fs == nil {
 		fs = localFS{}
 	}
-	// TODO: mount stdout, stderr, stdin
 	defaultFS = &filesystem{
 		fs:   fs,
 		byFD: make(map[uintptr]*File),
 	}
+	defaultFS.mountStd()
 }
 
 func FS() Filesystem {
@@ -67,15 +74,37 @@ func ByFD(fd uintptr) *File {
 type filesystem struct {
 	fs Filesystem
 	sync.RWMutex
-	byFD map[uintptr]*File
+	fds  []*fdEntry        // file descriptor table
+	byFD map[uintptr]*File // FILE streams associated with file descriptors
 }
 
+// mountStd installs stdin, stdout and stderr as file descriptors 0, 1 and 2.
+func (fs *filesystem) mountStd() {
+	fs.Lock()
+	defer fs.Unlock()
+	fs.setFD(0, newFileDesc(fs.fs.Stdin(), os.O_RDONLY))
+	fs.setFD(1, newFileDesc(fs.fs.Stdout(), os.O_WRONLY))
+	fs.setFD(2, newFileDesc(fs.fs.Stderr(), os.O_WRONLY))
+}
+
+// fileByFD returns a FILE stream for the file descriptor. The stream is created if necessary.
 func (fs *filesystem) fileByFD(fd uintptr) (*File, error) {
 	fs.RLock()
 	f := fs.byFD[fd]
 	fs.RUnlock()
-	if f == nil {
-		return nil, errors.New("invalid fd")
+	if f != nil {
+		return f, nil
+	}
+	fs.Lock()
+	defer fs.Unlock()
+	if f = fs.byFD[fd]; f != nil {
+		return f, nil
+	}
+	e := fs.entry(fd)
+	if e == nil {
+		return nil, syscall.EBADF
 	}
+	f = &File{fs: fs, fd: fd, file: e.desc}
+	fs.byFD[fd] = f
 	return f, nil
 }
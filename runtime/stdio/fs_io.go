@@ -0,0 +1,171 @@
+package stdio
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NewIOFS creates a read-only Filesystem from io/fs.FS (for example, embed.FS).
+//
+// Paths are slash-separated and are resolved from the root of fsys. Relative paths are resolved from the current
+// directory, which is "/" initially. Standard streams are the ones of the process.
+func NewIOFS(fsys fs.FS) Filesystem {
+	return &ioFS{fsys: fsys, cwd: "/"}
+}
+
+type ioFS struct {
+	fsys fs.FS
+	mu   sync.RWMutex
+	cwd  string
+}
+
+// name converts the path to a name accepted by fs.FS.
+func (f *ioFS) name(op, p string) (string, error) {
+	if !path.IsAbs(p) {
+		f.mu.RLock()
+		p = path.Join(f.cwd, p)
+		f.mu.RUnlock()
+	}
+	name := strings.TrimPrefix(path.Clean(p), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: p, Err: fs.ErrInvalid}
+	}
+	return name, nil
+}
+
+func readOnly(op, p string) error {
+	return &fs.PathError{Op: op, Path: p, Err: syscall.EROFS}
+}
+
+func (f *ioFS) Stdout() FileI {
+	return os.Stdout
+}
+
+func (f *ioFS) Stderr() FileI {
+	return os.Stderr
+}
+
+func (f *ioFS) Stdin() FileI {
+	return os.Stdin
+}
+
+func (f *ioFS) Getwd() (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.cwd, nil
+}
+
+func (f *ioFS) Chdir(p string) error {
+	name, err := f.name("chdir", p)
+	if err != nil {
+		return err
+	}
+	st, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return err
+	} else if !st.IsDir() {
+		return &fs.PathError{Op: "chdir", Path: p, Err: syscall.ENOTDIR}
+	}
+	f.mu.Lock()
+	f.cwd = path.Join("/", name)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *ioFS) Mkdir(p string, perm os.FileMode) error {
+	return readOnly("mkdir", p)
+}
+
+func (f *ioFS) ReadDir(p string) ([]os.DirEntry, error) {
+	name, err := f.name("readdir", p)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(f.fsys, name)
+}
+
+func (f *ioFS) Rmdir(p string) error {
+	return readOnly("rmdir", p)
+}
+
+func (f *ioFS) Unlink(p string) error {
+	return readOnly("unlink", p)
+}
+
+func (f *ioFS) Rename(from, to string) error {
+	return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EROFS}
+}
+
+func (f *ioFS) Stat(p string) (os.FileInfo, error) {
+	name, err := f.name("stat", p)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, name)
+}
+
+func (f *ioFS) Chmod(p string, mode os.FileMode) error {
+	return readOnly("chmod", p)
+}
+
+func (f *ioFS) Chown(p string, uid, gid int) error {
+	return readOnly("chown", p)
+}
+
+func (f *ioFS) Chtimes(p string, atime, mtime time.Time) error {
+	return readOnly("chtimes", p)
+}
+
+func (f *ioFS) Open(p string, flag int, mode os.FileMode) (FileI, error) {
+	if flag&accMode != os.O_RDONLY || flag&(os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, readOnly("open", p)
+	}
+	name, err := f.name("open", p)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &ioFile{File: file, name: p}, nil
+}
+
+// ioFile is an open file in io/fs.FS.
+type ioFile struct {
+	fs.File
+	name string
+}
+
+func (f *ioFile) Fd() uintptr {
+	return badFD
+}
+
+func (f *ioFile) Name() string {
+	return f.name
+}
+
+func (f *ioFile) Sync() error {
+	return nil
+}
+
+func (f *ioFile) Write(p []byte) (int, error) {
+	return 0, syscall.EBADF
+}
+
+func (f *ioFile) Seek(off int64, whence int) (int64, error) {
+	s, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, syscall.ESPIPE
+	}
+	return s.Seek(off, whence)
+}
@@ -1,6 +1,9 @@
 package stdio
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 func NewLocalFS() Filesystem {
 	return localFS{}
@@ -28,6 +31,14 @@ func (localFS) Chdir(path string) error {
 	return os.Chdir(path)
 }
 
+func (localFS) Mkdir(path string, perm os.FileMode) error {
+	return os.Mkdir(path, perm)
+}
+
+func (localFS) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
 func (localFS) Rmdir(path string) error {
 	return os.RemoveAll(path)
 }
@@ -36,10 +47,26 @@ func (localFS) Unlink(path string) error {
 	return os.Remove(path)
 }
 
+func (localFS) Rename(from, to string) error {
+	return os.Rename(from, to)
+}
+
 func (localFS) Stat(path string) (os.FileInfo, error) {
 	return os.Stat(path)
 }
 
+func (localFS) Chmod(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode)
+}
+
+func (localFS) Chown(path string, uid, gid int) error {
+	return os.Chown(path, uid, gid)
+}
+
+func (localFS) Chtimes(path string, atime, mtime time.Time) error {
+	return os.Chtimes(path, atime, mtime)
+}
+
 func (localFS) Open(path string, flag int, mode os.FileMode) (FileI, error) {
 	return os.OpenFile(path, flag, mode)
 }
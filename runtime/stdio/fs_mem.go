@@ -0,0 +1,503 @@
+package stdio
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var _ Filesystem = (*MemFS)(nil)
+
+// MemFS is a read-write filesystem that keeps all files in memory.
+//
+// Paths are slash-separated. Relative paths are resolved from the current directory, which is "/" initially.
+// Standard input is empty and the output is discarded; use WithStreams to replace them.
+type MemFS struct {
+	mu   sync.RWMutex
+	root *memNode
+	cwd  string
+
+	stdin  FileI
+	stdout FileI
+	stderr FileI
+}
+
+// NewMemFS creates an empty in-memory filesystem.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		root:   &memNode{mode: fs.ModeDir | 0755, modTime: time.Now(), kids: make(map[string]*memNode)},
+		cwd:    "/",
+		stdin:  &streamFile{name: "stdin", r: bytes.NewReader(nil)},
+		stdout: &streamFile{name: "stdout", w: io.Discard},
+		stderr: &streamFile{name: "stderr", w: io.Discard},
+	}
+}
+
+type memNode struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+	kids    map[string]*memNode // only for directories
+}
+
+func (n *memNode) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *memNode) info() fs.FileInfo {
+	return &memInfo{name: n.name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (fi *memInfo) Name() string {
+	if fi.name == "" {
+		return "/"
+	}
+	return fi.name
+}
+
+func (fi *memInfo) Size() int64        { return fi.size }
+func (fi *memInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *memInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memInfo) Sys() interface{}   { return nil }
+
+// abs returns an absolute clean path. It must be called with the lock held.
+func (m *MemFS) abs(name string) string {
+	if !path.IsAbs(name) {
+		name = path.Join(m.cwd, name)
+	}
+	return path.Clean(name)
+}
+
+// lookup finds a node by an absolute clean path. It must be called with the lock held.
+func (m *MemFS) lookup(op, name string) (*memNode, error) {
+	n := m.root
+	for _, part := range strings.Split(strings.TrimPrefix(name, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if !n.isDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+		}
+		n = n.kids[part]
+		if n == nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	return n, nil
+}
+
+// lookupParent finds a parent directory of the path. It must be called with the lock held.
+func (m *MemFS) lookupParent(op, name string) (*memNode, string, error) {
+	if name == "/" {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: syscall.EINVAL}
+	}
+	dir, base := path.Split(name)
+	d, err := m.lookup(op, path.Clean(dir))
+	if err != nil {
+		return nil, "", err
+	}
+	if !d.isDir() {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+	}
+	return d, base, nil
+}
+
+func (m *MemFS) Stdout() FileI {
+	return m.stdout
+}
+
+func (m *MemFS) Stderr() FileI {
+	return m.stderr
+}
+
+func (m *MemFS) Stdin() FileI {
+	return m.stdin
+}
+
+func (m *MemFS) Getwd() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cwd, nil
+}
+
+func (m *MemFS) Chdir(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	n, err := m.lookup("chdir", p)
+	if err != nil {
+		return err
+	}
+	if !n.isDir() {
+		return &fs.PathError{Op: "chdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	m.cwd = p
+	return nil
+}
+
+// MkdirAll creates a directory with all its parents.
+func (m *MemFS) MkdirAll(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	n := m.root
+	for _, part := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if !n.isDir() {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+		}
+		sub := n.kids[part]
+		if sub == nil {
+			sub = &memNode{name: part, mode: fs.ModeDir | perm.Perm(), modTime: time.Now(), kids: make(map[string]*memNode)}
+			n.kids[part] = sub
+		}
+		n = sub
+	}
+	if !n.isDir() {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+func (m *MemFS) Mkdir(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	d, base, err := m.lookupParent("mkdir", p)
+	if err != nil {
+		return err
+	}
+	if d.kids[base] != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	d.kids[base] = &memNode{name: base, mode: fs.ModeDir | perm.Perm(), modTime: time.Now(), kids: make(map[string]*memNode)}
+	return nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, err := m.lookup("readdir", m.abs(name))
+	if err != nil {
+		return nil, err
+	} else if !n.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	out := make([]os.DirEntry, 0, len(n.kids))
+	for _, kid := range n.kids {
+		out = append(out, fs.FileInfoToDirEntry(kid.info()))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() < out[j].Name()
+	})
+	return out, nil
+}
+
+func (m *MemFS) Rmdir(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	n, err := m.lookup("rmdir", p)
+	if err != nil {
+		return err
+	}
+	if !n.isDir() {
+		return &fs.PathError{Op: "rmdir", Path: name, Err: syscall.ENOTDIR}
+	} else if len(n.kids) != 0 {
+		return &fs.PathError{Op: "rmdir", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	d, base, err := m.lookupParent("rmdir", p)
+	if err != nil {
+		return err
+	}
+	delete(d.kids, base)
+	return nil
+}
+
+func (m *MemFS) Unlink(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	n, err := m.lookup("unlink", p)
+	if err != nil {
+		return err
+	}
+	if n.isDir() {
+		return &fs.PathError{Op: "unlink", Path: name, Err: syscall.EISDIR}
+	}
+	d, base, err := m.lookupParent("unlink", p)
+	if err != nil {
+		return err
+	}
+	// open files keep the node, as in POSIX
+	delete(d.kids, base)
+	return nil
+}
+
+func (m *MemFS) Rename(from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pfrom, pto := m.abs(from), m.abs(to)
+	n, err := m.lookup("rename", pfrom)
+	if err != nil {
+		return err
+	}
+	dfrom, bfrom, err := m.lookupParent("rename", pfrom)
+	if err != nil {
+		return err
+	}
+	dto, bto, err := m.lookupParent("rename", pto)
+	if err != nil {
+		return err
+	}
+	if pfrom == pto {
+		return nil
+	}
+	if n.isDir() && strings.HasPrefix(pto, pfrom+"/") {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EINVAL}
+	}
+	if old := dto.kids[bto]; old != nil {
+		switch {
+		case old.isDir() && !n.isDir():
+			return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EISDIR}
+		case !old.isDir() && n.isDir():
+			return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.ENOTDIR}
+		case old.isDir() && len(old.kids) != 0:
+			return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.ENOTEMPTY}
+		}
+	}
+	delete(dfrom.kids, bfrom)
+	n.name = bto
+	dto.kids[bto] = n
+	return nil
+}
+
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, err := m.lookup("stat", m.abs(name))
+	if err != nil {
+		return nil, err
+	}
+	return n.info(), nil
+}
+
+func (m *MemFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.lookup("chmod", m.abs(name))
+	if err != nil {
+		return err
+	}
+	const bits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+	n.mode = n.mode&^bits | mode&bits
+	return nil
+}
+
+// Chown only checks that the file exists, since MemFS does not keep file owners.
+func (m *MemFS) Chown(name string, uid, gid int) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, err := m.lookup("chown", m.abs(name))
+	return err
+}
+
+// Chtimes sets the modification time of the file. Access times are not tracked.
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.lookup("chtimes", m.abs(name))
+	if err != nil {
+		return err
+	}
+	n.modTime = mtime
+	return nil
+}
+
+func (m *MemFS) Open(name string, flag int, mode os.FileMode) (FileI, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.abs(name)
+	write := flag&accMode != os.O_RDONLY
+	n, err := m.lookup("open", p)
+	switch {
+	case err == nil && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case err == nil:
+		if n.isDir() && write {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+		if write && flag&os.O_TRUNC != 0 {
+			n.data = nil
+			n.modTime = time.Now()
+		}
+	case flag&os.O_CREATE != 0 && isNotExist(err):
+		d, base, err := m.lookupParent("open", p)
+		if err != nil {
+			return nil, err
+		}
+		n = &memNode{name: base, mode: mode.Perm(), modTime: time.Now()}
+		d.kids[base] = n
+	default:
+		return nil, err
+	}
+	return &memFile{fs: m, node: n, name: name, flag: flag}, nil
+}
+
+func isNotExist(err error) bool {
+	pe, ok := err.(*fs.PathError)
+	return ok && pe.Err == fs.ErrNotExist
+}
+
+// WriteFile creates or truncates the file and writes data to it.
+func (m *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := m.Open(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// ReadFile returns the content of the file.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, err := m.lookup("open", m.abs(name))
+	if err != nil {
+		return nil, err
+	} else if n.isDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: syscall.EISDIR}
+	}
+	return append([]byte{}, n.data...), nil
+}
+
+// memFile is an open file in MemFS.
+type memFile struct {
+	fs     *MemFS
+	node   *memNode
+	name   string
+	flag   int
+	off    int64
+	closed bool
+}
+
+func (f *memFile) Fd() uintptr {
+	return badFD
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.RLock()
+	defer f.fs.mu.RUnlock()
+	if f.closed {
+		return nil, fs.ErrClosed
+	}
+	return f.node.info(), nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	} else if f.flag&accMode == os.O_WRONLY {
+		return 0, syscall.EBADF
+	} else if f.node.isDir() {
+		return 0, syscall.EISDIR
+	}
+	if f.off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	} else if f.flag&accMode == os.O_RDONLY {
+		return 0, syscall.EBADF
+	}
+	nd := f.node
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(nd.data))
+	}
+	if end := f.off + int64(len(p)); end > int64(len(nd.data)) {
+		if end > int64(cap(nd.data)) {
+			data := make([]byte, end, 2*end)
+			copy(data, nd.data)
+			nd.data = data
+		} else {
+			// the data is never shrunk in place, thus the rest of the buffer is zeroed
+			nd.data = nd.data[:end]
+		}
+	}
+	n := copy(nd.data[f.off:], p)
+	f.off += int64(n)
+	nd.modTime = time.Now()
+	return n, nil
+}
+
+func (f *memFile) Seek(off int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		off += f.off
+	case io.SeekEnd:
+		off += int64(len(f.node.data))
+	default:
+		return 0, syscall.EINVAL
+	}
+	if off < 0 {
+		return 0, syscall.EINVAL
+	}
+	f.off = off
+	return off, nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,119 @@
+package stdio
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// Streams overrides standard streams of a Filesystem. Nil streams are inherited from the filesystem.
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// WithStreams wraps the filesystem and replaces its standard streams.
+//
+// It is mostly useful for testing: output of the program can be captured by setting Stdout to a bytes.Buffer.
+func WithStreams(fs Filesystem, s Streams) Filesystem {
+	if fs == nil {
+		fs = localFS{}
+	}
+	sfs := &streamsFS{Filesystem: fs}
+	if s.Stdin != nil {
+		sfs.stdin = asFileI("stdin", s.Stdin)
+	}
+	if s.Stdout != nil {
+		sfs.stdout = asFileI("stdout", s.Stdout)
+	}
+	if s.Stderr != nil {
+		sfs.stderr = asFileI("stderr", s.Stderr)
+	}
+	return sfs
+}
+
+type streamsFS struct {
+	Filesystem
+	stdin  FileI
+	stdout FileI
+	stderr FileI
+}
+
+func (fs *streamsFS) Stdout() FileI {
+	if fs.stdout != nil {
+		return fs.stdout
+	}
+	return fs.Filesystem.Stdout()
+}
+
+func (fs *streamsFS) Stderr() FileI {
+	if fs.stderr != nil {
+		return fs.stderr
+	}
+	return fs.Filesystem.Stderr()
+}
+
+func (fs *streamsFS) Stdin() FileI {
+	if fs.stdin != nil {
+		return fs.stdin
+	}
+	return fs.Filesystem.Stdin()
+}
+
+// asFileI returns the stream itself if it implements FileI, or wraps it otherwise.
+func asFileI(name string, s interface{}) FileI {
+	if f, ok := s.(FileI); ok {
+		return f
+	}
+	f := &streamFile{name: name}
+	f.r, _ = s.(io.Reader)
+	f.w, _ = s.(io.Writer)
+	return f
+}
+
+// streamFile implements FileI for a stream that cannot be seeked.
+type streamFile struct {
+	name string
+	r    io.Reader
+	w    io.Writer
+}
+
+func (f *streamFile) Fd() uintptr {
+	return badFD
+}
+
+func (f *streamFile) Name() string {
+	return f.name
+}
+
+func (f *streamFile) Sync() error {
+	return nil
+}
+
+func (f *streamFile) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, syscall.EBADF
+	}
+	return f.r.Read(p)
+}
+
+func (f *streamFile) Write(p []byte) (int, error) {
+	if f.w == nil {
+		return 0, syscall.EBADF
+	}
+	return f.w.Write(p)
+}
+
+func (f *streamFile) Seek(off int64, whence int) (int64, error) {
+	return 0, syscall.ESPIPE
+}
+
+func (f *streamFile) Stat() (os.FileInfo, error) {
+	return &memInfo{name: f.name, mode: os.ModeNamedPipe | 0600}, nil
+}
+
+// Close does nothing, since the stream is owned by the caller.
+func (f *streamFile) Close() error {
+	return nil
+}
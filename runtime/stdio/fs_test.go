@@ -0,0 +1,118 @@
+package stdio
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func setTestFS(t testing.TB, fs Filesystem) {
+	SetFS(fs)
+	t.Cleanup(func() {
+		SetFS(nil)
+	})
+}
+
+func TestMemFS(t *testing.T) {
+	mfs := NewMemFS()
+	var out bytes.Buffer
+	setTestFS(t, WithStreams(mfs, Streams{
+		Stdin:  strings.NewReader("42"),
+		Stdout: &out,
+	}))
+
+	require.NoError(t, mfs.MkdirAll("/data/sub", 0755))
+	require.NoError(t, mfs.WriteFile("/data/in.txt", []byte("hello"), 0644))
+	require.Equal(t, int32(0), Chdir(libc.CString("/data")))
+
+	f := FOpen("in.txt", "r")
+	require.NotNil(t, f)
+	var buf [16]byte
+	require.Equal(t, int32(5), f.Read(&buf[0], len(buf)))
+	require.Equal(t, "hello", string(buf[:5]))
+	require.Equal(t, int32(0), f.Read(&buf[0], len(buf)))
+	require.Equal(t, int32(1), f.IsEOF())
+	require.Equal(t, int32(0), f.Close())
+
+	f = FOpen("sub/out.txt", "w")
+	require.NotNil(t, f)
+	require.Equal(t, 5, Fprintf(f, "x=%d\n", 10))
+	require.Equal(t, int32(0), f.Seek(1, SEEK_SET))
+	require.Equal(t, int64(1), f.PutC('-'))
+	require.Equal(t, int32(0), f.Close())
+
+	f = FOpen("sub/out.txt", "a")
+	require.NotNil(t, f)
+	require.Equal(t, int32(0), f.Seek(0, SEEK_SET))
+	require.Equal(t, int64(2), f.PutS(libc.CString("ok")))
+	require.Equal(t, int32(0), f.Close())
+
+	data, err := mfs.ReadFile("/data/sub/out.txt")
+	require.NoError(t, err)
+	require.Equal(t, "x-10\nok", string(data))
+
+	var v int
+	require.Equal(t, 1, Scanf("%d", &v))
+	require.Equal(t, 42, v)
+	require.Equal(t, 5, Printf("v=%d\n", v))
+	require.Equal(t, "v=42\n", out.String())
+
+	require.Equal(t, -1, Rename("sub", "in.txt"))
+	require.Equal(t, 0, Rename("sub/out.txt", "out.txt"))
+	require.Equal(t, int32(-1), Unlink(libc.CString("sub")))
+	require.Equal(t, int32(0), Rmdir(libc.CString("sub")))
+	require.Equal(t, int32(-1), Rmdir(libc.CString("/data")))
+	require.Equal(t, 0, Remove("in.txt"))
+	require.Equal(t, 0, Remove("out.txt"))
+	require.Equal(t, int32(0), Rmdir(libc.CString("/data")))
+
+	dir := make([]byte, 16)
+	require.Equal(t, &dir[0], GetCwd(&dir[0], len(dir)))
+	require.Equal(t, "/data", libc.GoString(&dir[0]))
+	_, err = mfs.Stat("/data")
+	require.True(t, os.IsNotExist(err))
+
+	fd := Open(libc.CString("/new"), csys.O_CREAT|csys.O_EXCL|csys.O_RDWR, csys.Mode(0600))
+	require.NotEqual(t, badFD, fd)
+	require.Equal(t, badFD, Open(libc.CString("/new"), csys.O_CREAT|csys.O_EXCL|csys.O_RDWR, csys.Mode(0600)))
+	st, err := mfs.Stat("/new")
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), st.Mode())
+	require.Equal(t, int32(0), Close(fd))
+}
+
+func TestIOFS(t *testing.T) {
+	setTestFS(t, NewIOFS(fstest.MapFS{
+		"data/in.txt": {Data: []byte("embedded")},
+	}))
+
+	require.Equal(t, int32(0), Chdir(libc.CString("data")))
+	f := FOpen("in.txt", "r")
+	require.NotNil(t, f)
+	var buf [16]byte
+	require.Equal(t, int32(8), f.Read(&buf[0], len(buf)))
+	require.Equal(t, "embedded", string(buf[:8]))
+	require.Equal(t, int32(0), f.Seek(2, SEEK_SET))
+	require.Equal(t, 'b', rune(f.GetC()))
+	require.Equal(t, int32(0), f.Close())
+
+	fd := Open(libc.CString("/data/in.txt"), csys.O_RDONLY)
+	require.NotEqual(t, badFD, fd)
+	require.Equal(t, 3, Read(fd, unsafe.Pointer(&buf[0]), 3))
+	require.Equal(t, "emb", string(buf[:3]))
+	require.Equal(t, int32(0), Close(fd))
+
+	require.Nil(t, FOpen("in.txt", "w"))
+	require.Equal(t, badFD, Open(libc.CString("new.txt"), csys.O_CREAT|csys.O_WRONLY, csys.Mode(0644)))
+	require.Equal(t, -1, Remove("in.txt"))
+	require.Equal(t, -1, Rename("in.txt", "out.txt"))
+	require.Nil(t, FOpen("../../in.txt", "r"))
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"unsafe"
 
@@ -127,8 +126,7 @@ func FprintlnfGo(w io.Writer, format string, args ...interface{}) (int, error) {
 }
 
 func Printf(format string, args ...interface{}) int {
-	n, _ := FprintfGo(os.Stdout, format, args...)
-	return n
+	return Fprintf(Stdout(), format, args...)
 }
 
 func Vprintf(format string, args libc.ArgList) int {
@@ -136,7 +134,11 @@ func Vprintf(format string, args libc.ArgList) int {
 }
 
 func Dprintf(format string, args ...interface{}) int {
-	n, _ := FprintlnfGo(os.Stderr, format, args...)
+	f := Stderr()
+	if f == nil {
+		return -1
+	}
+	n, _ := FprintlnfGo(f.file, format, args...)
 	return n
 }
 
@@ -169,6 +171,9 @@ func Vsnprintf(buf *byte, sz int, format string, args libc.ArgList) int {
 }
 
 func Fprintf(file *File, format string, args ...interface{}) int {
+	if file == nil {
+		return -1
+	}
 	n, err := FprintfGo(file.file, format, args...)
 	if err != nil {
 		file.err = err
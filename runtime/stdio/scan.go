@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
 )
@@ -140,7 +139,11 @@ func (r *spaceReader) Read(p []byte) (int, error) {
 }
 
 func Scanf(format string, args ...interface{}) int {
-	n, err := FscanfGo(os.Stdin, format, args...)
+	f := Stdin()
+	if f == nil {
+		return -1
+	}
+	n, err := FscanfGo(f.file, format, args...)
 	if err != nil {
 		libc.SetErr(err)
 		return -1
@@ -0,0 +1,98 @@
+package stdio
+
+import (
+	"log"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+// statFile is implemented by files that can return information about themselves, for example by *os.File.
+type statFile interface {
+	Stat() (os.FileInfo, error)
+}
+
+func (d *fileDesc) Stat() (os.FileInfo, error) {
+	f, ok := d.file.(statFile)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	return f.Stat()
+}
+
+func Stat(path *byte, dst *csys.StatRes) int32 {
+	name := libc.GoString(path)
+	st, err := FS().Stat(name)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	dst.Fill(st)
+	return 0
+}
+
+func FStat(fd uintptr, dst *csys.StatRes) int32 {
+	d, err := defaultFS.descByFD(fd)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	st, err := d.Stat()
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	dst.Fill(st)
+	return 0
+}
+
+func Mkdir(path *byte, mode csys.Mode) int32 {
+	name := libc.GoString(path)
+	err := FS().Mkdir(name, mode.FileMode().Perm())
+	log.Printf("mkdir(%q, 0%o): %v", name, mode, err)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
+}
+
+func Chmod(path *byte, mode csys.Mode) int32 {
+	name := libc.GoString(path)
+	err := FS().Chmod(name, mode.FileMode())
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
+}
+
+// Chown changes the owner of the file. The value of ^uint32(0) (that is, -1) leaves the id unchanged.
+func Chown(path *byte, uid, gid uint32) int32 {
+	name := libc.GoString(path)
+	err := FS().Chown(name, int(int32(uid)), int(int32(gid)))
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
+}
+
+// Utime sets access and modification times of the file. If times is nil, both are set to the current time.
+func Utime(path *byte, times *csys.UTimBuf) int32 {
+	name := libc.GoString(path)
+	atime := time.Now()
+	mtime := atime
+	if times != nil {
+		atime, mtime = times.ATime.GoTime(), times.MTime.GoTime()
+	}
+	err := FS().Chtimes(name, atime, mtime)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package stdio
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/runtime/csys"
+	"github.com/gotranspile/cxgo/runtime/libc"
+)
+
+func TestStat(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	require.Equal(t, int32(0), Mkdir(libc.CString(sub), 0750))
+	require.Equal(t, int32(-1), Mkdir(libc.CString(sub), 0750))
+
+	var st csys.StatRes
+	require.Equal(t, int32(0), Stat(libc.CString(sub), &st))
+	require.Equal(t, int32(1), csys.IsDir(st.Mode))
+	require.Equal(t, int32(0), csys.IsReg(st.Mode))
+
+	name := filepath.Join(sub, "a.txt")
+	require.NoError(t, os.WriteFile(name, []byte("hello"), 0644))
+	require.Equal(t, int32(0), Chmod(libc.CString(name), 0600))
+	require.Equal(t, int32(0), Utime(libc.CString(name), &csys.UTimBuf{ATime: 1000, MTime: 2000}))
+	require.Equal(t, int32(0), Chown(libc.CString(name), ^uint32(0), ^uint32(0)))
+
+	require.Equal(t, int32(0), Stat(libc.CString(name), &st))
+	require.Equal(t, int32(1), csys.IsReg(st.Mode))
+	require.Equal(t, csys.S_IFREG|0600, st.Mode)
+	require.Equal(t, uint64(5), st.Size)
+	require.Equal(t, libc.Time(2000), st.MTim.Sec)
+	if runtime.GOOS == "linux" {
+		require.Equal(t, libc.Time(1000), st.ATim.Sec)
+		require.Equal(t, int32(1), st.Links)
+		require.Equal(t, int32(os.Getuid()), st.UID)
+		require.Equal(t, int32(os.Getgid()), st.GID)
+		require.NotZero(t, st.Inode)
+		require.NotZero(t, st.BlockSize)
+	}
+
+	fd := Open(libc.CString(name), csys.O_RDONLY)
+	require.NotEqual(t, badFD, fd)
+	var st2 csys.StatRes
+	require.Equal(t, int32(0), FStat(fd, &st2))
+	require.Equal(t, st, st2)
+	require.Equal(t, int32(0), Close(fd))
+	require.Equal(t, int32(-1), FStat(fd, &st2))
+
+	require.Equal(t, int32(0), Utime(libc.CString(name), nil))
+	require.Equal(t, int32(0), Stat(libc.CString(name), &st))
+	require.WithinDuration(t, time.Now(), st.MTim.Sec.GoTime(), time.Minute)
+
+	require.Equal(t, int32(-1), Stat(libc.CString(filepath.Join(dir, "none")), &st))
+	require.Equal(t, 2, libc.Errno)
+}
+
+func TestModeConv(t *testing.T) {
+	for _, m := range []os.FileMode{
+		0644,
+		os.ModeDir | 0755,
+		os.ModeSymlink | 0777,
+		os.ModeNamedPipe | 0600,
+		os.ModeDevice | os.ModeCharDevice | 0620,
+		os.ModeSetuid | os.ModeSticky | 0755,
+	} {
+		require.Equal(t, m, csys.ModeOf(m).FileMode(), "%v", m)
+	}
+}
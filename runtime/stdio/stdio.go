@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"syscall"
 	"unsafe"
 
 	"github.com/gotranspile/cxgo/runtime/libc"
@@ -22,23 +23,39 @@ const (
 )
 
 func Stdout() *File {
-	return defaultFS.OpenFrom(defaultFS.fs.Stdout())
+	return ByFD(1)
 }
 
 func Stderr() *File {
-	return defaultFS.OpenFrom(defaultFS.fs.Stderr())
+	return ByFD(2)
 }
 
 func Stdin() *File {
-	return defaultFS.OpenFrom(defaultFS.fs.Stdin())
+	return ByFD(0)
 }
 
 func Remove(path string, _ ...interface{}) int {
-	panic("TODO")
+	fs := FS()
+	st, err := fs.Stat(path)
+	if err == nil && st.IsDir() {
+		err = fs.Rmdir(path)
+	} else if err == nil {
+		err = fs.Unlink(path)
+	}
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
 }
 
 func Rename(path1, path2 string, _ ...interface{}) int {
-	panic("TODO")
+	err := FS().Rename(path1, path2)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
 }
 
 func openFlags(mode string) int {
@@ -69,26 +86,46 @@ func OpenFrom(f FileI) *File {
 	return defaultFS.OpenFrom(f)
 }
 
+// OpenFrom allocates a new file descriptor for the file and opens a FILE stream for it.
 func (fs *filesystem) OpenFrom(f FileI) *File {
+	ff, err := fs.openStream(newFileDesc(f, os.O_RDWR))
+	if err != nil {
+		libc.SetErr(err)
+		return nil
+	}
+	return ff
+}
+
+func (fs *filesystem) openStream(d *fileDesc) (*File, error) {
+	fs.Lock()
+	defer fs.Unlock()
+	fd, err := fs.allocFD(d, 0)
+	if err != nil {
+		return nil, err
+	}
 	ff := &File{
 		fs:   fs,
-		file: f,
-		fd:   f.Fd(),
+		file: d,
+		fd:   fd,
 	}
-	fs.Lock()
-	fs.byFD[ff.fd] = ff
-	fs.Unlock()
-	return ff
+	fs.byFD[fd] = ff
+	return ff, nil
 }
 
 func (fs *filesystem) Open(path string, flag int) *File {
-	f, err := fs.fs.Open(path, flag, defPermFile)
+	d, err := fs.openDesc(path, flag, defPermFile)
 	log.Printf("fopen(%q, %v): %v", path, flag, err)
 	if err != nil {
 		libc.SetErr(err)
 		return nil
 	}
-	return fs.OpenFrom(f)
+	ff, err := fs.openStream(d)
+	if err != nil {
+		_ = d.Close()
+		libc.SetErr(err)
+		return nil
+	}
+	return ff
 }
 
 func (fs *filesystem) OpenS(path, mode string) *File {
@@ -96,30 +133,92 @@ func (fs *filesystem) OpenS(path, mode string) *File {
 	return fs.Open(path, flags)
 }
 
+// FDOpen opens a FILE stream for the file descriptor. The stream shares the file offset and status flags
+// with the descriptor, and closing the stream closes the descriptor.
+//
+// The mode must be allowed by the access mode of the descriptor. It never truncates the file.
 func FDOpen(fd uintptr, mode string) *File {
-	f := ByFD(fd)
-	log.Printf("fdopen(%d, %q): %v", fd, mode, f)
-	if f == nil {
+	f, err := defaultFS.fdOpen(fd, mode)
+	if err != nil {
+		log.Printf("fdopen(%d, %q): %v", fd, mode, err)
+		libc.SetErr(err)
 		return nil
 	}
-	flags := openFlags(mode)
-	_ = flags // FIXME: use flags
 	return f
 }
 
 func FDOpenS(fd uintptr, mode string) *File {
-	f := ByFD(fd)
-	log.Printf("fdopen(%d, %q): %v", fd, mode, f)
+	return FDOpen(fd, mode)
+}
+
+func (fs *filesystem) fdOpen(fd uintptr, mode string) (*File, error) {
+	d, err := fs.descByFD(fd)
+	if err != nil {
+		return nil, err
+	}
+	flags := openFlags(mode)
+	if acc := d.getFlags() & accMode; acc != os.O_RDWR && acc != flags&accMode {
+		return nil, syscall.EINVAL
+	}
+	if flags&os.O_APPEND != 0 {
+		d.setFlags(d.getFlags() | os.O_APPEND)
+	}
+	return fs.fileByFD(fd)
+}
+
+// FReOpen opens a file and associates the stream with it. The file descriptor of the stream is preserved,
+// thus it can be used to redirect standard streams. An empty path reopens the same file with a different mode.
+func FReOpen(path, mode string, f *File) *File {
 	if f == nil {
+		libc.SetErr(syscall.EBADF)
+		return nil
+	}
+	if _, ok := f.file.(closedFile); ok {
+		libc.SetErr(syscall.EBADF)
+		return nil
+	}
+	if path == "" {
+		path = f.file.Name()
+	}
+	err := f.fs.reopen(f, path, openFlags(mode))
+	log.Printf("freopen(%q, %q): %v", path, mode, err)
+	if err != nil {
+		libc.SetErr(err)
 		return nil
 	}
-	flags := openFlags(mode)
-	_ = flags // FIXME: use flags
 	return f
 }
 
-func FReOpen(path, mode string, f *File) *File {
-	panic("TODO")
+func (fs *filesystem) reopen(f *File, path string, flags int) error {
+	// pending writes must reach the old file before the new one is opened, since it may be the same file
+	_ = f.file.Sync()
+	d, err := fs.openDesc(path, flags, defPermFile)
+	if err != nil {
+		return err
+	}
+	// the descriptor is replaced under one lock, thus it cannot be reused by other files
+	fs.Lock()
+	if fs.byFD[f.fd] != f {
+		fs.Unlock()
+		_ = d.Close()
+		return syscall.EBADF
+	}
+	old, err := fs.releaseFD(f.fd)
+	if err != nil {
+		fs.Unlock()
+		_ = d.Close()
+		return err
+	}
+	fs.setFD(f.fd, d)
+	f.file = d
+	f.err = nil
+	f.c = nil
+	fs.byFD[f.fd] = f
+	fs.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+	return nil
 }
 
 func Fscanf(file *File, format string, args ...interface{}) int {
@@ -144,6 +243,9 @@ type File struct {
 }
 
 func (f *File) File() FileI {
+	if d, ok := f.file.(*fileDesc); ok {
+		return d.file
+	}
 	return f.file
 }
 
@@ -182,13 +284,17 @@ func (f *File) Close() int32 {
 	if f == nil {
 		return -1
 	}
-	if err := f.file.Close(); err != nil {
+	if _, ok := f.file.(closedFile); ok {
+		f.err = syscall.EBADF
+		libc.SetErr(syscall.EBADF)
+		return EOF
+	}
+	if err := f.fs.closeFD(f.fd); err != nil {
 		f.err = err
+		libc.SetErr(err)
+		return EOF
 	}
-	f.fs.Lock()
-	delete(f.fs.byFD, f.fd)
-	f.fs.Unlock()
-	return 0 // TODO
+	return 0
 }
 
 func (f *File) WriteN(p *byte, size, cnt int) int32 {
@@ -1,9 +1,11 @@
 package stdio
 
 import (
+	"io"
 	"log"
 	"math"
 	"os"
+	"syscall"
 	"unsafe"
 
 	"github.com/gotranspile/cxgo/runtime/csys"
@@ -11,28 +13,177 @@ import (
 )
 
 func Create(path *byte, mode csys.Mode) uintptr {
-	panic("TODO")
+	return Open(path, csys.O_CREAT|csys.O_WRONLY|csys.O_TRUNC, mode)
 }
 
+// Open opens a file and returns the lowest free file descriptor. The mode must be passed if O_CREAT is set.
 func Open(path *byte, flags int32, ctls ...interface{}) uintptr {
 	spath := libc.GoString(path)
-	if len(ctls) != 0 {
-		log.Printf("FIXME: open(%q, %x): ignoring controls: %#v", spath, flags, ctls)
+	mode := os.FileMode(defPermFile)
+	if flags&csys.O_CREAT != 0 {
+		if m, ok := ctlInt(ctls); ok {
+			mode = csys.Mode(m).FileMode().Perm()
+		}
 	}
-	if flags != 0 {
-		log.Printf("FIXME: open(%q, %x): ignoring flags: 0x%x", spath, flags, flags)
+	fd, err := defaultFS.openFD(spath, int(flags), mode)
+	log.Printf("open(%q, %x, 0%o): %v", spath, flags, mode, err)
+	if err != nil {
+		libc.SetErr(err)
+		return badFD
+	}
+	return fd
+}
+
+// FDControl implements fcntl. Supported commands are F_DUPFD, F_GETFD, F_SETFD, F_GETFL and F_SETFL.
+func FDControl(fd uintptr, cmd int32, ctls ...interface{}) int32 {
+	arg, _ := ctlInt(ctls)
+	switch cmd {
+	case csys.F_DUPFD:
+		if arg < 0 {
+			libc.SetErr(syscall.EINVAL)
+			return -1
+		}
+		fd2, err := defaultFS.dupFD(fd, uintptr(arg))
+		if err != nil {
+			libc.SetErr(err)
+			return -1
+		}
+		return int32(fd2)
+	case csys.F_GETFD, csys.F_SETFD:
+		fs := defaultFS
+		fs.Lock()
+		defer fs.Unlock()
+		e := fs.entry(fd)
+		if e == nil {
+			libc.SetErr(syscall.EBADF)
+			return -1
+		}
+		if cmd == csys.F_SETFD {
+			e.cloexec = int32(arg)&csys.FD_CLOEXEC != 0
+			return 0
+		}
+		if e.cloexec {
+			return csys.FD_CLOEXEC
+		}
+		return 0
+	case csys.F_GETFL, csys.F_SETFL:
+		d, err := defaultFS.descByFD(fd)
+		if err != nil {
+			libc.SetErr(err)
+			return -1
+		}
+		if cmd == csys.F_SETFL {
+			d.setFlags(int(arg))
+			return 0
+		}
+		return int32(d.getFlags())
+	default:
+		libc.SetErr(syscall.EINVAL)
+		return -1
+	}
+}
+
+// Read reads up to sz bytes from the file descriptor. It returns 0 at the end of file.
+func Read(fd uintptr, p unsafe.Pointer, sz int) int {
+	d, err := defaultFS.descByFD(fd)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	} else if sz == 0 {
+		return 0
+	}
+	n, err := d.Read(unsafe.Slice((*byte)(p), sz))
+	if err == io.EOF {
+		return n
+	} else if err != nil && n == 0 {
+		libc.SetErr(err)
+		return -1
 	}
-	// FIXME: handle flags
-	f := defaultFS.Open(spath, os.O_RDONLY)
-	log.Printf("open(%q, %x): %v", spath, flags, f)
-	if f == nil {
+	return n
+}
+
+// Write writes sz bytes to the file descriptor.
+func Write(fd uintptr, p unsafe.Pointer, sz int) int {
+	d, err := defaultFS.descByFD(fd)
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	} else if sz == 0 {
 		return 0
 	}
-	return f.FileNo()
+	n, err := d.Write(unsafe.Slice((*byte)(p), sz))
+	if err != nil && n == 0 {
+		libc.SetErr(err)
+		return -1
+	}
+	return n
+}
+
+// Close closes the file descriptor, as well as the FILE stream opened for it.
+func Close(fd uintptr) int32 {
+	if err := defaultFS.closeFD(fd); err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	return 0
+}
+
+// Dup duplicates the file descriptor to the lowest free one.
+func Dup(fd uintptr) uintptr {
+	fd2, err := defaultFS.dupFD(fd, 0)
+	if err != nil {
+		libc.SetErr(err)
+		return badFD
+	}
+	return fd2
 }
 
-func FDControl(fd uintptr, flags int32, ctls ...interface{}) int32 {
-	panic("TODO")
+// Dup2 duplicates the file descriptor to fd2, closing it first if necessary.
+// It fails with EBADF if fd2 is negative or not less than OPEN_MAX.
+func Dup2(fd, fd2 uintptr) uintptr {
+	fd2, err := defaultFS.dup2FD(fd, fd2)
+	if err != nil {
+		libc.SetErr(err)
+		return badFD
+	}
+	return fd2
+}
+
+// GetDTableSize returns the size of the file descriptor table.
+func GetDTableSize() int32 {
+	return OPEN_MAX
+}
+
+// Pipe creates a pipe and stores file descriptors for the read and write ends to fds[0] and fds[1].
+func Pipe(fds *int32) int32 {
+	r, w, err := os.Pipe()
+	if err != nil {
+		libc.SetErr(err)
+		return -1
+	}
+	fs := defaultFS
+	fs.Lock()
+	rfd, err := fs.allocFD(newFileDesc(r, os.O_RDONLY), 0)
+	if err != nil {
+		fs.Unlock()
+		r.Close()
+		w.Close()
+		libc.SetErr(err)
+		return -1
+	}
+	wfd, err := fs.allocFD(newFileDesc(w, os.O_WRONLY), 0)
+	if err != nil {
+		fs.fds[rfd] = nil
+		fs.Unlock()
+		r.Close()
+		w.Close()
+		libc.SetErr(err)
+		return -1
+	}
+	fs.Unlock()
+	dst := unsafe.Slice(fds, 2)
+	dst[0], dst[1] = int32(rfd), int32(wfd)
+	return 0
 }
 
 func Chdir(path *byte) int32 {
@@ -81,12 +232,14 @@ func Access(path *byte, flags int32) int32 {
 }
 
 func Lseek(fd uintptr, offs uint64, whence int32) uint64 {
-	f := ByFD(fd)
-	if f == nil {
+	d, err := defaultFS.descByFD(fd)
+	if err != nil {
+		libc.SetErr(err)
 		return math.MaxUint64
 	}
-	off := f.Seek(int64(offs), whence)
-	if off < 0 {
+	off, err := d.Seek(int64(offs), int(whence))
+	if err != nil {
+		libc.SetErr(err)
 		return math.MaxUint64
 	}
 	return uint64(off)
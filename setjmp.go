@@ -0,0 +1,211 @@
+package cxgo
+
+import (
+	"fmt"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+// setjmp support
+//
+// Go has no way to return to a previous stack frame, so setjmp is translated structurally.
+// A setjmp call must be a part of a top-level statement of a function body (if, switch, assignment, etc).
+// That statement and the rest of the function is moved to a closure that accepts the setjmp result:
+//
+//	if (setjmp(buf)) { return -1; }
+//	work();
+//	return 0;
+//
+// becomes:
+//
+//	return libc.SetJumpRet(&buf, func(_jmp int) int32 {
+//		if _jmp != 0 { return -1 }
+//		work()
+//		return 0
+//	})
+//
+// LongJump unwinds the stack with a panic which is recovered by libc.SetJumpRet, and the closure is called again.
+
+// isSetJumpCall checks if the function ident is a setjmp function from the library.
+func (g *translator) isSetJumpCall(id *types.Ident) bool {
+	if id.Name != libs.SetJumpFunc {
+		return false
+	}
+	sid, ok := g.env.IdentByName(libs.SetJumpFunc)
+	return ok && sid == id
+}
+
+func (g *translator) NewSetJumpExpr(buf Expr) *SetJumpExpr {
+	return &SetJumpExpr{
+		Buf: buf,
+		Val: types.NewIdent("_jmp", g.env.Go().Int()),
+	}
+}
+
+var _ Expr = (*SetJumpExpr)(nil)
+
+// SetJumpExpr is a setjmp call. It evaluates to the value passed to the closure generated by rewriteSetJumps.
+type SetJumpExpr struct {
+	Buf Expr
+	Val *types.Ident
+}
+
+func (e *SetJumpExpr) Visit(v Visitor) {
+	v(e.Buf)
+}
+
+func (e *SetJumpExpr) CType(types.Type) types.Type {
+	return e.Val.CType(nil)
+}
+
+func (e *SetJumpExpr) AsExpr() GoExpr {
+	return e.Val.GoIdent()
+}
+
+func (e *SetJumpExpr) IsConst() bool {
+	return false
+}
+
+func (e *SetJumpExpr) HasSideEffects() bool {
+	return true
+}
+
+func (e *SetJumpExpr) Uses() []types.Usage {
+	return []types.Usage{{Ident: e.Val, Access: types.AccessRead}}
+}
+
+// findSetJumps returns all setjmp calls in the node.
+func findSetJumps(n Node) []*SetJumpExpr {
+	var (
+		out   []*SetJumpExpr
+		visit Visitor
+	)
+	visit = func(n Node) {
+		switch n := n.(type) {
+		case nil:
+			return
+		case *SetJumpExpr:
+			out = append(out, n)
+		}
+		n.Visit(visit)
+	}
+	visit(n)
+	return out
+}
+
+// findLabels returns all labels and goto targets in the node.
+func findLabels(n Node, labels, gotos map[string]struct{}) {
+	var visit Visitor
+	visit = func(n Node) {
+		switch n := n.(type) {
+		case nil:
+			return
+		case *CLabelStmt:
+			labels[n.Label] = struct{}{}
+		case *CGotoStmt:
+			gotos[n.Label] = struct{}{}
+		}
+		n.Visit(visit)
+	}
+	visit(n)
+}
+
+// setJumpHead returns a setjmp call in the statement header (condition, value, etc).
+// It returns an error if the call is nested in statement's body, or there are multiple calls.
+func setJumpHead(st CStmt) (*SetJumpExpr, error) {
+	all := findSetJumps(st)
+	if len(all) == 0 {
+		return nil, nil
+	} else if len(all) > 1 {
+		return nil, fmt.Errorf("multiple setjmp calls in one statement are not supported")
+	}
+	var head []*SetJumpExpr
+	switch st := st.(type) {
+	case *CIfStmt:
+		head = findSetJumps(st.Cond)
+	case *CSwitchStmt:
+		head = findSetJumps(st.Cond)
+	case *CExprStmt, *CDeclStmt, *CAssignStmt, *CReturnStmt:
+		head = all
+	}
+	if len(head) == 0 {
+		return nil, fmt.Errorf("setjmp is only supported in statements at the top level of the function body")
+	}
+	return head[0], nil
+}
+
+// rewriteSetJumps moves statements following setjmp calls to libc.SetJump closures.
+func (g *translator) rewriteSetJumps(decl []CDecl) {
+	for _, d := range decl {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		stmts, err := g.rewriteSetJumpStmts(f.Type.Return(), f.Body.Stmts)
+		if err != nil {
+			g.addError(fmt.Errorf("%s: %w", f.Name.Name, err))
+			continue
+		}
+		f.Body.Stmts = stmts
+	}
+}
+
+func (g *translator) rewriteSetJumpStmts(ret types.Type, stmts []CStmt) ([]CStmt, error) {
+	for i, st := range stmts {
+		sj, err := setJumpHead(st)
+		if err != nil {
+			return nil, err
+		} else if sj == nil {
+			continue
+		}
+		tail, err := g.rewriteSetJumpStmts(ret, stmts[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		body := append([]CStmt{st}, tail...)
+		if err := checkSetJumpGotos(stmts[:i], body); err != nil {
+			return nil, err
+		}
+		out := append([]CStmt{}, stmts[:i]...)
+		lit := g.NewFuncLit(g.env.FuncT(ret, &types.Field{Name: sj.Val}), body...)
+		if ret == nil {
+			fnc := types.NewIdentGo("_cxgo_setjmp_void", "libc.SetJump", g.env.FuncTT(nil, sj.Buf.CType(nil), lit.Type))
+			out = append(out, NewCExprStmt1(&CallExpr{
+				Fun:  FuncIdent{fnc},
+				Args: []Expr{sj.Buf, lit},
+			}))
+		} else {
+			fnc := types.NewIdentGo("_cxgo_setjmp_ret", "libc.SetJumpRet", g.env.FuncTT(ret, sj.Buf.CType(nil), lit.Type))
+			out = append(out, &CReturnStmt{Expr: &CallExpr{
+				Fun:  FuncIdent{fnc},
+				Args: []Expr{sj.Buf, lit},
+			}})
+		}
+		return out, nil
+	}
+	return stmts, nil
+}
+
+// checkSetJumpGotos checks that no goto crosses the boundary of setjmp closure.
+func checkSetJumpGotos(pre, body []CStmt) error {
+	preLabels, preGotos := make(map[string]struct{}), make(map[string]struct{})
+	bodyLabels, bodyGotos := make(map[string]struct{}), make(map[string]struct{})
+	for _, st := range pre {
+		findLabels(st, preLabels, preGotos)
+	}
+	for _, st := range body {
+		findLabels(st, bodyLabels, bodyGotos)
+	}
+	for l := range preGotos {
+		if _, ok := bodyLabels[l]; ok {
+			return fmt.Errorf("goto %s jumps over setjmp call", l)
+		}
+	}
+	for l := range bodyGotos {
+		if _, ok := preLabels[l]; ok {
+			return fmt.Errorf("goto %s jumps back over setjmp call", l)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package cxgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+var casesTranslateSetJump = []parseCase{
+	{
+		name: "setjmp if",
+		src: `
+#include <setjmp.h>
+
+jmp_buf env;
+
+int foo(int a) {
+	a++;
+	if (setjmp(env)) {
+		return -1;
+	}
+	if (a > 2) {
+		longjmp(env, 1);
+	}
+	return a;
+}
+`,
+		exp: `
+var env libc.JumpBuf
+
+func foo(a int32) int32 {
+	a++
+	return libc.SetJumpRet(&env, func(_jmp int) int32 {
+		if _jmp != 0 {
+			return -1
+		}
+		if a > 2 {
+			env.LongJump(1)
+		}
+		return a
+	})
+}
+`,
+	},
+	{
+		name: "setjmp void",
+		src: `
+#include <setjmp.h>
+
+void foo(jmp_buf* env) {
+	int r = setjmp(*env);
+	if (r == 0) {
+		longjmp(*env, 2);
+	}
+}
+`,
+		exp: `
+func foo(env *libc.JumpBuf) {
+	libc.SetJump(env, func(_jmp int) {
+		var r int32 = int32(_jmp)
+		if r == 0 {
+			(*env).LongJump(2)
+		}
+	})
+}
+`,
+	},
+}
+
+func TestTranslateSetJump(t *testing.T) {
+	runTestTranslate(t, casesTranslateSetJump)
+}
+
+var casesSetJumpErrors = []struct {
+	name string
+	src  string
+	err  string
+}{
+	{
+		name: "nested",
+		src: `
+#include <setjmp.h>
+
+jmp_buf env;
+
+void foo(int a) {
+	while (a) {
+		if (setjmp(env)) {
+			return;
+		}
+	}
+}
+`,
+		err: "foo: setjmp is only supported in statements at the top level of the function body",
+	},
+	{
+		name: "multiple",
+		src: `
+#include <setjmp.h>
+
+jmp_buf env;
+
+int foo() {
+	return setjmp(env) + setjmp(env);
+}
+`,
+		err: "foo: multiple setjmp calls in one statement are not supported",
+	},
+	{
+		name: "goto",
+		src: `
+#include <setjmp.h>
+
+jmp_buf env;
+
+void foo(int a) {
+again:
+	if (setjmp(env)) {
+		goto again;
+	}
+}
+`,
+		err: "foo: goto again jumps back over setjmp call",
+	},
+}
+
+func TestSetJumpErrors(t *testing.T) {
+	for _, c := range casesSetJumpErrors {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			env := libs.NewEnv(types.Config32())
+			ast, err := ParseSource(env, ParseConfig{
+				Sources: []cc.Source{{Name: "main.c", Value: c.src}},
+			})
+			require.NoError(t, err)
+			_, err = TranslateAST("main.c", ast, env, Config{})
+			require.EqualError(t, err, c.err)
+		})
+	}
+}
+
+const runSetJumpSrc = `
+#include <stdio.h>
+#include <setjmp.h>
+
+static jmp_buf env;
+static int depth = 0;
+
+void fail(int code) {
+	longjmp(env, code);
+}
+
+int parse(int x) {
+	if (setjmp(env) != 0) {
+		return -1;
+	}
+	if (x > 2) fail(3);
+	return x;
+}
+
+int retry() {
+	int tries = 0;
+	switch (setjmp(env)) {
+	case 0:
+		break;
+	default:
+		tries++;
+		break;
+	}
+	if (tries < 3) {
+		longjmp(env, 0);
+	}
+	return tries;
+}
+
+int main() {
+	printf("%d %d %d\n", parse(1), parse(5), retry());
+	return 0;
+}
+`
+
+func TestRunSetJump(t *testing.T) {
+	testTranspileOut(t, runSetJumpSrc)
+}
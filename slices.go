@@ -0,0 +1,863 @@
+package cxgo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Slices infers which pointers should become Go slices.
+//
+// A pointer is considered a slice if it's indexed, dereferenced with an offset, or allocated as an array
+// (malloc(n * sizeof(T)), calloc(n, sizeof(T)) or realloc(p, n * sizeof(T))). Slice-ness is propagated
+// through assignments, function arguments, return values and struct fields: all pointers connected this way
+// become slices together.
+//
+// If any pointer in such group is used in a way that slices cannot express (pointer arithmetic, increments,
+// comparisons, taking an address, etc), the whole group is kept as pointers and each offending site is reported
+// in Fallbacks. Pointer arithmetic also implies an array, thus pointers used only this way are reported as well.
+//
+// All C files must be indexed with IndexFile before any of them is translated.
+type Slices struct {
+	slots    map[string]*sliceSlot
+	funcs    map[string]*sliceFunc
+	flows    []sliceFlow
+	seeds    []sliceSite
+	blocks   []sliceSite
+	inits    map[string][]token.Position // struct names initialized with initializer lists
+	resolved bool
+
+	globals   map[string]bool
+	locals    map[token.Position]bool
+	fields    map[string]map[string]bool // struct name -> field names
+	args      map[string]map[int]bool    // function name -> argument indexes
+	rets      map[string]bool
+	fallbacks []SliceFallback
+}
+
+// SliceFallback describes a site that prevented a pointer from being converted to a slice.
+type SliceFallback struct {
+	Name   string         // C name of the pointer that was expected to become a slice
+	Where  token.Position // position of the offending expression
+	Reason string
+}
+
+func (f SliceFallback) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Where, f.Name, f.Reason)
+}
+
+type sliceSlotKind int
+
+const (
+	sliceGlobal = sliceSlotKind(iota)
+	sliceLocal
+	sliceArg
+	sliceRet
+	sliceField
+)
+
+// sliceSlot is a pointer variable, argument, return value or a struct field.
+type sliceSlot struct {
+	kind   sliceSlotKind
+	name   string         // C name, used in reports
+	owner  string         // function or struct name
+	index  int            // argument index
+	pos    token.Position // position of the local variable or argument declaration
+	parent *sliceSlot
+}
+
+func (s *sliceSlot) root() *sliceSlot {
+	for s.parent != nil {
+		if s.parent.parent != nil {
+			s.parent = s.parent.parent
+		}
+		s = s.parent
+	}
+	return s
+}
+
+type sliceFunc struct {
+	args int
+	addr []token.Position // sites where the function is used as a value
+}
+
+type sliceValKind int
+
+const (
+	sliceValOther = sliceValKind(iota)
+	sliceValSlot
+	sliceValNull
+	sliceValArray
+)
+
+// sliceVal is a pointer value assigned to a slot.
+type sliceVal struct {
+	kind   sliceValKind
+	key    string // for sliceValSlot
+	reason string // for sliceValOther
+}
+
+type sliceFlow struct {
+	dst   string
+	val   sliceVal
+	where token.Position
+}
+
+type sliceSite struct {
+	key    string
+	where  token.Position
+	reason string
+}
+
+// NewSlices creates an empty slice inference index.
+func NewSlices() *Slices {
+	return &Slices{
+		slots: make(map[string]*sliceSlot),
+		funcs: make(map[string]*sliceFunc),
+		inits: make(map[string][]token.Position),
+	}
+}
+
+// Index records how pointers are declared, assigned and used in a translation unit.
+func (s *Slices) Index(tu *cc.AST) {
+	s.resolved = false
+	v := &sliceVisitor{s: s, allowed: make(map[cc.Node]bool)}
+	cc.Inspect(tu.TranslationUnit, v.visit)
+}
+
+type sliceVisitor struct {
+	s       *Slices
+	fnc     string           // current function
+	allowed map[cc.Node]bool // expressions that are already handled by a parent node
+}
+
+func isLibSource(pos token.Position) bool {
+	return isCxgoSource(pos.Filename) || strings.HasPrefix(pos.Filename, libs.IncludePath)
+}
+
+// slicePtr checks if a pointer of a given type can be converted to a slice.
+func slicePtr(t cc.Type) bool {
+	if t == nil || t.Kind() != cc.Ptr {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case cc.Void, cc.Function, cc.Invalid:
+		return false
+	}
+	return true
+}
+
+func (v *sliceVisitor) visit(n cc.Node, enter bool) bool {
+	if fd, ok := n.(*cc.FunctionDefinition); ok {
+		if !enter {
+			v.fnc = ""
+			return true
+		}
+		return v.funcDef(fd)
+	}
+	if !enter {
+		return true
+	}
+	switch n := n.(type) {
+	case *cc.InitDeclarator:
+		if n.Case == cc.InitDeclaratorInit && n.Initializer.Case == cc.InitializerExpr {
+			if key := v.declKey(n.Declarator); key != "" {
+				v.flow(key, n.Initializer.AssignmentExpression, n.Initializer.Position())
+			}
+		}
+	case *cc.Initializer:
+		v.initializer(n)
+	case *cc.JumpStatement:
+		if n.Case == cc.JumpStatementReturn && n.Expression != nil && v.fnc != "" {
+			v.flow(retKey(v.fnc), n.Expression, n.Position())
+		}
+	case *cc.AssignmentExpression:
+		v.assign(n)
+	case *cc.PostfixExpression:
+		v.postfix(n)
+	case *cc.UnaryExpression:
+		v.unary(n)
+	case *cc.CastExpression:
+		if n.Case == cc.CastExpressionCast {
+			if key := v.slotOf(n.CastExpression); key != "" && !v.allowed[n] && !sameElemCast(n.TypeName.Type(), n.CastExpression.Operand.Type()) {
+				v.block(key, n.Position(), "cast to "+n.TypeName.Type().String())
+			}
+		}
+	case *cc.AdditiveExpression:
+		v.additive(n)
+	case *cc.RelationalExpression:
+		if n.Case != cc.RelationalExpressionShift {
+			v.blockSlot(n.RelationalExpression, n.Position(), "pointer comparison")
+			v.blockSlot(n.ShiftExpression, n.Position(), "pointer comparison")
+		}
+	case *cc.EqualityExpression:
+		if n.Case != cc.EqualityExpressionRel && !isNullExpr(n.EqualityExpression) && !isNullExpr(n.RelationalExpression) {
+			v.blockSlot(n.EqualityExpression, n.Position(), "pointer comparison")
+			v.blockSlot(n.RelationalExpression, n.Position(), "pointer comparison")
+		}
+	case *cc.PrimaryExpression:
+		if n.Case == cc.PrimaryExpressionIdent && !v.allowed[n] {
+			if d := n.Declarator(); d != nil && d.Type().Kind() == cc.Function && !isLibSource(d.Position()) {
+				f := v.s.function(d.Name().String())
+				f.addr = append(f.addr, n.Position())
+			}
+		}
+	}
+	return true
+}
+
+func (v *sliceVisitor) funcDef(fd *cc.FunctionDefinition) bool {
+	d := fd.Declarator
+	if isLibSource(d.Position()) {
+		return false
+	}
+	name := d.Name().String()
+	if strings.HasPrefix(name, macroFuncPrefix) {
+		return false
+	}
+	v.fnc = name
+	f := v.s.function(name)
+	ft := d.Type()
+	f.args = len(ft.Parameters())
+	for i, p := range ft.Parameters() {
+		if slicePtr(p.Type()) {
+			sl := &sliceSlot{kind: sliceArg, name: p.Name().String(), owner: name, index: i}
+			if pd := p.Declarator(); pd != nil {
+				sl.pos = pd.Position()
+			}
+			v.s.slot(argKey(name, i), sl)
+		}
+	}
+	if slicePtr(ft.Result()) {
+		v.s.slot(retKey(name), &sliceSlot{kind: sliceRet, name: name, owner: name})
+	}
+	return true
+}
+
+func (v *sliceVisitor) initializer(n *cc.Initializer) {
+	switch n.Case {
+	case cc.InitializerInitList:
+		if t := n.Type(); t != nil && t.Kind() == cc.Struct {
+			if name := sliceStructName(t); name != "" {
+				v.s.inits[name] = append(v.s.inits[name], n.Position())
+			}
+		}
+	case cc.InitializerExpr:
+		if n.Parent() != nil {
+			v.blockSlot(n.AssignmentExpression, n.Position(), "used in an initializer list")
+		}
+	}
+}
+
+func (v *sliceVisitor) assign(n *cc.AssignmentExpression) {
+	switch n.Case {
+	case cc.AssignmentExpressionAssign:
+		if key := v.slotOf(n.UnaryExpression); key != "" {
+			v.flow(key, n.AssignmentExpression, n.Position())
+		}
+	case cc.AssignmentExpressionAdd, cc.AssignmentExpressionSub:
+		v.arithSlot(n.UnaryExpression, n.Position(), "pointer arithmetic")
+	}
+}
+
+func (v *sliceVisitor) postfix(n *cc.PostfixExpression) {
+	switch n.Case {
+	case cc.PostfixExpressionIndex:
+		if key := v.slotOf(n.PostfixExpression); key != "" && !isZeroExpr(n.Expression) {
+			v.seed(key, n.Position(), "indexed")
+		}
+	case cc.PostfixExpressionCall:
+		callee, ok := unwrapSliceExpr(n.PostfixExpression).(*cc.PrimaryExpression)
+		if !ok || callee.Case != cc.PrimaryExpressionIdent {
+			return
+		}
+		v.allowed[callee] = true
+		d := callee.Declarator()
+		if d == nil || d.Type().Kind() != cc.Function {
+			return
+		}
+		name := d.Name().String()
+		i := 0
+		for list := n.ArgumentExpressionList; list != nil; list = list.ArgumentExpressionList {
+			v.flow(argKey(name, i), list.AssignmentExpression, list.AssignmentExpression.Position())
+			i++
+		}
+	case cc.PostfixExpressionInc, cc.PostfixExpressionDec:
+		v.arithSlot(n.PostfixExpression, n.Position(), "pointer increment")
+	}
+}
+
+func (v *sliceVisitor) unary(n *cc.UnaryExpression) {
+	switch n.Case {
+	case cc.UnaryExpressionInc, cc.UnaryExpressionDec:
+		v.arithSlot(n.UnaryExpression, n.Position(), "pointer increment")
+	case cc.UnaryExpressionAddrof:
+		v.blockSlot(n.CastExpression, n.Position(), "address taken")
+	case cc.UnaryExpressionSizeofExpr:
+		v.blockSlot(n.UnaryExpression, n.Position(), "sizeof of a pointer")
+	case cc.UnaryExpressionDeref:
+		// *(p + i) is the same as p[i]
+		add, ok := unwrapSliceExpr(n.CastExpression).(*cc.AdditiveExpression)
+		if !ok || add.Case != cc.AdditiveExpressionAdd && add.Case != cc.AdditiveExpressionSub {
+			return
+		}
+		key := v.slotOf(add.AdditiveExpression)
+		if key == "" && add.Case == cc.AdditiveExpressionAdd {
+			key = v.slotOf(add.MultiplicativeExpression)
+		}
+		if key == "" || add.Case == cc.AdditiveExpressionSub && slicePtr(add.MultiplicativeExpression.Operand.Type()) {
+			return
+		}
+		v.allowed[add] = true
+		v.seed(key, n.Position(), "dereferenced with an offset")
+	}
+}
+
+func (v *sliceVisitor) additive(n *cc.AdditiveExpression) {
+	if n.Case == cc.AdditiveExpressionMul || v.allowed[n] {
+		return
+	}
+	reason := "pointer arithmetic"
+	if n.Case == cc.AdditiveExpressionSub && slicePtr(n.MultiplicativeExpression.Operand.Type()) {
+		reason = "pointer difference"
+	}
+	v.arithSlot(n.AdditiveExpression, n.Position(), reason)
+	v.arithSlot(n.MultiplicativeExpression, n.Position(), reason)
+}
+
+func (v *sliceVisitor) flow(dst string, src cc.Node, where token.Position) {
+	src = unwrapSliceExpr(src)
+	if c, ok := src.(*cc.ConditionalExpression); ok && c.Case == cc.ConditionalExpressionCond {
+		v.flow(dst, c.Expression, where)
+		v.flow(dst, c.ConditionalExpression, where)
+		return
+	}
+	v.s.flows = append(v.s.flows, sliceFlow{dst: dst, val: v.value(src), where: where})
+}
+
+// value classifies a pointer expression assigned to a slot.
+func (v *sliceVisitor) value(n cc.Node) sliceVal {
+	n = unwrapSliceExpr(n)
+	if isNullExpr(n) {
+		return sliceVal{kind: sliceValNull}
+	}
+	if key := v.slotOf(n); key != "" {
+		return sliceVal{kind: sliceValSlot, key: key}
+	}
+	switch n := n.(type) {
+	case *cc.CastExpression:
+		if n.Case == cc.CastExpressionCast && sameElemCast(n.TypeName.Type(), n.CastExpression.Operand.Type()) {
+			v.allowed[n] = true
+			return v.value(n.CastExpression)
+		}
+	case *cc.UnaryExpression:
+		// &p[0] is the same as p
+		if n.Case == cc.UnaryExpressionAddrof {
+			if p, ok := unwrapSliceExpr(n.CastExpression).(*cc.PostfixExpression); ok && p.Case == cc.PostfixExpressionIndex && isZeroExpr(p.Expression) {
+				return v.value(p.PostfixExpression)
+			}
+		}
+	case *cc.PostfixExpression:
+		if n.Case == cc.PostfixExpressionCall {
+			return v.callValue(n)
+		}
+	}
+	return sliceVal{reason: "assigned from a pointer expression"}
+}
+
+func (v *sliceVisitor) callValue(n *cc.PostfixExpression) sliceVal {
+	callee, ok := unwrapSliceExpr(n.PostfixExpression).(*cc.PrimaryExpression)
+	if !ok || callee.Case != cc.PrimaryExpressionIdent {
+		return sliceVal{reason: "assigned from an indirect call"}
+	}
+	name := callee.Token.Value.String()
+	var args []*cc.AssignmentExpression
+	for list := n.ArgumentExpressionList; list != nil; list = list.ArgumentExpressionList {
+		args = append(args, list.AssignmentExpression)
+	}
+	switch name {
+	case "malloc", "__builtin_malloc":
+		if len(args) == 1 {
+			if m, ok := unwrapSliceExpr(args[0]).(*cc.MultiplicativeExpression); ok && m.Case == cc.MultiplicativeExpressionMul {
+				return sliceVal{kind: sliceValArray}
+			}
+		}
+		return sliceVal{reason: "single element allocation"}
+	case "calloc":
+		if len(args) == 2 && !isConstExpr(args[0], 1) {
+			return sliceVal{kind: sliceValArray}
+		}
+		return sliceVal{reason: "single element allocation"}
+	case "realloc":
+		if len(args) != 2 {
+			break
+		}
+		if m, ok := unwrapSliceExpr(args[1]).(*cc.MultiplicativeExpression); !ok || m.Case != cc.MultiplicativeExpressionMul {
+			return sliceVal{reason: "untyped reallocation"}
+		}
+		val := v.value(args[0])
+		switch val.kind {
+		case sliceValNull:
+			return sliceVal{kind: sliceValArray}
+		case sliceValSlot:
+			v.seed(val.key, n.Position(), "reallocated as an array")
+		}
+		return val
+	}
+	return sliceVal{kind: sliceValSlot, key: retKey(name)}
+}
+
+// declKey returns a slot key for a declarator of a variable.
+func (v *sliceVisitor) declKey(d *cc.Declarator) string {
+	if d == nil || d.IsTypedefName || !slicePtr(d.Type()) || isLibSource(d.Position()) {
+		return ""
+	}
+	name := d.Name().String()
+	if d.IsParameter {
+		return v.paramKey(d)
+	}
+	if d.Linkage != cc.None {
+		key := "var " + name
+		v.s.slot(key, &sliceSlot{kind: sliceGlobal, name: name})
+		return key
+	}
+	key := "local " + d.Position().String()
+	v.s.slot(key, &sliceSlot{kind: sliceLocal, name: name, pos: d.Position()})
+	return key
+}
+
+func (v *sliceVisitor) paramKey(d *cc.Declarator) string {
+	fd := v.s.funcs[v.fnc]
+	if v.fnc == "" || fd == nil {
+		return ""
+	}
+	for i := 0; i < fd.args; i++ {
+		key := argKey(v.fnc, i)
+		if sl := v.s.slots[key]; sl != nil && sl.name == d.Name().String() {
+			return key
+		}
+	}
+	return ""
+}
+
+// slotOf returns a slot key for an expression, if it refers to a pointer variable or a struct field.
+func (v *sliceVisitor) slotOf(n cc.Node) string {
+	switch n := unwrapSliceExpr(n).(type) {
+	case *cc.PrimaryExpression:
+		if n.Case != cc.PrimaryExpressionIdent {
+			return ""
+		}
+		return v.declKey(n.Declarator())
+	case *cc.PostfixExpression:
+		if n.Case != cc.PostfixExpressionSelect && n.Case != cc.PostfixExpressionPSelect {
+			return ""
+		}
+		f := n.Field
+		if f == nil || f.Name() == 0 || !slicePtr(f.Type()) {
+			return ""
+		}
+		if sd := f.Declarator(); sd == nil || isLibSource(sd.Position()) {
+			return ""
+		}
+		st := n.PostfixExpression.Operand.Type()
+		if n.Case == cc.PostfixExpressionPSelect {
+			st = st.Elem()
+		}
+		sname := sliceStructName(st)
+		if sname == "" {
+			return ""
+		}
+		key := fieldKey(sname, f.Name().String())
+		v.s.slot(key, &sliceSlot{kind: sliceField, name: sname + "." + f.Name().String(), owner: sname})
+		return key
+	}
+	return ""
+}
+
+func (v *sliceVisitor) seed(key string, where token.Position, reason string) {
+	v.s.seeds = append(v.s.seeds, sliceSite{key: key, where: where, reason: reason})
+}
+
+func (v *sliceVisitor) block(key string, where token.Position, reason string) {
+	v.s.blocks = append(v.s.blocks, sliceSite{key: key, where: where, reason: reason})
+}
+
+func (v *sliceVisitor) blockSlot(n cc.Node, where token.Position, reason string) {
+	if key := v.slotOf(n); key != "" {
+		v.block(key, where, reason)
+	}
+}
+
+// arithSlot records pointer arithmetic. It implies that the pointer refers to an array, thus it seeds the inference,
+// but slices cannot express it, so the pointer is kept and the site is reported in Fallbacks.
+func (v *sliceVisitor) arithSlot(n cc.Node, where token.Position, reason string) {
+	if key := v.slotOf(n); key != "" {
+		v.seed(key, where, reason)
+		v.block(key, where, reason)
+	}
+}
+
+func argKey(fnc string, i int) string {
+	return "arg " + fnc + " " + strconv.Itoa(i)
+}
+
+func retKey(fnc string) string {
+	return "ret " + fnc
+}
+
+func fieldKey(sname, field string) string {
+	return "field " + sname + " " + field
+}
+
+// sliceStructName returns a name of a struct type, as used in IdentConfig.
+// It's either a struct tag, or a typedef name for anonymous structs.
+func sliceStructName(t cc.Type) string {
+	var name string
+	for {
+		if t.Name() != 0 {
+			name = t.Name().String()
+		}
+		u := t.Alias()
+		if u == t {
+			break
+		}
+		t = u
+	}
+	if t.Kind() != cc.Struct {
+		return ""
+	}
+	if t.Name() != 0 {
+		return t.Name().String()
+	}
+	return name
+}
+
+// sameElemCast checks if a pointer cast keeps a pointer convertible to a slice: either to the same type, or to void*.
+func sameElemCast(to, from cc.Type) bool {
+	if to.Kind() != cc.Ptr || from.Kind() != cc.Ptr {
+		return false
+	}
+	if to.Elem().Kind() == cc.Void || from.Elem().Kind() == cc.Void {
+		return true
+	}
+	return to.Elem().String() == from.Elem().String()
+}
+
+// unwrapSliceExpr skips expression nodes that only wrap a single sub-expression, including parentheses.
+func unwrapSliceExpr(n cc.Node) cc.Node {
+	for {
+		switch e := n.(type) {
+		case *cc.Expression:
+			if e.Case != cc.ExpressionAssign {
+				return n
+			}
+			n = e.AssignmentExpression
+		case *cc.Initializer:
+			if e.Case != cc.InitializerExpr {
+				return n
+			}
+			n = e.AssignmentExpression
+		case *cc.AssignmentExpression:
+			if e.Case != cc.AssignmentExpressionCond {
+				return n
+			}
+			n = e.ConditionalExpression
+		case *cc.ConditionalExpression:
+			if e.Case != cc.ConditionalExpressionLOr {
+				return n
+			}
+			n = e.LogicalOrExpression
+		case *cc.LogicalOrExpression:
+			if e.Case != cc.LogicalOrExpressionLAnd {
+				return n
+			}
+			n = e.LogicalAndExpression
+		case *cc.LogicalAndExpression:
+			if e.Case != cc.LogicalAndExpressionOr {
+				return n
+			}
+			n = e.InclusiveOrExpression
+		case *cc.InclusiveOrExpression:
+			if e.Case != cc.InclusiveOrExpressionXor {
+				return n
+			}
+			n = e.ExclusiveOrExpression
+		case *cc.ExclusiveOrExpression:
+			if e.Case != cc.ExclusiveOrExpressionAnd {
+				return n
+			}
+			n = e.AndExpression
+		case *cc.AndExpression:
+			if e.Case != cc.AndExpressionEq {
+				return n
+			}
+			n = e.EqualityExpression
+		case *cc.EqualityExpression:
+			if e.Case != cc.EqualityExpressionRel {
+				return n
+			}
+			n = e.RelationalExpression
+		case *cc.RelationalExpression:
+			if e.Case != cc.RelationalExpressionShift {
+				return n
+			}
+			n = e.ShiftExpression
+		case *cc.ShiftExpression:
+			if e.Case != cc.ShiftExpressionAdd {
+				return n
+			}
+			n = e.AdditiveExpression
+		case *cc.AdditiveExpression:
+			if e.Case != cc.AdditiveExpressionMul {
+				return n
+			}
+			n = e.MultiplicativeExpression
+		case *cc.MultiplicativeExpression:
+			if e.Case != cc.MultiplicativeExpressionCast {
+				return n
+			}
+			n = e.CastExpression
+		case *cc.CastExpression:
+			if e.Case != cc.CastExpressionUnary {
+				return n
+			}
+			n = e.UnaryExpression
+		case *cc.UnaryExpression:
+			if e.Case != cc.UnaryExpressionPostfix {
+				return n
+			}
+			n = e.PostfixExpression
+		case *cc.PostfixExpression:
+			if e.Case != cc.PostfixExpressionPrimary {
+				return n
+			}
+			n = e.PrimaryExpression
+		case *cc.PrimaryExpression:
+			if e.Case != cc.PrimaryExpressionExpr {
+				return n
+			}
+			n = e.Expression
+		default:
+			return n
+		}
+	}
+}
+
+func isConstExpr(n cc.Node, val int64) bool {
+	var op cc.Operand
+	switch n := unwrapSliceExpr(n).(type) {
+	case *cc.PrimaryExpression:
+		op = n.Operand
+	case *cc.CastExpression:
+		op = n.Operand
+	case *cc.Expression:
+		op = n.Operand
+	default:
+		return false
+	}
+	if op == nil {
+		return false
+	}
+	switch c := op.Value().(type) {
+	case cc.Int64Value:
+		return int64(c) == val
+	case cc.Uint64Value:
+		return val >= 0 && uint64(c) == uint64(val)
+	}
+	return false
+}
+
+func isZeroExpr(n cc.Node) bool {
+	return isConstExpr(n, 0)
+}
+
+// isNullExpr checks if an expression is a NULL pointer constant.
+func isNullExpr(n cc.Node) bool {
+	n = unwrapSliceExpr(n)
+	if c, ok := n.(*cc.CastExpression); ok && c.Case == cc.CastExpressionCast {
+		return isNullExpr(c.CastExpression)
+	}
+	return isZeroExpr(n)
+}
+
+func (s *Slices) slot(key string, sl *sliceSlot) {
+	if _, ok := s.slots[key]; !ok {
+		s.slots[key] = sl
+	}
+}
+
+func (s *Slices) function(name string) *sliceFunc {
+	f := s.funcs[name]
+	if f == nil {
+		f = &sliceFunc{}
+		s.funcs[name] = f
+	}
+	return f
+}
+
+func (s *Slices) resolve() {
+	if s.resolved {
+		return
+	}
+	s.resolved = true
+	for _, sl := range s.slots {
+		sl.parent = nil
+	}
+	var blocks []sliceSite
+	seeds := append([]sliceSite{}, s.seeds...)
+	for _, f := range s.flows {
+		dst := s.slots[f.dst]
+		switch f.val.kind {
+		case sliceValSlot:
+			src := s.slots[f.val.key]
+			if dst == nil || src == nil {
+				if dst != nil {
+					blocks = append(blocks, sliceSite{key: f.dst, where: f.where, reason: "assigned from a pointer that cannot be a slice"})
+				}
+				continue
+			}
+			if a, b := dst.root(), src.root(); a != b {
+				b.parent = a
+			}
+		case sliceValArray:
+			if dst != nil {
+				seeds = append(seeds, sliceSite{key: f.dst, where: f.where, reason: "allocated as an array"})
+			}
+		case sliceValOther:
+			if dst != nil {
+				blocks = append(blocks, sliceSite{key: f.dst, where: f.where, reason: f.val.reason})
+			}
+		}
+	}
+	blocks = append(blocks, s.blocks...)
+	for name, f := range s.funcs {
+		if len(f.addr) == 0 {
+			continue
+		}
+		keys := []string{retKey(name)}
+		for i := 0; i < f.args; i++ {
+			keys = append(keys, argKey(name, i))
+		}
+		for _, key := range keys {
+			for _, pos := range f.addr {
+				blocks = append(blocks, sliceSite{key: key, where: pos, reason: "function is used as a value"})
+			}
+		}
+	}
+	for key, sl := range s.slots {
+		if sl.kind != sliceField {
+			continue
+		}
+		for _, pos := range s.inits[sl.owner] {
+			blocks = append(blocks, sliceSite{key: key, where: pos, reason: "struct is initialized with a list"})
+		}
+	}
+	isSeed := make(map[*sliceSlot]bool)
+	for _, st := range seeds {
+		if sl := s.slots[st.key]; sl != nil {
+			isSeed[sl.root()] = true
+		}
+	}
+	isBlocked := make(map[*sliceSlot]bool)
+	s.fallbacks = nil
+	for _, st := range blocks {
+		sl := s.slots[st.key]
+		if sl == nil {
+			continue
+		}
+		r := sl.root()
+		isBlocked[r] = true
+		if isSeed[r] {
+			s.fallbacks = append(s.fallbacks, SliceFallback{Name: sl.name, Where: st.where, Reason: st.reason})
+		}
+	}
+	sort.SliceStable(s.fallbacks, func(i, j int) bool {
+		a, b := s.fallbacks[i].Where, s.fallbacks[j].Where
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	s.globals = make(map[string]bool)
+	s.locals = make(map[token.Position]bool)
+	s.fields = make(map[string]map[string]bool)
+	s.args = make(map[string]map[int]bool)
+	s.rets = make(map[string]bool)
+	for _, sl := range s.slots {
+		if r := sl.root(); !isSeed[r] || isBlocked[r] {
+			continue
+		}
+		switch sl.kind {
+		case sliceGlobal:
+			s.globals[sl.name] = true
+		case sliceLocal:
+			s.locals[sl.pos] = true
+		case sliceField:
+			field := strings.TrimPrefix(sl.name, sl.owner+".")
+			if s.fields[sl.owner] == nil {
+				s.fields[sl.owner] = make(map[string]bool)
+			}
+			s.fields[sl.owner][field] = true
+		case sliceArg:
+			if s.args[sl.owner] == nil {
+				s.args[sl.owner] = make(map[int]bool)
+			}
+			s.args[sl.owner][sl.index] = true
+			if sl.pos.IsValid() {
+				s.locals[sl.pos] = true
+			}
+		case sliceRet:
+			s.rets[sl.owner] = true
+		}
+	}
+}
+
+// Fallbacks returns sites that prevented pointers from being converted to slices.
+func (s *Slices) Fallbacks() []SliceFallback {
+	s.resolve()
+	return s.fallbacks
+}
+
+// isSlice checks if a declaration of a variable or an argument was inferred as a slice.
+func (s *Slices) isSlice(d *cc.Declarator) bool {
+	s.resolve()
+	if d.Linkage != cc.None {
+		return s.globals[d.Name().String()]
+	}
+	return s.locals[d.Position()]
+}
+
+// idents returns slice hints for function arguments, return values and struct fields, merged with the given list.
+func (s *Slices) idents(list []IdentConfig) []IdentConfig {
+	s.resolve()
+	extra := make(map[string][]IdentConfig)
+	for sname, fields := range s.fields {
+		for name := range fields {
+			extra[sname] = append(extra[sname], IdentConfig{Name: name, Type: HintSlice})
+		}
+	}
+	for fnc, args := range s.args {
+		for i := range args {
+			extra[fnc] = append(extra[fnc], IdentConfig{Index: i, Type: HintSlice})
+		}
+	}
+	for fnc := range s.rets {
+		extra[fnc] = append(extra[fnc], IdentConfig{Name: "return", Type: HintSlice})
+	}
+	return mergeIdentFields(list, extra)
+}
+
+// declConf returns a config for a variable or an argument declaration, including inferred slice hints.
+func (g *translator) declConf(d *cc.Declarator) IdentConfig {
+	conf := g.idents[d.Name().String()]
+	if conf.Type == "" && g.conf.Slices != nil && g.conf.Slices.isSlice(d) {
+		conf.Type = HintSlice
+	}
+	return conf
+}
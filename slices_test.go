@@ -0,0 +1,153 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestSlicesInfer(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"vec.h": `
+typedef struct {
+	int n;
+	int* data;
+} vec;
+
+int* vec_alloc(int n);
+int vec_sum(int* a, int n);
+int walk(int* p, int n);
+`,
+		"vec.c": `
+#include <stdlib.h>
+#include "vec.h"
+
+int* vec_alloc(int n) {
+	int* buf = malloc(n * sizeof(int));
+	return buf;
+}
+
+int vec_sum(int* a, int n) {
+	int s = 0;
+	for (int i = 0; i < n; i++) s += a[i];
+	return s;
+}
+
+int walk(int* p, int n) {
+	int s = 0;
+	int* end = p + n;
+	while (p < end) s += *p++;
+	return s;
+}
+`,
+		"main.c": `
+#include <stdlib.h>
+#include "vec.h"
+
+int first(int* p) { return *p; }
+
+int run(vec* v, int n) {
+	v->data = vec_alloc(n);
+	v->n = n;
+	for (int i = 0; i < n; i++) *(v->data + i) = i;
+	int* tmp = v->data;
+	int* w = calloc(4, sizeof(int));
+	w[1] = first(tmp);
+	return vec_sum(tmp, v->n) + walk(w, 4);
+}
+`,
+	})
+	files := []string{"vec.c", "main.c"}
+	s := NewSlices()
+	conf := Config{Root: src, MaxDecls: -1, Slices: s}
+	for _, name := range files {
+		err := IndexFile(filepath.Join(src, name), libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	var fallbacks []string
+	for _, f := range s.Fallbacks() {
+		fallbacks = append(fallbacks, strings.TrimPrefix(f.String(), src+string(filepath.Separator)))
+	}
+	require.Equal(t, []string{
+		"vec.c:17:13: p: pointer arithmetic",
+		"vec.c:18:9: p: pointer comparison",
+		"vec.c:18:24: p: pointer increment",
+	}, fallbacks)
+	for _, name := range files {
+		err := Translate(src, filepath.Join(src, name), out, libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "vec.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "\tData []int32\n")
+	require.Contains(t, string(data), "func vec_alloc(n int32) []int32 {\n\tvar buf []int32 = make([]int32, int(n))\n")
+	require.Contains(t, string(data), "func vec_sum(a []int32, n int32) int32 {")
+	require.Contains(t, string(data), "func walk(p *int32, n int32) int32 {")
+
+	data, err = os.ReadFile(filepath.Join(out, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+import (
+	"github.com/gotranspile/cxgo/runtime/libc"
+	"unsafe"
+)
+
+func first(p []int32) int32 {
+	return p[0]
+}
+func run(v *vec, n int32) int32 {
+	v.Data = vec_alloc(n)
+	v.N = n
+	for i := int32(0); i < n; i++ {
+		v.Data[i] = i
+	}
+	var tmp []int32 = v.Data
+	var w *int32 = libc.NewArray[int32](4)
+	*(*int32)(unsafe.Add(unsafe.Pointer(w), unsafe.Sizeof(int32(0))*1)) = first(tmp)
+	return vec_sum(tmp, v.N) + walk(w, 4)
+}
+`, string(data))
+}
+
+func TestSlicesArithOnly(t *testing.T) {
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"main.c": `
+int count(const char* s) {
+	int n = 0;
+	while (*s++) n++;
+	return n;
+}
+
+int last(int* p, int n) {
+	p += n - 1;
+	return *p;
+}
+
+int get(int* p) {
+	return *p;
+}
+`,
+	})
+	s := NewSlices()
+	err := IndexFile(filepath.Join(src, "main.c"), libs.NewEnv(types.Config32()), Config{Root: src, MaxDecls: -1, Slices: s})
+	require.NoError(t, err)
+	var fallbacks []string
+	for _, f := range s.Fallbacks() {
+		fallbacks = append(fallbacks, strings.TrimPrefix(f.String(), src+string(filepath.Separator)))
+	}
+	require.Equal(t, []string{
+		"main.c:3:10: s: pointer increment",
+		"main.c:8:2: p: pointer arithmetic",
+	}, fallbacks)
+}
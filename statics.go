@@ -0,0 +1,229 @@
+package cxgo
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"modernc.org/cc/v3"
+)
+
+// Statics renames file-scope static functions and variables that collide with each other
+// (or with external symbols) once C files are translated into the same Go package.
+//
+// All C files must be indexed with IndexFile before any of them is translated.
+type Statics struct {
+	root  string // absolute path of the C source root
+	split bool   // each directory is a separate Go package, see Packages
+
+	visible map[string]map[string]string   // C file -> static name -> file that defines it
+	statics map[string]map[string]struct{} // static name -> files that define it
+	globals map[string]map[string]struct{} // external name -> directories that define it
+}
+
+// NewStatics creates a new index of static symbols for a given C source root.
+func NewStatics(root string) (*Statics, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Statics{
+		root:    root,
+		visible: make(map[string]map[string]string),
+		statics: make(map[string]map[string]struct{}),
+		globals: make(map[string]map[string]struct{}),
+	}, nil
+}
+
+// relPath returns a slash-separated path of a file relative to the root, or false if it's outside of the root.
+func (s *Statics) relPath(fname string) (string, bool) {
+	if isCxgoSource(fname) {
+		return "", false
+	}
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+// pkgOf returns a Go package key for a file. All files share the same package, unless Packages are used.
+func (s *Statics) pkgOf(rel string) string {
+	if !s.split {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Dir(rel))
+}
+
+// Index records definitions of static and external functions and variables visible in a translation unit.
+//
+// Static symbols declared in a header are defined by the header itself, thus including it from
+// multiple files does not cause a collision.
+func (s *Statics) Index(fname string, tu *cc.AST) {
+	cur, ok := s.relPath(fname)
+	if !ok {
+		return
+	}
+	vis := s.visible[cur]
+	if vis == nil {
+		vis = make(map[string]string)
+		s.visible[cur] = vis
+	}
+	add := func(name, file string, static bool) {
+		if static {
+			vis[name] = file
+			addToSet(s.statics, name, file)
+		} else {
+			addToSet(s.globals, name, s.pkgOf(file))
+		}
+	}
+	for it := tu.TranslationUnit; it != nil; it = it.TranslationUnit {
+		d := it.ExternalDeclaration
+		if d == nil {
+			continue
+		}
+		file, ok := s.relPath(d.Position().Filename)
+		if !ok {
+			continue // system headers
+		}
+		switch d.Case {
+		case cc.ExternalDeclarationFuncDef:
+			fd := d.FunctionDefinition
+			name := fd.Declarator.Name().String()
+			if strings.HasPrefix(name, macroFuncPrefix) {
+				continue
+			}
+			add(name, file, fd.Declarator.IsStatic())
+		case cc.ExternalDeclarationDecl:
+			for il := d.Declaration.InitDeclaratorList; il != nil; il = il.InitDeclaratorList {
+				dd := il.InitDeclarator.Declarator
+				name := dd.Name().String()
+				if name == "" || dd.IsTypedefName || dd.IsExtern() {
+					continue
+				}
+				if dd.Type() != nil && dd.Type().Kind() == cc.Function {
+					continue // prototype; defined by the function definition
+				}
+				add(name, file, dd.IsStatic())
+			}
+		}
+	}
+}
+
+func addToSet(m map[string]map[string]struct{}, key, val string) {
+	set := m[key]
+	if set == nil {
+		set = make(map[string]struct{})
+		m[key] = set
+	}
+	set[val] = struct{}{}
+}
+
+// collides checks if a static symbol defined in a given file has the same name as any other
+// function or variable in the same Go package.
+func (s *Statics) collides(name, file string) bool {
+	pkg := s.pkgOf(file)
+	if _, ok := s.globals[name][pkg]; ok {
+		return true
+	}
+	for other := range s.statics[name] {
+		if other != file && s.pkgOf(other) == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// declared checks if a Go name is used by any function or variable in the Go package of a given file,
+// including symbols defined in other files of the package and renamed static symbols.
+func (s *Statics) declared(fname, name string) bool {
+	cur, ok := s.relPath(fname)
+	if !ok {
+		return false
+	}
+	pkg := s.pkgOf(cur)
+	if _, ok := s.globals[name][pkg]; ok {
+		return true
+	}
+	for file := range s.statics[name] {
+		if s.pkgOf(file) == pkg && !s.collides(name, file) {
+			return true
+		}
+	}
+	for sname, files := range s.statics {
+		for file := range files {
+			if s.pkgOf(file) == pkg && s.collides(sname, file) && staticPrefix(file)+"_"+sname == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renames returns Go names for colliding static symbols visible in a given C file.
+func (s *Statics) renames(fname string) map[string]string {
+	cur, ok := s.relPath(fname)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string)
+	for name, file := range s.visible[cur] {
+		if s.collides(name, file) {
+			out[name] = staticPrefix(file) + "_" + name
+		}
+	}
+	return out
+}
+
+// staticPrefix converts a file path to an unexported Go identifier.
+func staticPrefix(file string) string {
+	file = strings.TrimSuffix(file, filepath.Ext(file))
+	pref := strings.Map(func(r rune) rune {
+		if r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			return r
+		}
+		return '_'
+	}, file)
+	if pref == "" || !unicode.IsLetter(rune(pref[0])) {
+		pref = "f" + pref
+	}
+	// the result is always followed by a symbol name, thus it cannot be a keyword
+	return strings.ToLower(pref[:1]) + pref[1:]
+}
+
+// idents adds renames for colliding static symbols visible in a given file. User-configured renames take priority.
+func (s *Statics) idents(fname string, list []IdentConfig) []IdentConfig {
+	renames := s.renames(fname)
+	if len(renames) == 0 {
+		return list
+	}
+	out := make([]IdentConfig, 0, len(list)+len(renames))
+	for _, c := range list {
+		if goname, ok := renames[c.Name]; ok {
+			delete(renames, c.Name)
+			if c.Rename == "" {
+				c.Rename = goname
+			}
+		}
+		out = append(out, c)
+	}
+	names := make([]string, 0, len(renames))
+	for name := range renames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, IdentConfig{Name: name, Rename: renames[name]})
+	}
+	return out
+}
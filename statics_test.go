@@ -0,0 +1,115 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestStaticsRename(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"util.h": `
+static int twice(int x) { return 2 * x; }
+int next_a(void);
+int next_b(void);
+`,
+		"a.c": `
+#include "util.h"
+
+static int count;
+static int step(void) { return ++count; }
+int next_a(void) { return twice(step()); }
+`,
+		"sub/b.c": `
+#include "../util.h"
+
+static int count = 100;
+static int step(void) { return --count; }
+int next_b(void) { return twice(step()); }
+`,
+		"c.c": `
+int step = 1;
+static int only(void) { return step; }
+int total(void) { return only(); }
+`,
+	})
+	files := []string{"a.c", "sub/b.c", "c.c"}
+	s, err := NewStatics(src)
+	require.NoError(t, err)
+	conf := Config{Root: src, MaxDecls: -1, Statics: s}
+	for _, name := range files {
+		err = IndexFile(filepath.Join(src, name), libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	for _, name := range files {
+		err = Translate(src, filepath.Join(src, name), out, libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "a.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+var a_count int32
+
+func a_step() int32 {
+	return func() int32 {
+		p_ := &a_count
+		*p_++
+		return *p_
+	}()
+}
+func next_a() int32 {
+	return twice(a_step())
+}
+`, string(data))
+
+	data, err = os.ReadFile(filepath.Join(out, "sub_b.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "var sub_b_count int32 = 100\n")
+	require.Contains(t, string(data), "func next_b() int32 {\n\treturn twice(sub_b_step())\n}")
+
+	data, err = os.ReadFile(filepath.Join(out, "c.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "var step int32 = 1\n")
+	require.Contains(t, string(data), "func only() int32 {\n\treturn step\n}")
+}
+
+func TestStaticLocalsPackage(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"a.c": `
+int counter_n = 1;
+`,
+		"b.c": `
+int counter(void) {
+	static int n;
+	return ++n;
+}
+`,
+	})
+	files := []string{"a.c", "b.c"}
+	s, err := NewStatics(src)
+	require.NoError(t, err)
+	conf := Config{Root: src, MaxDecls: -1, Statics: s}
+	for _, name := range files {
+		err = IndexFile(filepath.Join(src, name), libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	for _, name := range files {
+		err = Translate(src, filepath.Join(src, name), out, libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "b.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "var counter_n2 int32\n")
+}
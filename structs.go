@@ -0,0 +1,286 @@
+package cxgo
+
+import (
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modernc.org/cc/v3"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Structs tracks definitions of struct types across translation units.
+//
+// Projects often declare an opaque (or a partial) struct in public headers and define it completely in private ones.
+// Each translation unit only sees one of the definitions, which leads to conflicting Go types with the same name.
+// If all definitions of a tag are prefixes of the most complete one, the pair is merged: only the complete
+// definition is emitted, and fields that are missing in public definitions are considered private.
+// Structs are not merged if the size of a partial public definition is taken, since C code would use a different size.
+//
+// All C files must be indexed with IndexFile before any of them is translated.
+type Structs struct {
+	// ExportPrivate controls if private fields are exported in Go. They are unexported by default.
+	ExportPrivate bool
+
+	tags     map[string]*structTag
+	resolved bool
+	merged   map[string]*structMerge
+}
+
+type structTag struct {
+	variants []structVariant // distinct definitions
+}
+
+type structVariant struct {
+	fields []structField // empty for opaque declarations
+	sized  bool          // sizeof or _Alignof is used with this definition
+}
+
+type structField struct {
+	name string
+	typ  string
+}
+
+type structMerge struct {
+	fields  int             // number of fields in the complete definition
+	private map[string]bool // names of private fields
+}
+
+// NewStructs creates an empty index of struct definitions.
+func NewStructs() *Structs {
+	return &Structs{tags: make(map[string]*structTag)}
+}
+
+// Index records definitions of tagged structs in a translation unit, including opaque ones.
+func (s *Structs) Index(tu *cc.AST) {
+	s.resolved = false
+	defs := make(map[string][]structField)
+	used := make(map[string]struct{})
+	sized := make(map[string]bool)
+	cc.Inspect(tu.TranslationUnit, func(n cc.Node, enter bool) bool {
+		if e, ok := n.(*cc.UnaryExpression); ok && enter {
+			var t cc.Type
+			switch e.Case {
+			case cc.UnaryExpressionSizeofExpr, cc.UnaryExpressionAlignofExpr:
+				if op := e.UnaryExpression.Operand; op != nil {
+					t = op.Type()
+				}
+			case cc.UnaryExpressionSizeofType, cc.UnaryExpressionAlignofType:
+				t = e.TypeName.Type()
+			}
+			for t != nil && t.Kind() == cc.Array {
+				t = t.Elem()
+			}
+			if t != nil && t.Kind() == cc.Struct && t.Tag() != 0 {
+				sized[t.Tag().String()] = true
+			}
+			return true
+		}
+		sp, ok := n.(*cc.StructOrUnionSpecifier)
+		if !enter || !ok || sp.Token.Value == 0 || sp.StructOrUnion.Case != cc.StructOrUnionStruct {
+			return true
+		}
+		if fname := sp.Position().Filename; isCxgoSource(fname) || strings.HasPrefix(fname, libs.IncludePath) {
+			return true
+		}
+		tag := sp.Token.Value.String()
+		switch sp.Case {
+		case cc.StructOrUnionSpecifierDef:
+			t := sp.Type()
+			if t == nil || t.IsIncomplete() {
+				return true
+			}
+			fields := make([]structField, 0, t.NumField())
+			for i := 0; i < t.NumField(); i++ {
+				f := t.FieldByIndex([]int{i})
+				fields = append(fields, structField{name: f.Name().String(), typ: f.Type().String()})
+			}
+			defs[tag] = fields
+		case cc.StructOrUnionSpecifierTag:
+			used[tag] = struct{}{}
+		}
+		return true
+	})
+	for tag := range used {
+		if _, ok := defs[tag]; !ok {
+			s.addVariant(tag, nil, false)
+		}
+	}
+	for tag, fields := range defs {
+		s.addVariant(tag, fields, sized[tag])
+	}
+}
+
+func (s *Structs) addVariant(tag string, fields []structField, sized bool) {
+	st := s.tags[tag]
+	if st == nil {
+		st = &structTag{}
+		s.tags[tag] = st
+	}
+	for i, v := range st.variants {
+		if sameFields(v.fields, fields) {
+			st.variants[i].sized = v.sized || sized
+			return
+		}
+	}
+	st.variants = append(st.variants, structVariant{fields: fields, sized: sized})
+}
+
+func sameFields(a, b []structField) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Structs) resolve() {
+	if s.resolved {
+		return
+	}
+	s.resolved = true
+	s.merged = make(map[string]*structMerge)
+	for tag, st := range s.tags {
+		if len(st.variants) < 2 {
+			continue
+		}
+		full, public := st.variants[0].fields, len(st.variants[0].fields)
+		for _, v := range st.variants[1:] {
+			if len(v.fields) > len(full) {
+				full = v.fields
+			}
+			if len(v.fields) < public {
+				public = len(v.fields)
+			}
+		}
+		ok := true
+		for _, v := range st.variants {
+			if !sameFields(v.fields, full[:len(v.fields)]) {
+				ok = false // conflicting definitions
+				break
+			}
+			if v.sized && len(v.fields) < len(full) {
+				ok = false // C code depends on the size of a partial definition
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		m := &structMerge{fields: len(full), private: make(map[string]bool)}
+		for _, f := range full[public:] {
+			m.private[f.name] = true
+		}
+		s.merged[tag] = m
+	}
+}
+
+// Merged returns true if public and private definitions of a given struct tag were merged.
+func (s *Structs) Merged(tag string) bool {
+	s.resolve()
+	_, ok := s.merged[tag]
+	return ok
+}
+
+// isPartial checks if a given C type is a public (opaque or partial) definition of a merged struct.
+func (s *Structs) isPartial(tag string, t cc.Type) bool {
+	s.resolve()
+	m, ok := s.merged[tag]
+	if !ok {
+		return false
+	}
+	return t.IsIncomplete() || t.NumField() < m.fields
+}
+
+// checkPartial records public definitions of merged structs, so they can be skipped.
+func (g *translator) checkPartial(tag string, t cc.Type) {
+	if g.conf.Structs != nil && tag != "" && g.conf.Structs.isPartial(tag, t) {
+		g.partial[tag] = true
+	}
+}
+
+// idents returns renames for private fields of merged structs, merged with the given list.
+func (s *Structs) idents(list []IdentConfig) []IdentConfig {
+	s.resolve()
+	if len(s.merged) == 0 {
+		return list
+	}
+	extra := make(map[string][]IdentConfig)
+	for tag, m := range s.merged {
+		names := make([]string, 0, len(m.private))
+		for name := range m.private {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			goname := unexportedName(name)
+			if s.ExportPrivate {
+				goname = asExportedName(name)
+			}
+			extra[tag] = append(extra[tag], IdentConfig{Name: name, Rename: goname})
+		}
+	}
+	return mergeIdentFields(list, extra)
+}
+
+// mergeIdentFields adds generated field configs to identifiers in the list. Fields configured by the user win.
+func mergeIdentFields(list []IdentConfig, extra map[string][]IdentConfig) []IdentConfig {
+	byName := make(map[string]int)
+	out := make([]IdentConfig, 0, len(list)+len(extra))
+	for _, c := range list {
+		byName[c.Name] = len(out)
+		out = append(out, c)
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add := extra[name]
+		i, ok := byName[name]
+		if !ok {
+			i = len(out)
+			out = append(out, IdentConfig{Name: name})
+		}
+		c := &out[i]
+		seen := make(map[string]struct{})
+		for _, f := range c.Fields {
+			seen[fieldConfKey(f)] = struct{}{}
+		}
+		fields := append([]IdentConfig{}, c.Fields...)
+		for _, f := range add {
+			if _, ok := seen[fieldConfKey(f)]; ok {
+				continue // user config wins
+			}
+			fields = append(fields, f)
+		}
+		c.Fields = fields
+	}
+	return out
+}
+
+// fieldConfKey identifies a field config by name, or by an argument index for unnamed ones.
+func fieldConfKey(f IdentConfig) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return "#" + strconv.Itoa(f.Index)
+}
+
+func unexportedName(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.ToLower(s[:1]) + s[1:]
+	if token.IsKeyword(s) {
+		s += "_"
+	}
+	return s
+}
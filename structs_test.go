@@ -0,0 +1,180 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+func TestStructsMerge(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	writeTestFiles(t, src, map[string]string{
+		"pub.h": `
+typedef struct widget widget;
+
+struct other {
+	int a;
+};
+
+int widget_get(widget* w);
+`,
+		"handle.h": `
+struct handle {
+	int id;
+};
+`,
+		"priv.h": `
+#include "pub.h"
+
+struct widget {
+	int value;
+	char* type;
+};
+`,
+		"handle_priv.h": `
+struct handle {
+	int id;
+	int refs;
+};
+`,
+		"conflict.h": `
+struct other {
+	float b;
+};
+`,
+		"api.c": `
+#include "pub.h"
+#include "handle.h"
+
+int use(widget* w, struct handle* h, struct other* o) {
+	return widget_get(w) + h->id + o->a;
+}
+`,
+		"impl.c": `
+#include "priv.h"
+#include "handle_priv.h"
+#include "conflict.h"
+
+int widget_get(widget* w) { return w->value + (w->type != 0); }
+int handle_id(struct handle* h) { h->refs++; return h->id; }
+`,
+	})
+	files := []string{"pub.h", "handle.h", "priv.h", "handle_priv.h", "api.c", "impl.c"}
+	run := func(export bool) map[string]string {
+		out := filepath.Join(dir, "go")
+		require.NoError(t, os.RemoveAll(out))
+		s := NewStructs()
+		s.ExportPrivate = export
+		conf := Config{Root: src, MaxDecls: -1, Structs: s}
+		for _, name := range files {
+			err := IndexFile(filepath.Join(src, name), libs.NewEnv(types.Config32()), conf)
+			require.NoError(t, err)
+		}
+		require.True(t, s.Merged("widget"))
+		require.True(t, s.Merged("handle"))
+		require.False(t, s.Merged("other"))
+		for _, name := range files {
+			err := Translate(src, filepath.Join(src, name), out, libs.NewEnv(types.Config32()), conf)
+			require.NoError(t, err)
+		}
+		res := make(map[string]string)
+		list, err := os.ReadDir(out)
+		require.NoError(t, err)
+		for _, f := range list {
+			data, err := os.ReadFile(filepath.Join(out, f.Name()))
+			require.NoError(t, err)
+			res[f.Name()] = string(data)
+		}
+		return res
+	}
+	res := run(false)
+	require.Equal(t, `package lib
+
+type other struct {
+	A int32
+}
+`, res["pub.go"])
+	require.Equal(t, `package lib
+
+type widget struct {
+	value int32
+	type_ *byte
+}
+`, res["priv.go"])
+	require.Equal(t, `package lib
+
+type handle struct {
+	Id   int32
+	refs int32
+}
+`, res["handle_priv.go"])
+	require.NotContains(t, res, "handle.go")
+	require.Equal(t, `package lib
+
+import "github.com/gotranspile/cxgo/runtime/libc"
+
+func widget_get(w *widget) int32 {
+	return w.value + libc.BoolToInt(w.type_ != nil)
+}
+func handle_id(h *handle) int32 {
+	h.refs++
+	return h.Id
+}
+`, res["impl.go"])
+
+	res = run(true)
+	require.Contains(t, res["priv.go"], "\tType  *byte\n")
+	require.Contains(t, res["impl.go"], "h.Refs++")
+}
+
+func TestStructsMergeSized(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"pub.h": `
+typedef struct buf {
+	int len;
+} buf;
+`,
+		"priv.h": `
+struct buf {
+	int len;
+	char* data;
+};
+`,
+		"api.c": `
+#include "pub.h"
+
+char scratch[sizeof(buf)];
+`,
+		"impl.c": `
+#include "priv.h"
+
+int buf_len(struct buf* b) { return b->len; }
+`,
+	})
+	s := NewStructs()
+	conf := Config{Root: dir, MaxDecls: -1, Structs: s}
+	for _, name := range []string{"api.c", "impl.c"} {
+		err := IndexFile(filepath.Join(dir, name), libs.NewEnv(types.Config32()), conf)
+		require.NoError(t, err)
+	}
+	require.False(t, s.Merged("buf"))
+}
+
+func TestStructsIdentsOrder(t *testing.T) {
+	s := &Structs{resolved: true, merged: map[string]*structMerge{
+		"b": {private: map[string]bool{"z": true, "y": true}},
+		"a": {private: map[string]bool{"x": true}},
+	}}
+	require.Equal(t, []IdentConfig{
+		{Name: "c"},
+		{Name: "a", Fields: []IdentConfig{{Name: "x", Rename: "x"}}},
+		{Name: "b", Fields: []IdentConfig{{Name: "y", Rename: "y"}, {Name: "z", Rename: "z"}}},
+	}, s.idents([]IdentConfig{{Name: "c"}}))
+}
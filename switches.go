@@ -0,0 +1,578 @@
+package cxgo
+
+import (
+	"bytes"
+	"go/ast"
+	token2 "go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"modernc.org/cc/v3"
+	"modernc.org/token"
+
+	"github.com/gotranspile/cxgo/libs"
+)
+
+// Runtime switches
+//
+// Conditional compilation is resolved by the preprocessor, thus only one side of `#ifdef FEATURE` survives
+// the translation. For macros that are marked as switches, cxgo rewrites conditionals in statement context
+// to regular `if` statements before preprocessing:
+//
+//	#ifdef ENABLE_LOGGING          if (_cxgo_switch_ENABLE_LOGGING) {
+//		log_msg("x");          ->      log_msg("x");
+//	#else                          } else {
+//		x++;                           x++;
+//	#endif                         }
+//
+// The condition is declared as an external _Bool variable, which is renamed to a Go constant.
+// Each directive is replaced on the same line, so positions of the rest of the code are not affected.
+// Conditionals that cannot be proven to wrap complete statements (struct fields, initializers, partial statements,
+// dangling else or case labels) are left to the preprocessor, which resolves them according to the default value
+// of the switch. Switches that guard file-scope declarations are rejected, since one of the branches would be lost.
+//
+// Only user sources are rewritten; files included with angle brackets are read as is.
+
+// Switch is a C macro that is translated to a Go bool constant, so both sides of conditionals are kept.
+type Switch struct {
+	Name  string `yaml:"name" json:"name"`   // C macro name
+	Go    string `yaml:"go" json:"go"`       // Go constant name; derived from Name if empty
+	Value bool   `yaml:"value" json:"value"` // default value; the macro is defined for the preprocessor if it's true
+}
+
+const switchPrefix = "_cxgo_switch_"
+
+// GoName returns a Go constant name for the switch.
+func (s Switch) GoName() string {
+	if s.Go != "" {
+		return s.Go
+	}
+	var buf strings.Builder
+	for _, part := range strings.Split(s.Name, "_") {
+		if part == "" {
+			continue
+		}
+		buf.WriteString(strings.ToUpper(part[:1]))
+		buf.WriteString(strings.ToLower(part[1:]))
+	}
+	name := buf.String()
+	if name == "" || !unicode.IsUpper(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+func (s Switch) cName() string {
+	return switchPrefix + s.Name
+}
+
+// switchesSource returns C declarations and defines for switches.
+func switchesSource(list []Switch) string {
+	var buf strings.Builder
+	for _, s := range list {
+		if s.Value {
+			buf.WriteString("#define " + s.Name + "\n")
+		}
+		buf.WriteString("extern const _Bool " + s.cName() + ";\n")
+	}
+	return buf.String()
+}
+
+func switchNames(list []Switch) map[string]struct{} {
+	names := make(map[string]struct{}, len(list))
+	for _, s := range list {
+		names[s.Name] = struct{}{}
+	}
+	return names
+}
+
+// switchIdents returns renames of switch variables to Go constants.
+func switchIdents(list []Switch) []IdentConfig {
+	out := make([]IdentConfig, 0, len(list))
+	for _, s := range list {
+		out = append(out, IdentConfig{Name: s.cName(), Rename: s.GoName()})
+	}
+	return out
+}
+
+// SwitchDecls returns Go constant declarations for switches.
+func SwitchDecls(list []Switch) []GoDecl {
+	if len(list) == 0 {
+		return nil
+	}
+	d := &ast.GenDecl{Tok: token2.CONST}
+	for _, s := range list {
+		d.Specs = append(d.Specs, &ast.ValueSpec{
+			Names:  []*ast.Ident{ident(s.GoName())},
+			Values: []ast.Expr{boolLit(s.Value)},
+		})
+	}
+	if len(d.Specs) > 1 {
+		d.Lparen = 1
+	}
+	return []GoDecl{d}
+}
+
+// WriteSwitches writes Go constants for switches to cxgo_switches.go file in the output directory.
+// If Packages is set, constants are written to each of the packages.
+func WriteSwitches(out string, env *libs.Env, conf Config) error {
+	decls := SwitchDecls(conf.Switches)
+	if len(decls) == 0 {
+		return nil
+	}
+	const gofile = "cxgo_switches.go"
+	if p := conf.Packages; p != nil {
+		for _, dir := range p.dirs {
+			path := filepath.Join(filepath.FromSlash(dir), gofile)
+			if err := writeGoFiles(out, p.names[dir], path, "", decls, env, conf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	pkg := conf.Package
+	if pkg == "" {
+		pkg = "lib"
+	}
+	return writeGoFiles(out, pkg, gofile, "", decls, env, conf)
+}
+
+// switchFS rewrites conditionals of switches in user C files. See rewriteSwitches.
+//
+// CC uses the filesystem value as a part of the cache key, thus switches are kept as a string.
+// Rewritten files are cached in switchCache, since CC calls Stat for each include.
+type switchFS struct {
+	fs    cc.Filesystem
+	names string // space-separated
+}
+
+func newSwitchFS(fs cc.Filesystem, list []Switch) cc.Filesystem {
+	if len(list) == 0 {
+		return fs
+	}
+	names := make([]string, 0, len(list))
+	for _, s := range list {
+		names = append(names, s.Name)
+	}
+	return switchFS{fs: fs, names: strings.Join(names, " ")}
+}
+
+type switchKey struct {
+	names string
+	path  string
+	size  int64
+	mod   time.Time
+}
+
+type switchFile struct {
+	data []byte // nil if the file is not changed
+	err  error
+}
+
+var switchCache sync.Map // switchKey -> switchFile
+
+// content returns a rewritten file. It returns nil if the file is not changed.
+func (fs switchFS) content(path string, fi os.FileInfo) ([]byte, error) {
+	key := switchKey{names: fs.names, path: path, size: fi.Size(), mod: fi.ModTime()}
+	if v, ok := switchCache.Load(key); ok {
+		f := v.(switchFile)
+		return f.data, f.err
+	}
+	var f switchFile
+	r, err := fs.fs.Open(path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{})
+	for _, name := range strings.Fields(fs.names) {
+		names[name] = struct{}{}
+	}
+	if out, ok, err := rewriteSwitches(path, data, names); err != nil {
+		f.err = err
+	} else if ok {
+		f.data = out
+	}
+	switchCache.Store(key, f)
+	return f.data, f.err
+}
+
+type switchFI struct {
+	os.FileInfo
+	size int64
+}
+
+func (fi switchFI) Size() int64 {
+	return fi.size
+}
+
+func (fs switchFS) Stat(path string, sys bool) (os.FileInfo, error) {
+	fi, err := fs.fs.Stat(path, sys)
+	if err != nil || sys || fi.IsDir() {
+		return fi, err
+	}
+	// CC skips include paths that fail to stat, thus rewrite errors are only reported by Open
+	data, err := fs.content(path, fi)
+	if err != nil || data == nil {
+		return fi, nil
+	}
+	return switchFI{FileInfo: fi, size: int64(len(data))}, nil
+}
+
+func (fs switchFS) Open(path string, sys bool) (io.ReadCloser, error) {
+	if sys {
+		return fs.fs.Open(path, sys)
+	}
+	fi, err := fs.fs.Stat(path, sys)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.content(path, fi)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return fs.fs.Open(path, sys)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+var reSwitchIf = regexp.MustCompile(`^if\s+(!\s*)?defined\s*(?:\(\s*(\w+)\s*\)|(\w+))$`)
+
+// braceKind is a kind of C scope opened by a curly brace.
+type braceKind int
+
+const (
+	braceOther = braceKind(iota) // struct, enum, initializer
+	braceBlock                   // function body or a compound statement
+)
+
+// switchBrace is a C scope opened by a curly brace.
+type switchBrace struct {
+	kind   braceKind
+	labels int // number of case and default labels in this scope
+}
+
+// switchScan is a state of a simplified C scanner used by rewriteSwitches.
+type switchScan struct {
+	braces []switchBrace
+	last   string      // last significant token; identifiers and literals are replaced by "id" and "lit"
+	tokens int         // number of significant tokens scanned so far
+	next   *switchCond // conditional that must not be followed by else
+}
+
+func (s switchScan) clone() switchScan {
+	s.braces = append([]switchBrace{}, s.braces...)
+	return s
+}
+
+// reset restores the state of the scanner to s2, keeping the token counter.
+func (s *switchScan) reset(s2 switchScan) {
+	s2 = s2.clone()
+	s2.tokens = s.tokens
+	*s = s2
+}
+
+// same checks if both states are in the same scope, with the same number of case labels in each of them.
+func (s switchScan) same(s2 switchScan) bool {
+	if len(s.braces) != len(s2.braces) {
+		return false
+	}
+	for i := range s.braces {
+		if s.braces[i] != s2.braces[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// atStmt checks if the scanner is at the start of a statement in a function body.
+func (s switchScan) atStmt() bool {
+	if len(s.braces) == 0 || s.braces[len(s.braces)-1].kind != braceBlock {
+		return false
+	}
+	switch s.last {
+	case ";", "{", "}":
+		return true
+	}
+	return false
+}
+
+func (s *switchScan) token(tok string) {
+	if s.next != nil {
+		if tok == "else" {
+			// else would belong to a different if statement after the rewrite
+			s.next.name = ""
+		}
+		s.next = nil
+	}
+	s.tokens++
+	switch tok {
+	case "{":
+		kind := braceOther
+		if len(s.braces) == 0 {
+			if s.last == ")" {
+				kind = braceBlock
+			}
+		} else if s.braces[len(s.braces)-1].kind == braceBlock {
+			switch s.last {
+			case ")", ";", "{", "}", ":", "else", "do":
+				kind = braceBlock
+			}
+		}
+		s.braces = append(s.braces, switchBrace{kind: kind})
+	case "}":
+		if len(s.braces) != 0 {
+			s.braces = s.braces[:len(s.braces)-1]
+		}
+	case "case", "default":
+		if len(s.braces) != 0 {
+			s.braces[len(s.braces)-1].labels++
+		}
+	}
+	s.last = tok
+}
+
+// code scans a line of C code. It returns true if the line ends inside a block comment.
+func (s *switchScan) code(line string, comment bool) bool {
+	for i := 0; i < len(line); {
+		if comment {
+			j := strings.Index(line[i:], "*/")
+			if j < 0 {
+				return true
+			}
+			i += j + 2
+			comment = false
+			continue
+		}
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\\':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return false
+		case strings.HasPrefix(line[i:], "/*"):
+			comment = true
+			i += 2
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(line) && line[j] != c; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+			i = j + 1
+			s.token("lit")
+		case c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || c >= 0x80:
+			j := i
+			for j < len(line) && (line[j] == '_' || line[j] == '.' || unicode.IsLetter(rune(line[j])) || unicode.IsDigit(rune(line[j])) || line[j] >= 0x80) {
+				j++
+			}
+			switch w := line[i:j]; w {
+			case "else", "do", "case", "default":
+				s.token(w)
+			default:
+				if unicode.IsDigit(rune(c)) {
+					s.token("lit")
+				} else {
+					s.token("id")
+				}
+			}
+			i = j
+		default:
+			s.token(string(c))
+			i++
+		}
+	}
+	return false
+}
+
+// switchCond is a state of a conditional directive in rewriteSwitches.
+type switchCond struct {
+	name   string // switch name; empty if it's not a switch or it cannot be rewritten
+	global string // switch name for conditionals at file scope
+	not    bool
+	start  switchScan
+	first  *switchScan // scanner state at the end of the first branch
+	lines  []int       // lines of directives: #if, #else (optional) and #endif
+}
+
+// complete checks if the scanner is at the same statement level as at the start of the conditional,
+// and there are no case labels in between.
+func (c *switchCond) complete(s switchScan) bool {
+	return s.same(c.start) && s.atStmt()
+}
+
+// rewriteSwitches rewrites conditional directives for given switch macros into if statements.
+// It returns false if nothing was rewritten, and an error if a switch guards file-scope declarations.
+func rewriteSwitches(fname string, data []byte, names map[string]struct{}) ([]byte, bool, error) {
+	if !bytes.Contains(data, []byte("#")) {
+		return data, false, nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	var (
+		scan    switchScan
+		comment bool
+		stack   []*switchCond
+		done    []*switchCond
+	)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if comment || !strings.HasPrefix(trimmed, "#") {
+			comment = scan.code(line, comment)
+			continue
+		}
+		// join continuation lines of a directive
+		start := i
+		dir := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+		for strings.HasSuffix(dir, "\\") && i+1 < len(lines) {
+			i++
+			dir = strings.TrimSuffix(dir, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		if j := strings.Index(dir, "//"); j >= 0 {
+			dir = dir[:j]
+		}
+		if j := strings.Index(dir, "/*"); j >= 0 {
+			if k := strings.Index(dir[j:], "*/"); k >= 0 {
+				dir = dir[:j] + " " + dir[j+k+2:]
+			} else {
+				dir = dir[:j]
+				comment = true
+			}
+		}
+		dir = strings.TrimSpace(dir)
+		word := dir
+		if j := strings.IndexAny(dir, " \t(!"); j >= 0 {
+			word = dir[:j]
+		}
+		switch word {
+		case "if", "ifdef", "ifndef":
+			c := &switchCond{start: scan.clone(), lines: []int{start}}
+			stack = append(stack, c)
+			var (
+				name string
+				not  bool
+			)
+			switch word {
+			case "ifdef", "ifndef":
+				name = strings.TrimSpace(dir[len(word):])
+				not = word == "ifndef"
+			default:
+				if sub := reSwitchIf.FindStringSubmatch(dir); sub != nil {
+					name = sub[2] + sub[3]
+					not = sub[1] != ""
+				}
+			}
+			if _, ok := names[name]; !ok {
+				break
+			} else if len(scan.braces) == 0 {
+				c.global = name
+			} else if scan.atStmt() {
+				c.name, c.not = name, not
+				scan.next = c
+			}
+		case "elif":
+			if len(stack) == 0 {
+				continue
+			}
+			c := stack[len(stack)-1]
+			c.name = ""
+			if c.first == nil {
+				end := scan.clone()
+				c.first = &end
+			}
+			scan.reset(c.start)
+		case "else":
+			if len(stack) == 0 {
+				continue
+			}
+			c := stack[len(stack)-1]
+			if c.name != "" {
+				if !c.complete(scan) {
+					c.name = ""
+				}
+				c.lines = append(c.lines, start)
+				// both branches are compiled, thus the state carries over
+				scan.last = "{"
+				scan.next = c
+				continue
+			}
+			if c.first == nil {
+				end := scan.clone()
+				c.first = &end
+			}
+			scan.reset(c.start)
+		case "endif":
+			if len(stack) == 0 {
+				continue
+			}
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if c.global != "" && scan.tokens != c.start.tokens {
+				return nil, false, ErrorfWithPos(token.Position{Filename: fname, Line: c.lines[0] + 1, Column: 1},
+					"switch %s guards file-scope declarations; only statements in function bodies can be switched", c.global)
+			}
+			if c.name != "" {
+				if !c.complete(scan) {
+					continue
+				}
+				c.lines = append(c.lines, start)
+				done = append(done, c)
+				scan.last = "}"
+				scan.next = c
+				continue
+			}
+			if c.first != nil {
+				scan.reset(*c.first)
+			}
+		}
+	}
+	repl := make(map[int]string)
+	for _, c := range done {
+		if c.name == "" {
+			continue
+		}
+		cond := switchPrefix + c.name
+		if c.not {
+			cond = "!" + cond
+		}
+		repl[c.lines[0]] = "if (" + cond + ") {"
+		if len(c.lines) == 3 {
+			repl[c.lines[1]] = "} else {"
+		}
+		repl[c.lines[len(c.lines)-1]] = "}"
+	}
+	if len(repl) == 0 {
+		return data, false, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	for i := 0; i < len(lines); i++ {
+		s, ok := repl[i]
+		if !ok {
+			buf.WriteString(lines[i])
+			continue
+		}
+		buf.WriteString(s)
+		// keep the line count of multi-line directives
+		for ; ; i++ {
+			if strings.HasSuffix(lines[i], "\n") {
+				buf.WriteByte('\n')
+			}
+			if !strings.HasSuffix(strings.TrimSpace(lines[i]), "\\") || i+1 >= len(lines) {
+				break
+			}
+		}
+	}
+	return buf.Bytes(), true, nil
+}
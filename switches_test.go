@@ -0,0 +1,324 @@
+package cxgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotranspile/cxgo/libs"
+	"github.com/gotranspile/cxgo/types"
+)
+
+var casesRewriteSwitches = []struct {
+	name string
+	src  string
+	exp  string
+	err  string
+}{
+	{
+		name: "ifdef else",
+		src: `
+void f() {
+	int x = 0;
+#ifdef SW
+	x++;
+#else
+	x--;
+#endif
+}
+`,
+		exp: `
+void f() {
+	int x = 0;
+if (_cxgo_switch_SW) {
+	x++;
+} else {
+	x--;
+}
+}
+`,
+	},
+	{
+		name: "not defined",
+		src: `
+void f(int x) {
+	if (x) {
+#  if !defined(SW) // comment
+		x++;
+#  endif
+	}
+}
+`,
+		exp: `
+void f(int x) {
+	if (x) {
+if (!_cxgo_switch_SW) {
+		x++;
+}
+	}
+}
+`,
+	},
+	{
+		name: "nested",
+		src: `
+void f(int x) {
+#ifndef SW
+#ifdef OTHER
+	x++;
+#endif
+#if defined SW2
+	x--;
+#endif
+#endif
+}
+`,
+		exp: `
+void f(int x) {
+if (!_cxgo_switch_SW) {
+#ifdef OTHER
+	x++;
+#endif
+if (_cxgo_switch_SW2) {
+	x--;
+}
+}
+}
+`,
+	},
+	{
+		name: "file scope",
+		src: `
+#ifdef SW
+#define X 1
+#endif
+struct S {
+#ifdef SW
+	int y;
+#endif
+};
+`,
+	},
+	{
+		name: "file scope decl",
+		src: `
+int x;
+#ifndef SW
+int y;
+#endif
+`,
+		err: "main.c:3:1: switch SW guards file-scope declarations; only statements in function bodies can be switched",
+	},
+	{
+		name: "dangling else",
+		src: `
+void f(int x) {
+	if (x) x++;
+#ifdef SW
+	else x--;
+#endif
+	if (x) x++;
+#ifdef SW
+	x++;
+#else
+	else x--;
+#endif
+#ifdef SW
+	if (x) x++;
+#endif
+	else x--;
+}
+`,
+	},
+	{
+		name: "case labels",
+		src: `
+void f(int x) {
+	switch (x) {
+	case 1:
+		x++;
+#ifdef SW
+	case 2:
+		x--;
+#endif
+	default:
+		x--;
+#ifndef SW
+		switch (x) {
+		case 3:
+			x++;
+		}
+#endif
+	}
+}
+`,
+		exp: `
+void f(int x) {
+	switch (x) {
+	case 1:
+		x++;
+#ifdef SW
+	case 2:
+		x--;
+#endif
+	default:
+		x--;
+if (!_cxgo_switch_SW) {
+		switch (x) {
+		case 3:
+			x++;
+		}
+}
+	}
+}
+`,
+	},
+	{
+		name: "initializer",
+		src: `
+void f() {
+	int x[] = {
+#ifdef SW
+	1,
+#endif
+	2};
+}
+`,
+	},
+	{
+		name: "partial statement",
+		src: `
+void f(int x) {
+	if (x)
+#ifdef SW
+		x++;
+#else
+		x--;
+#endif
+#ifdef SW
+	if (x) {
+#endif
+		x++;
+#ifdef SW
+	}
+#endif
+}
+`,
+	},
+	{
+		name: "elif",
+		src: `
+void f(int x) {
+#ifdef SW
+	x++;
+#elif defined(OTHER)
+	x--;
+#endif
+}
+`,
+	},
+}
+
+func TestRewriteSwitches(t *testing.T) {
+	names := map[string]struct{}{"SW": {}, "SW2": {}}
+	for _, c := range casesRewriteSwitches {
+		t.Run(c.name, func(t *testing.T) {
+			out, ok, err := rewriteSwitches("main.c", []byte(c.src), names)
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			if c.exp == "" {
+				require.False(t, ok)
+				require.Equal(t, c.src, string(out))
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, c.exp, string(out))
+		})
+	}
+}
+
+func TestSwitches(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c")
+	out := filepath.Join(dir, "go")
+	writeTestFiles(t, src, map[string]string{
+		"log.c": `
+#include <stdio.h>
+void log_msg(int v);
+
+int work(int x) {
+	int y = 0;
+#ifdef ENABLE_LOGGING
+	log_msg(x);
+#else
+	y++;
+#endif
+	return x + y;
+}
+`,
+	})
+	conf := Config{
+		Package:  "lib",
+		MaxDecls: -1,
+		Switches: []Switch{{Name: "ENABLE_LOGGING", Value: true}, {Name: "fast_path", Go: "Fast"}},
+	}
+	env := libs.NewEnv(types.Config32())
+	err := Translate(src, filepath.Join(src, "log.c"), out, env, conf)
+	require.NoError(t, err)
+	err = WriteSwitches(out, env, conf)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(out, "log.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+func work(x int32) int32 {
+	var y int32 = 0
+	if EnableLogging {
+		log_msg(x)
+	} else {
+		y++
+	}
+	return x + y
+}
+`, string(data))
+
+	data, err = os.ReadFile(filepath.Join(out, "cxgo_switches.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package lib
+
+const (
+	EnableLogging = true
+	Fast          = false
+)
+`, string(data))
+	require.Equal(t, "UseFastPath", Switch{Name: "USE_FAST_PATH"}.GoName())
+	require.Equal(t, "X2d", Switch{Name: "_2D"}.GoName())
+}
+
+func TestSwitchesFileScope(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"log.h": `
+#ifdef ENABLE_LOGGING
+void log_msg(int v);
+#endif
+`,
+		"log.c": `
+#include "log.h"
+`,
+	})
+	conf := Config{
+		Package:  "lib",
+		MaxDecls: -1,
+		Switches: []Switch{{Name: "ENABLE_LOGGING", Value: true}},
+	}
+	env := libs.NewEnv(types.Config32())
+	err := Translate(dir, filepath.Join(dir, "log.c"), filepath.Join(dir, "go"), env, conf)
+	require.ErrorContains(t, err, "log.h:1:1: switch ENABLE_LOGGING guards file-scope declarations")
+}
@@ -0,0 +1,165 @@
+package cxgo
+
+import (
+	"testing"
+
+	"github.com/gotranspile/cxgo/types"
+)
+
+func withTarget(name string) envFunc {
+	return func(c *types.Config) {
+		conf, err := types.ConfigForTarget(name)
+		if err != nil {
+			panic(err)
+		}
+		*c = conf
+	}
+}
+
+var casesTranslateTargets = []parseCase{
+	{
+		name: "target arm",
+		src: `
+#include <limits.h>
+
+struct S {
+	char c;
+	long l;
+	long long ll;
+};
+
+char c = CHAR_MAX;
+#if __BYTE_ORDER__ == __ORDER_BIG_ENDIAN__
+int be = 1;
+#else
+int be = 0;
+#endif
+`,
+		exp: `
+type S struct {
+	C  uint8
+	L  int32
+	Ll int64
+}
+
+var c uint8 = math.MaxUint8
+var be int32 = 0
+`,
+		envFuncs: []envFunc{withTarget("arm")},
+	},
+	{
+		name: "target ppc64",
+		src: `
+#include <limits.h>
+
+struct S {
+	char c;
+	long l;
+	int i;
+};
+
+long lmax = LONG_MAX;
+#if __BYTE_ORDER__ == __ORDER_BIG_ENDIAN__
+int be = 1;
+#else
+int be = 0;
+#endif
+`,
+		exp: `
+type S struct {
+	C uint8
+	L int64
+	I int32
+}
+
+var lmax int64 = math.MaxInt64
+var be int32 = 1
+`,
+		envFuncs: []envFunc{withTarget("ppc64")},
+	},
+	{
+		name: "target i386",
+		src: `
+#include <stddef.h>
+
+struct S {
+	char c;
+	long long ll;
+};
+
+char buf[sizeof(struct S)];
+`,
+		exp: `
+type S struct {
+	C  int8
+	Ll int64
+}
+
+var buf [12]byte
+`,
+		envFuncs: []envFunc{withTarget("i386")},
+	},
+	{
+		name: "target ppc64 bitfields",
+		src: `
+struct S {
+	unsigned int a:4;
+	unsigned int b:4;
+};
+
+unsigned int foo(struct S* s) {
+	return s->a;
+}
+`,
+		exp: `
+type S struct {
+	bitfield0 uint32
+}
+
+func (s S) A() uint32 {
+	return uint32(s.bitfield0 >> 28 & 0xF)
+}
+func (s *S) SetA(v uint32) {
+	s.bitfield0 = s.bitfield0&^0xF0000000 | uint32(v)<<28&0xF0000000
+}
+func (s S) B() uint32 {
+	return uint32(s.bitfield0 >> 24 & 0xF)
+}
+func (s *S) SetB(v uint32) {
+	s.bitfield0 = s.bitfield0&^0xF000000 | uint32(v)<<24&0xF000000
+}
+func foo(s *S) uint32 {
+	return s.A()
+}
+`,
+		envFuncs: []envFunc{withTarget("ppc64")},
+	},
+	{
+		name: "float alignment",
+		src: `
+#include <stddef.h>
+
+struct S {
+	char c;
+	float f;
+};
+
+char buf[sizeof(struct S)];
+`,
+		exp: `
+type S struct {
+	C int8
+	F float32
+}
+
+var buf [8]byte
+`,
+		envFuncs: []envFunc{func(c *types.Config) {
+			*c = types.Config64()
+		}},
+	},
+}
+
+func TestTranslateTargets(t *testing.T) {
+	runTestTranslate(t, casesTranslateTargets)
+}
@@ -2,6 +2,7 @@ package cxgo
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/format"
 	"os"
@@ -27,6 +28,7 @@ type Config struct {
 	MaxDecls           int
 	Predef             string
 	Define             []Define
+	Switches           []Switch // macros that are kept as Go constants, see Switch
 	FlattenAll         bool
 	ForwardDecl        bool
 	SkipDecl           map[string]bool
@@ -39,6 +41,29 @@ type Config struct {
 	IntReformat        bool // automatically select new base for formatting int literals
 	KeepFree           bool // do not rewrite free() calls to nil assignments
 	DoNotEdit          bool // generate DO NOT EDIT header comments
+
+	// Packages maps C directories to separate Go packages. Package is ignored if it's set.
+	Packages *Packages
+	// Structs merges public and private definitions of the same struct from different files.
+	Structs *Structs
+	// Slices converts pointers to Go slices, if they are inferred to be used as arrays.
+	Slices *Slices
+	// Statics renames file-scope static symbols that collide with symbols from other files.
+	Statics *Statics
+	// MacroFuncs translates function-like macros to generic Go functions and records the ones that were skipped.
+	MacroFuncs *MacroFuncs
+}
+
+func (c Config) sourceConfig() SourceConfig {
+	return SourceConfig{
+		Predef:           c.Predef,
+		Define:           c.Define,
+		Switches:         c.Switches,
+		Include:          c.Include,
+		SysInclude:       c.SysInclude,
+		IgnoreIncludeDir: c.IgnoreIncludeDir,
+		MacroFuncs:       c.MacroFuncs,
+	}
 }
 
 type TypeHint string
@@ -95,44 +120,87 @@ func ErrorfWithPos(where token.Position, format string, args ...any) error {
 }
 
 func Translate(root, fname, out string, env *libs.Env, conf Config) error {
-	cname := fname
-	tu, err := Parse(env, root, cname, SourceConfig{
-		Predef:           conf.Predef,
-		Define:           conf.Define,
-		Include:          conf.Include,
-		SysInclude:       conf.SysInclude,
-		IgnoreIncludeDir: conf.IgnoreIncludeDir,
-	})
+	pkg, gofile, decls, err := translateFile(root, fname, env, conf)
+	if err != nil {
+		return err
+	}
+	return writeGoFiles(out, pkg, gofile, "", decls, env, conf)
+}
+
+// IndexFile parses a C file and records it in all project-wide indexes set in the config:
+// Packages, Structs, Slices and Statics. The file is parsed only once for all of them.
+//
+// All C files must be indexed before any of them is translated.
+func IndexFile(fname string, env *libs.Env, conf Config) error {
+	tu, err := Parse(env, conf.Root, fname, conf.sourceConfig())
 	if err != nil {
 		return fmt.Errorf("parsing failed: %w", err)
 	}
-	decls, err := TranslateAST(cname, tu, env, conf)
+	if p := conf.Packages; p != nil {
+		p.Index(fname, tu)
+	}
+	if s := conf.Structs; s != nil {
+		s.Index(tu)
+	}
+	if s := conf.Slices; s != nil {
+		s.Index(tu)
+	}
+	if s := conf.Statics; s != nil {
+		s.split = conf.Packages != nil
+		s.Index(fname, tu)
+	}
+	return nil
+}
+
+// translateFile parses and translates a single C file. It returns Go package name, relative Go file path and declarations.
+func translateFile(root, fname string, env *libs.Env, conf Config) (string, string, []GoDecl, error) {
+	cname := fname
+	tu, err := Parse(env, root, cname, conf.sourceConfig())
 	if err != nil {
-		return err
+		return "", "", nil, fmt.Errorf("parsing failed: %w", err)
 	}
 	pkg := conf.Package
 	if pkg == "" {
 		pkg = "lib"
 	}
-	_ = os.MkdirAll(out, 0755)
-	bbuf := bytes.NewBuffer(nil)
+	if p := conf.Packages; p != nil {
+		conf.Idents = p.idents(fname, conf.Idents)
+		pkg = p.PackageName(fname)
+		p.addImports(env)
+	}
+	if s := conf.Statics; s != nil {
+		conf.Idents = s.idents(fname, conf.Idents)
+	}
+	decls, err := TranslateAST(cname, tu, env, conf)
+	if err != nil {
+		return "", "", nil, err
+	}
 	gofile := conf.GoFile
 	if gofile == "" {
 		gofile, err = filepath.Rel(root, fname)
 		if err != nil {
-			return err
+			return "", "", nil, err
 		}
 		if conf.GoFilePref != "" {
 			dir, base := filepath.Split(gofile)
 			gofile = dir + conf.GoFilePref + base
 		}
-		// flatten C source file path to make a single large Go package
-		// TODO: auto-generate Go packages based on dir structure
-		gofile = strings.ReplaceAll(gofile, string(filepath.Separator), "_")
+		if conf.Packages == nil {
+			// flatten C source file path to make a single large Go package
+			gofile = strings.ReplaceAll(gofile, string(filepath.Separator), "_")
+		}
 		gofile = strings.TrimSuffix(gofile, ".c")
 		gofile = strings.TrimSuffix(gofile, ".h")
 		gofile += ".go"
 	}
+	return pkg, gofile, decls, nil
+}
+
+// writeGoFiles prints declarations to one or more Go files, according to the config.
+// If build is set, it is added as a build constraint to each file.
+func writeGoFiles(out, pkg, gofile, build string, decls []GoDecl, env *libs.Env, conf Config) error {
+	_ = os.MkdirAll(out, 0755)
+	bbuf := bytes.NewBuffer(nil)
 	max := conf.MaxDecls
 	if max == 0 {
 		max = 100
@@ -152,7 +220,10 @@ func Translate(root, fname, out string, env *libs.Env, conf Config) error {
 		buf = append(buf, cur...)
 
 		bbuf.Reset()
-		err = PrintGo(bbuf, pkg, buf, conf.DoNotEdit)
+		if build != "" {
+			bbuf.WriteString("//go:build " + build + "\n\n")
+		}
+		err := PrintGo(bbuf, pkg, buf, conf.DoNotEdit)
 		if err != nil {
 			return err
 		}
@@ -164,6 +235,9 @@ func Translate(root, fname, out string, env *libs.Env, conf Config) error {
 		if !filepath.IsAbs(gopath) {
 			gopath = filepath.Join(out, gopath)
 		}
+		if conf.Packages != nil {
+			_ = os.MkdirAll(filepath.Dir(gopath), 0755)
+		}
 
 		fdata := bbuf.Bytes()
 		// run replacements defined in the config
@@ -192,13 +266,61 @@ func Translate(root, fname, out string, env *libs.Env, conf Config) error {
 // TranslateAST takes a C translation unit and converts it to a list of Go declarations.
 func TranslateAST(fname string, tu *cc.AST, env *libs.Env, conf Config) ([]GoDecl, error) {
 	t := newTranslator(env, conf)
-	return t.translate(fname, tu), nil
+	decls := t.translate(fname, tu)
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	return decls, nil
 }
 
 // TranslateCAST takes a C translation unit and converts it to a list of cxgo declarations.
 func TranslateCAST(fname string, tu *cc.AST, env *libs.Env, conf Config) ([]CDecl, error) {
 	t := newTranslator(env, conf)
-	return t.translateC(fname, tu), nil
+	decls := t.translateC(fname, tu)
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	return decls, nil
+}
+
+// FuncFlow contains control flow graphs of a single C function.
+type FuncFlow struct {
+	Name string
+	// Flow is the control flow of the function before flattening.
+	Flow *ControlFlow
+	// Flat is the control flow of the function after flattening, see IdentConfig.Flatten.
+	Flat *ControlFlow
+	// Structured is set if the control flow was rebuilt with loops and branches.
+	// Otherwise, the function was flattened to a sequence of numbered labels.
+	Structured bool
+}
+
+// TranslateFlow translates a C file and builds control flow graphs for a given function.
+func TranslateFlow(root, fname, fnc string, env *libs.Env, conf Config) (*FuncFlow, error) {
+	tu, err := Parse(env, root, fname, conf.sourceConfig())
+	if err != nil {
+		return nil, fmt.Errorf("parsing failed: %w", err)
+	}
+	g := newTranslator(env, conf)
+	decls := g.translatePreFlow(fname, tu)
+	if err := g.err(); err != nil {
+		return nil, err
+	}
+	for _, d := range decls {
+		f, ok := d.(*CFuncDecl)
+		if !ok || f.Body == nil || f.Name.Name != fnc {
+			continue
+		}
+		ff := &FuncFlow{Name: fnc, Flow: g.NewControlFlow(f.Body.Stmts)}
+		var stmts []CStmt
+		stmts, ff.Structured = ff.Flow.Structure()
+		if !ff.Structured {
+			stmts = ff.Flow.Flatten()
+		}
+		ff.Flat = g.NewControlFlow(stmts)
+		return ff, nil
+	}
+	return nil, fmt.Errorf("function %q is not defined in %s", fnc, fname)
 }
 
 func newTranslator(env *libs.Env, conf Config) *translator {
@@ -213,8 +335,24 @@ func newTranslator(env *libs.Env, conf Config) *translator {
 		named:     make(map[string]types.Named),
 		aliases:   make(map[string]types.Type),
 		macros:    make(map[string]*types.Ident),
+		macroCur:  make(map[token.Position]bool),
+		partial:   make(map[string]bool),
+
+		staticLocals: make(map[string]struct{}),
 	}
-	for _, v := range conf.Idents {
+	// placeholder type of function-like macro arguments, see macroFuncSource
+	tr.idents[macroTypeName] = IdentConfig{Name: macroTypeName, Rename: macroTypeParam}
+	for _, v := range switchIdents(conf.Switches) {
+		tr.idents[v.Name] = v
+	}
+	idents := conf.Idents
+	if conf.Structs != nil {
+		idents = conf.Structs.idents(idents)
+	}
+	if conf.Slices != nil {
+		idents = conf.Slices.idents(idents)
+	}
+	for _, v := range idents {
 		tr.idents[v.Name] = v
 	}
 	_, _ = tr.tenv.GetLibrary(libs.BuiltinH)
@@ -230,6 +368,7 @@ type translator struct {
 
 	file *cc.AST
 	cur  string
+	path string // path of the current file, as passed by the caller
 
 	idents    map[string]IdentConfig
 	ctypes    map[cc.Type]types.Type
@@ -237,7 +376,37 @@ type translator struct {
 	named     map[string]types.Named
 	aliases   map[string]types.Type
 	macros    map[string]*types.Ident
+	macroCur  map[token.Position]bool // macro sites that are being converted, see convMacroExpr
+	partial   map[string]bool         // public definitions of merged structs, see Structs
 	decls     map[cc.Node]*types.Ident
+
+	curFunc      *types.Ident        // function that is being converted
+	hoisted      []CDecl             // static locals of the current function, emitted before it
+	staticLocals map[string]struct{} // Go names of hoisted static locals
+
+	fieldDecls map[*cc.StructDeclarator]*cc.StructDeclaration // lazily populated, see fieldDecl
+	macroToks  map[token.Position][]*cc.Token                 // lazily populated, see macroSites
+	macroExps  map[string]string                              // lazily populated, see macroExpansions
+	arrayLens  map[cc.Type]*cc.AssignmentExpression           // lazily populated, see arrayLenMacro
+
+	errs []error // unsupported constructs; translation continues to report all of them, see addError
+}
+
+// addError records an error for a construct that cannot be translated. Unlike panics, which are reserved for
+// internal errors, these errors are returned to the caller after the translation completes.
+// Types may be converted more than once, thus the same error is only recorded once.
+func (g *translator) addError(err error) {
+	for _, e := range g.errs {
+		if e.Error() == err.Error() {
+			return
+		}
+	}
+	g.errs = append(g.errs, err)
+}
+
+// err returns all errors recorded with addError.
+func (g *translator) err() error {
+	return errors.Join(g.errs...)
 }
 
 func (g *translator) Nil() Nil {
@@ -295,7 +464,8 @@ func (g *translator) translateMain(d *CFuncDecl) {
 	d.Type = g.env.FuncT(nil, d.Type.Args()...)
 }
 
-func (g *translator) translate(cur string, ast *cc.AST) []GoDecl {
+// translatePreFlow converts the translation unit and runs all passes that must happen before the control flow is rebuilt.
+func (g *translator) translatePreFlow(cur string, ast *cc.AST) []CDecl {
 	decl := g.translateC(cur, ast)
 	g.rewriteStatements(decl)
 	if g.conf.FixImplicitReturns {
@@ -305,10 +475,17 @@ func (g *translator) translate(cur string, ast *cc.AST) []GoDecl {
 	decl = g.adaptMain(decl)
 	// run plugin hooks
 	decl = g.runASTPluginsC(cur, ast, decl)
+	return decl
+}
+
+func (g *translator) translate(cur string, ast *cc.AST) []GoDecl {
+	decl := g.translatePreFlow(cur, ast)
 	// flatten functions, if needed
 	g.flatten(decl)
 	// fix unused variables
 	g.fixUnusedVars(decl)
+	// move code following setjmp to closures
+	g.rewriteSetJumps(decl)
 	// convert to Go AST
 	var gdecl []GoDecl
 	for _, d := range decl {
@@ -333,9 +510,17 @@ func (g *translator) translate(cur string, ast *cc.AST) []GoDecl {
 }
 
 func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
-	g.file, g.cur = ast, strings.TrimLeft(cur, "./")
+	g.file, g.cur, g.path = ast, strings.TrimLeft(cur, "./"), cur
+	g.fieldDecls = nil
+	g.macroToks, g.macroExps = nil, nil
+	g.arrayLens = nil
 
 	decl := g.convertMacros(ast)
+	// functions generated from macros are parsed last, but we emit them right after other macros
+	var (
+		nmacros    = len(decl)
+		macroFuncs []CDecl
+	)
 
 	tu := ast.TranslationUnit
 	for tu != nil {
@@ -347,7 +532,15 @@ func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
 		var cd []CDecl
 		switch d.Case {
 		case cc.ExternalDeclarationFuncDef:
+			if strings.HasPrefix(d.FunctionDefinition.Declarator.Name().String(), macroFuncPrefix) {
+				macroFuncs = append(macroFuncs, g.convertFuncDef(d.FunctionDefinition)...)
+				continue
+			}
 			cd = g.convertFuncDef(d.FunctionDefinition)
+			if len(g.hoisted) != 0 {
+				cd = append(g.hoisted, cd...)
+				g.hoisted = nil
+			}
 		case cc.ExternalDeclarationDecl:
 			cd = g.convertDecl(d.Declaration)
 		case cc.ExternalDeclarationEmpty:
@@ -357,6 +550,9 @@ func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
 		}
 		decl = append(decl, cd...)
 	}
+	if len(macroFuncs) != 0 {
+		decl = append(decl[:nmacros:nmacros], append(macroFuncs, decl[nmacros:]...)...)
+	}
 	// remove forward declarations
 	m := make(map[string]CDecl)
 	skip := make(map[CDecl]struct{})
@@ -375,6 +571,11 @@ func (g *translator) translateC(cur string, ast *cc.AST) []CDecl {
 				skip[d2] = struct{}{}
 			}
 		case *CTypeDef:
+			if g.partial[d.Name().Name] {
+				// public definition of a merged struct; the private one is emitted instead
+				skip[d] = struct{}{}
+				continue
+			}
 			d2, ok := m[d.Name().Name].(*CTypeDef)
 			if !ok {
 				m[d.Name().Name] = d
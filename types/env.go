@@ -29,11 +29,15 @@ func Config64() Config {
 
 // Config stores configuration for base types.
 type Config struct {
-	PtrSize     int  // size of pointers in bytes
-	IntSize     int  // default int size in bytes
-	WCharSize   int  // wchar_t size
-	WCharSigned bool // is wchar_t signed?
-	UseGoInt    bool // use Go int for C int and long
+	PtrSize      int  // size of pointers in bytes
+	IntSize      int  // default int size in bytes
+	LongSize     int  // long size in bytes; defaults to PtrSize
+	Align64      int  // alignment of 64 bit types (long long, double) in structs; defaults to IntSize
+	WCharSize    int  // wchar_t size
+	WCharSigned  bool // is wchar_t signed?
+	UnsignedChar bool // is char unsigned?
+	BigEndian    bool // use big-endian byte order
+	UseGoInt     bool // use Go int for C int and long
 }
 
 func (c *Config) setDefaults() {
@@ -60,6 +64,12 @@ func (c *Config) setDefaults() {
 			c.IntSize = int(unsafe.Sizeof(int(0)))
 		}
 	}
+	if c.LongSize == 0 {
+		c.LongSize = c.PtrSize
+	}
+	if c.Align64 == 0 {
+		c.Align64 = c.IntSize
+	}
 }
 
 func NewEnv(c Config) *Env {
@@ -104,6 +114,11 @@ type Env struct {
 	wstringC2Go *Ident
 }
 
+// Config returns the config of the environment.
+func (e *Env) Config() Config {
+	return e.conf
+}
+
 // PtrSize returns size of the pointer.
 func (e *Env) PtrSize() int {
 	return e.conf.PtrSize
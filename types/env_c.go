@@ -14,6 +14,7 @@ type C struct {
 	mallocF  *Ident
 	freeF    *Ident
 	callocF  *Ident
+	reallocF *Ident
 	memmoveF *Ident
 	memcpyF  *Ident
 	memsetF  *Ident
@@ -36,7 +37,11 @@ func (c *C) init() {
 	g := c.e.Go()
 
 	c.pkg.NewAlias("bool", "", c.Bool())
-	c.charT = c.pkg.NewAlias("char", "", IntT(1)) // signed
+	if c.e.conf.UnsignedChar {
+		c.charT = c.pkg.NewAlias("char", "", UintT(1))
+	} else {
+		c.charT = c.pkg.NewAlias("char", "", IntT(1))
+	}
 	if c.e.conf.WCharSize == 2 && !c.e.conf.WCharSigned {
 		c.wcharT = c.pkg.NewTypeGo("wchar_t", "libc.WChar", UintT(2))
 	} else if c.e.conf.WCharSigned {
@@ -68,6 +73,7 @@ func (c *C) init() {
 	c.mallocF = NewIdentGo("__builtin_malloc", "libc.Malloc", c.e.FuncTT(unsafePtr, g.Int()))
 	c.freeF = NewIdentGo("free", "libc.Free", c.e.FuncTT(nil, unsafePtr))
 	c.callocF = NewIdentGo("calloc", "libc.Calloc", c.e.FuncTT(unsafePtr, g.Int(), g.Int()))
+	c.reallocF = NewIdentGo("realloc", "libc.Realloc", c.e.FuncTT(unsafePtr, unsafePtr, g.Int()))
 	c.memmoveF = NewIdentGo("__builtin_memmove", "libc.MemMove", c.e.FuncTT(unsafePtr, unsafePtr, unsafePtr, g.Int()))
 	c.memcpyF = NewIdentGo("__builtin_memcpy", "libc.MemCpy", c.e.FuncTT(unsafePtr, unsafePtr, unsafePtr, g.Int()))
 	c.memsetF = NewIdentGo("__builtin_memset", "libc.MemSet", c.e.FuncTT(unsafePtr, unsafePtr, g.Byte(), g.Int()))
@@ -168,12 +174,18 @@ func (c *C) UnsignedInt() Type {
 
 // Long returns C long type.
 func (c *C) Long() Type {
-	return c.Int()
+	if c.e.conf.UseGoInt || c.e.conf.LongSize == c.e.conf.IntSize {
+		return c.Int()
+	}
+	return IntT(c.e.conf.LongSize)
 }
 
 // UnsignedLong returns C unsigned long type.
 func (c *C) UnsignedLong() Type {
-	return c.UnsignedInt()
+	if c.e.conf.UseGoInt || c.e.conf.LongSize == c.e.conf.IntSize {
+		return c.UnsignedInt()
+	}
+	return UintT(c.e.conf.LongSize)
 }
 
 // LongLong returns C long long type.
@@ -232,6 +244,11 @@ func (c *C) CallocFunc() *Ident {
 	return c.callocF
 }
 
+// ReallocFunc returns C realloc function ident.
+func (c *C) ReallocFunc() *Ident {
+	return c.reallocF
+}
+
 // MemmoveFunc returns C memmove function ident.
 func (c *C) MemmoveFunc() *Ident {
 	return c.memmoveF
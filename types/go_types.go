@@ -43,6 +43,18 @@ func (e *Ident) GoIdent() *ast.Ident {
 	return ident(e.GoName)
 }
 
+// GoDoc returns a doc comment for the declaration of this identifier, if any.
+func (e *Ident) GoDoc() *ast.CommentGroup {
+	if len(e.Doc) == 0 {
+		return nil
+	}
+	g := &ast.CommentGroup{}
+	for _, line := range e.Doc {
+		g.List = append(g.List, &ast.Comment{Text: line})
+	}
+	return g
+}
+
 func (t *unkType) GoType() GoType {
 	if t.isStruct {
 		return ident("struct{}") // TODO
@@ -82,7 +94,9 @@ func (t namedPtr) GoType() GoType {
 
 func (t ArrayType) GoType() GoType {
 	var sz ast.Expr
-	if !t.slice {
+	if t.length != nil && !t.slice {
+		sz = t.length.GoIdent()
+	} else if !t.slice {
 		sz = &ast.BasicLit{
 			Kind:  token.INT,
 			Value: strconv.Itoa(t.size),
@@ -99,19 +113,46 @@ func (t *namedType) GoType() GoType {
 }
 
 func (f *Field) GoField() *ast.Field {
-	var names []*ast.Ident
+	var (
+		names []*ast.Ident
+		doc   *ast.CommentGroup
+	)
 	if f.Name != nil && !f.Name.IsUnnamed() {
 		names = append(names, f.Name.GoIdent())
+		doc = f.Name.GoDoc()
 	}
-	return &ast.Field{Names: names, Type: f.Type().GoType()}
+	return &ast.Field{Doc: doc, Names: names, Type: f.Type().GoType()}
 }
 
+// UnionStorageField is the name of the field that holds the storage of all union members.
+const UnionStorageField = "data"
+
 func (t *StructType) GoType() GoType {
 	fields := &ast.FieldList{}
 	if t.union {
-		fields.List = append(fields.List, &ast.Field{Type: ident("// union")})
+		elem, n, pad := t.unionStorage()
+		f := &ast.Field{
+			Names: []*ast.Ident{ident(UnionStorageField)},
+			Type:  &ast.ArrayType{Len: intLit(n), Elt: elem},
+		}
+		if HasPointers(t) && !PointersOnly(t) {
+			f.Doc = &ast.CommentGroup{List: []*ast.Comment{{
+				Text: "// TODO: pointers share storage with other data; values of other members are visible to GC as pointers",
+			}}}
+		}
+		fields.List = append(fields.List, f)
+		if pad != 0 {
+			fields.List = append(fields.List, &ast.Field{
+				Names: []*ast.Ident{ident("_")},
+				Type:  &ast.ArrayType{Len: intLit(pad), Elt: ident("byte")},
+			})
+		}
+		return &ast.StructType{Fields: fields}
 	}
 	for _, f := range t.fields {
+		if f.BitField != nil {
+			continue // stored in a separate field
+		}
 		fields.List = append(fields.List, f.GoField())
 	}
 	return &ast.StructType{Fields: fields}
@@ -152,3 +193,29 @@ func (t *FuncType) GoFuncType() *ast.FuncType {
 	}
 	return f
 }
+
+// unionStorage returns an element type and a length of a Go array that can hold any of union members,
+// as well as the number of padding bytes after it.
+//
+// Members are accessed by casting the pointer to the storage, thus the element is picked to match the alignment
+// of the union, up to the largest integer word. Unions with pointers use unsafe.Pointer to keep them visible
+// to the GC. If pointers share storage with other data, the GC may observe an integer in a pointer slot,
+// thus the translated type is marked with a comment.
+func (t *StructType) unionStorage() (GoType, int, int) {
+	word := t.Alignof()
+	if word > 8 {
+		word = 8
+	}
+	var elem GoType
+	if HasPointers(t) {
+		elem = ident("unsafe.Pointer")
+	} else {
+		elem = UintT(word).GoType()
+	}
+	size := t.Sizeof()
+	return elem, size / word, size % word
+}
+
+func intLit(v int) *ast.BasicLit {
+	return &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(v)}
+}
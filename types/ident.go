@@ -60,6 +60,7 @@ type Ident struct {
 	typ    Type
 	Name   string
 	GoName string
+	Doc    []string // doc comment lines for the declaration, in Go syntax
 }
 
 func (e *Ident) IsUnnamed() bool {
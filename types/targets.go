@@ -0,0 +1,77 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// targets is a list of ABI presets for well-known platforms. All of them assume Linux/GCC conventions,
+// except for wchar_t, which is always 16 bit, as expected by the runtime.
+var targets = map[string]Config{
+	"i386": {
+		PtrSize: 4, IntSize: 4, LongSize: 4, Align64: 4,
+	},
+	"amd64": {
+		PtrSize: 8, IntSize: 4, LongSize: 8, Align64: 8,
+	},
+	"arm": {
+		PtrSize: 4, IntSize: 4, LongSize: 4, Align64: 8,
+		UnsignedChar: true,
+	},
+	"aarch64": {
+		PtrSize: 8, IntSize: 4, LongSize: 8, Align64: 8,
+		UnsignedChar: true,
+	},
+	"ppc64": {
+		PtrSize: 8, IntSize: 4, LongSize: 8, Align64: 8,
+		UnsignedChar: true, BigEndian: true,
+	},
+	"ppc64le": {
+		PtrSize: 8, IntSize: 4, LongSize: 8, Align64: 8,
+		UnsignedChar: true,
+	},
+	"mips": {
+		PtrSize: 4, IntSize: 4, LongSize: 4, Align64: 8,
+		BigEndian: true,
+	},
+	"mipsle": {
+		PtrSize: 4, IntSize: 4, LongSize: 4, Align64: 8,
+	},
+}
+
+// targetAliases maps alternative names of targets (as used by GOARCH or GCC triples) to the ones in targets.
+var targetAliases = map[string]string{
+	"386":     "i386",
+	"x86":     "i386",
+	"x86_64":  "amd64",
+	"arm32":   "arm",
+	"arm64":   "aarch64",
+	"ppc64be": "ppc64",
+	"mipsbe":  "mips",
+}
+
+// Targets returns names of all ABI presets supported by ConfigForTarget.
+func Targets() []string {
+	out := make([]string, 0, len(targets))
+	for name := range targets {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ConfigForTarget returns a types config for a given target platform.
+// The name is either one of Targets, or a GOARCH/GCC alias for it.
+func ConfigForTarget(name string) (Config, error) {
+	name = strings.ToLower(name)
+	if alias, ok := targetAliases[name]; ok {
+		name = alias
+	}
+	c, ok := targets[name]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown target %q; supported: %s", name, strings.Join(Targets(), ", "))
+	}
+	c.setDefaults()
+	return c, nil
+}
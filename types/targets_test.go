@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigForTarget(t *testing.T) {
+	for _, name := range Targets() {
+		c, err := ConfigForTarget(name)
+		require.NoError(t, err, name)
+		require.NotZero(t, c.PtrSize, name)
+		require.Equal(t, 4, c.IntSize, name)
+		require.NotZero(t, c.WCharSize, name)
+	}
+	c, err := ConfigForTarget("arm64")
+	require.NoError(t, err)
+	require.Equal(t, Config{
+		PtrSize: 8, IntSize: 4, LongSize: 8, Align64: 8,
+		WCharSize: 2, UnsignedChar: true,
+	}, c)
+
+	c, err = ConfigForTarget("PPC64BE")
+	require.NoError(t, err)
+	require.True(t, c.BigEndian)
+
+	_, err = ConfigForTarget("z80")
+	require.Error(t, err)
+}
@@ -240,12 +240,31 @@ func (t namedPtr) Incomplete() bool {
 
 type Field struct {
 	Name *Ident
+	// BitField is set for C bit-fields. Such fields have no Go storage of their own,
+	// and are accessed by masking the storage field instead.
+	BitField *BitField
+	// Hidden is set for fields that only exist in Go to match the C layout, thus cannot be accessed from C.
+	// Bit-field storage and padding are hidden.
+	Hidden bool
 }
 
 func (f *Field) Type() Type {
 	return f.Name.CType(nil)
 }
 
+// BitField describes a C bit-field packed into a storage field of the struct.
+type BitField struct {
+	Storage   *Ident // unsigned integer storage field
+	Offset    int    // in bits, from the least significant bit of the storage
+	Width     int    // in bits
+	BigEndian bool   // byte array storage is loaded in big-endian order
+}
+
+// Mask returns the mask of the bit-field, already shifted to the offset of the field.
+func (b *BitField) Mask() uint64 {
+	return (uint64(1)<<b.Width - 1) << b.Offset
+}
+
 func FuncT(ptrSize int, ret Type, args ...*Field) *FuncType {
 	return funcT(ptrSize, ret, args, false)
 }
@@ -392,6 +411,13 @@ func ArrayT(elem Type, size int) Type {
 	}
 }
 
+// ArrayTLen is similar to ArrayT, but the length of the array is given by a named constant in the Go code.
+func ArrayTLen(elem Type, size int, length *Ident) Type {
+	t := ArrayT(elem, size).(ArrayType)
+	t.length = length
+	return t
+}
+
 func SliceT(elem Type) Type {
 	return ArrayType{
 		elem:  elem,
@@ -401,9 +427,10 @@ func SliceT(elem Type) Type {
 }
 
 type ArrayType struct {
-	elem  Type
-	size  int
-	slice bool
+	elem   Type
+	size   int
+	slice  bool
+	length *Ident // optional constant used as the array length
 }
 
 func (t ArrayType) Kind() Kind {
@@ -493,39 +520,38 @@ var (
 )
 
 func StructT(fields []*Field) *StructType {
-	checkFields(fields)
-	s := &StructType{
-		fields: append([]*Field{}, fields...),
-		union:  false,
-	}
-	h := s.hash()
+	return newStructType(fields, false, 0)
+}
 
-	structMu.RLock()
-	t, ok := structTypes[h]
-	structMu.RUnlock()
-	if ok {
-		return t
-	}
+func UnionT(fields []*Field) *StructType {
+	return newStructType(fields, true, 0)
+}
 
-	structMu.Lock()
-	defer structMu.Unlock()
-	if t, ok := structTypes[h]; ok {
-		return t
-	}
-	structTypes[h] = s
-	return s
+// StructTAligned is the same as StructT, but sets an explicit alignment of the struct, as defined by the target ABI.
+func StructTAligned(fields []*Field, align int) *StructType {
+	return newStructType(fields, false, align)
 }
 
-func UnionT(fields []*Field) *StructType {
+// UnionTAligned is the same as UnionT, but sets an explicit alignment of the union, as defined by the target ABI.
+func UnionTAligned(fields []*Field, align int) *StructType {
+	return newStructType(fields, true, align)
+}
+
+func newStructType(fields []*Field, union bool, align int) *StructType {
 	checkFields(fields)
 	s := &StructType{
 		fields: append([]*Field{}, fields...),
-		union:  true,
+		union:  union,
+		align:  align,
 	}
 	h := s.hash()
+	cache := structTypes
+	if union {
+		cache = unionTypes
+	}
 
 	structMu.RLock()
-	t, ok := unionTypes[h]
+	t, ok := cache[h]
 	structMu.RUnlock()
 	if ok {
 		return t
@@ -533,10 +559,10 @@ func UnionT(fields []*Field) *StructType {
 
 	structMu.Lock()
 	defer structMu.Unlock()
-	if t, ok := unionTypes[h]; ok {
+	if t, ok := cache[h]; ok {
 		return t
 	}
-	unionTypes[h] = s
+	cache[h] = s
 	return s
 }
 
@@ -544,15 +570,30 @@ type StructType struct {
 	Where  string
 	fields []*Field
 	union  bool
+	align  int // alignment defined by the target ABI; zero if unknown
 }
 
 func (t *StructType) hash() string {
 	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%d", t.align)
+	buf.WriteByte(0)
 	for _, f := range t.fields {
 		buf.WriteString(f.Name.Name)
 		buf.WriteByte(0)
+		// the same C field may be renamed differently in Go
+		buf.WriteString(f.Name.GoName)
+		buf.WriteByte(0)
 		fmt.Fprintf(buf, "%p", f.Type())
 		buf.WriteByte(0)
+		// identifiers are shared between equal structs, so they must have the same docs
+		for _, line := range f.Name.Doc {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		if b := f.BitField; b != nil {
+			fmt.Fprintf(buf, "%p:%d:%d:%v", b.Storage, b.Offset, b.Width, b.BigEndian)
+			buf.WriteByte(0)
+		}
 	}
 	return buf.String()
 }
@@ -565,6 +606,20 @@ func (t *StructType) Kind() Kind {
 	return Struct
 }
 
+func (t *StructType) IsUnion() bool {
+	return t.union
+}
+
+// HasBitFields checks if the struct has C bit-fields.
+func (t *StructType) HasBitFields() bool {
+	for _, f := range t.fields {
+		if f.BitField != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *StructType) Sizeof() int {
 	if t.union {
 		max := 0
@@ -573,10 +628,18 @@ func (t *StructType) Sizeof() int {
 				max = sz
 			}
 		}
-		return max
+		if max == 0 {
+			return 1
+		}
+		// union is padded to the alignment of the largest member
+		align := t.Alignof()
+		return (max + align - 1) / align * align
 	}
 	n := 0
 	for _, f := range t.fields {
+		if f.BitField != nil {
+			continue // accounted in the storage field
+		}
 		n += f.Type().Sizeof()
 	}
 	if n == 0 {
@@ -584,3 +647,96 @@ func (t *StructType) Sizeof() int {
 	}
 	return n
 }
+
+// Alignof returns the alignment of the struct or union. If the type was created with an explicit alignment
+// of the target ABI, it is returned as-is. Otherwise, natural alignment of its members is assumed.
+func (t *StructType) Alignof() int {
+	if t.align > 0 {
+		return t.align
+	}
+	max := 1
+	for _, f := range t.fields {
+		if f.BitField != nil {
+			continue
+		}
+		if a := Alignof(f.Type()); a > max {
+			max = a
+		}
+	}
+	return max
+}
+
+// Alignof returns the alignment of the type. Structs and unions may carry the alignment of the target ABI,
+// see StructTAligned. For other types, natural alignment is assumed.
+func Alignof(t Type) int {
+	switch t := t.(type) {
+	case nil:
+		return 1
+	case Named:
+		if t.Underlying() == nil {
+			return 1
+		}
+		return Alignof(t.Underlying())
+	case ArrayType:
+		return Alignof(t.elem)
+	case *StructType:
+		return t.Alignof()
+	}
+	sz := t.Sizeof()
+	switch {
+	case sz >= 8:
+		return 8
+	case sz >= 4:
+		return 4
+	case sz >= 2:
+		return 2
+	}
+	return 1
+}
+
+// PointersOnly checks if all the data of the type consists of pointers. Storage of such types can be
+// reinterpreted as an array of unsafe.Pointer without hiding any pointers from Go GC.
+func PointersOnly(t Type) bool {
+	switch t := t.(type) {
+	case nil:
+		return false
+	case PtrType, *FuncType:
+		return true
+	case Named:
+		return PointersOnly(t.Underlying())
+	case ArrayType:
+		return !t.slice && PointersOnly(t.elem)
+	case *StructType:
+		if len(t.fields) == 0 {
+			return false
+		}
+		for _, f := range t.fields {
+			if f.BitField != nil || !PointersOnly(f.Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// HasPointers checks if the type contains pointers that must be visible to Go GC.
+func HasPointers(t Type) bool {
+	switch t := t.(type) {
+	case nil:
+		return false
+	case PtrType, *FuncType:
+		return true
+	case Named:
+		return HasPointers(t.Underlying())
+	case ArrayType:
+		return t.slice || HasPointers(t.elem)
+	case *StructType:
+		for _, f := range t.fields {
+			if HasPointers(f.Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
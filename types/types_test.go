@@ -61,3 +61,26 @@ func TestCommonType(t *testing.T) {
 		})
 	}
 }
+
+func TestUnionStorage(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields []*Field
+		align  int
+		elem   string
+		n      int
+	}{
+		{"ints", []*Field{{Name: NewUnnamed(IntT(4))}, {Name: NewUnnamed(ArrayT(UintT(1), 3))}}, 4, "uint32", 1},
+		{"over aligned", []*Field{{Name: NewUnnamed(IntT(4))}, {Name: NewUnnamed(ArrayT(UintT(1), 3))}}, 16, "uint64", 2},
+		{"mixed pointers", []*Field{{Name: NewUnnamed(PtrT(8, IntT(4)))}, {Name: NewUnnamed(IntT(8))}}, 8, "unsafe.Pointer", 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u := UnionTAligned(c.fields, c.align)
+			elem, n, pad := u.unionStorage()
+			require.Equal(t, ident(c.elem), elem)
+			require.Equal(t, c.n, n)
+			require.Equal(t, 0, pad)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package cxgo
+
+import "testing"
+
+var casesTranslateUnions = []parseCase{
+	{
+		name: "union fields",
+		src: `
+typedef union {
+	int i;
+	float f;
+	unsigned char b[4];
+} U;
+
+void foo(U* p) {
+	U u;
+	u.f = 1.0f;
+	p->i = u.i;
+	p->b[0]++;
+}
+`,
+		exp: `
+type U struct {
+	data [1]uint32
+}
+
+func foo(p *U) {
+	var u U
+	*(*float32)(unsafe.Pointer(&u)) = 1.0
+	*(*int32)(unsafe.Pointer(p)) = *(*int32)(unsafe.Pointer(&u))
+	(*(*[4]uint8)(unsafe.Pointer(p)))[0]++
+}
+`,
+	},
+	{
+		name: "union size",
+		src: `
+#include <stddef.h>
+
+union U {
+	char c[5];
+	short s;
+};
+
+int foo() {
+	return sizeof(union U);
+}
+`,
+		exp: `
+type U struct {
+	data [3]uint16
+}
+
+func foo() int32 {
+	return int32(unsafe.Sizeof(U{}))
+}
+`,
+	},
+	{
+		name: "union init",
+		src: `
+typedef union {
+	int i;
+	float f;
+} U;
+
+void foo() {
+	U u = {.f = 2.0f};
+}
+`,
+		exp: `
+type U struct {
+	data [1]uint32
+}
+
+func foo() {
+	var u U = func() U {
+		var tmp U
+		*(*float32)(unsafe.Pointer(&tmp)) = 2.0
+		return tmp
+	}()
+	_ = u
+}
+`,
+	},
+	{
+		name: "union with pointers",
+		src: `
+typedef union {
+	int* p;
+	char* s;
+	void (*fn)(void);
+} U;
+`,
+		exp: `
+type U struct {
+	data [1]unsafe.Pointer
+}
+`,
+	},
+	{
+		name: "union abi align",
+		src: `
+typedef union {
+	long long v;
+	double d;
+	int i;
+} U;
+`,
+		exp: `
+type U struct {
+	data [2]uint32
+}
+`,
+	},
+	{
+		name: "union mixed pointers",
+		src: `
+typedef union {
+	int* p;
+	long long v;
+} U;
+`,
+		exp: `
+type U struct {
+	// TODO: pointers share storage with other data; values of other members are visible to GC as pointers
+	data [2]unsafe.Pointer
+}
+`,
+	},
+	{
+		name: "union from call",
+		src: `
+typedef union {
+	int i;
+	float f;
+} U;
+
+U get();
+
+float foo() {
+	return get().f;
+}
+`,
+		exp: `
+type U struct {
+	data [1]uint32
+}
+
+func get() U
+func foo() float32 {
+	return func() float32 {
+		tmp := get()
+		return *(*float32)(unsafe.Pointer(&tmp))
+	}()
+}
+`,
+	},
+	{
+		name: "anonymous union",
+		src: `
+struct S {
+	int kind;
+	union {
+		int i;
+		float f;
+	};
+};
+
+void foo(struct S* s) {
+	struct S s2 = {.kind = 1, .f = 2.0f};
+	s->i = s2.i;
+}
+`,
+		exp: `
+type S struct {
+	Kind  int32
+	Anon0 struct {
+		data [1]uint32
+	}
+}
+
+func foo(s *S) {
+	var s2 S = S{Kind: 1, Anon0: func() struct {
+		data [1]uint32
+	} {
+		var tmp struct {
+			data [1]uint32
+		}
+		*(*float32)(unsafe.Pointer(&tmp)) = 2.0
+		return tmp
+	}()}
+	*(*int32)(unsafe.Pointer(&s.Anon0)) = *(*int32)(unsafe.Pointer(&s2.Anon0))
+}
+`,
+	},
+}
+
+func TestTranslateUnions(t *testing.T) {
+	runTestTranslate(t, casesTranslateUnions)
+}
+
+const runUnionsSrc = `
+#include <stdio.h>
+#include <stdint.h>
+
+typedef union {
+	uint32_t u;
+	float f;
+	uint8_t b[4];
+	struct { uint16_t lo, hi; } h;
+} pun_t;
+
+struct msg {
+	int kind;
+	union {
+		int i;
+		double d;
+	};
+	union {
+		char c[3];
+		short s;
+	} small;
+};
+
+int main() {
+	pun_t p;
+	p.f = 1.0f;
+	printf("%x %d\n", p.u, (int)sizeof(pun_t));
+	p.u = 0x01020304;
+	printf("%d %d\n", p.h.lo, p.h.hi);
+	pun_t* pp = &p;
+	pp->b[0] = 9;
+	printf("%x\n", pp->u);
+
+	struct msg m = {.kind = 2, .i = 7};
+	printf("%d %d\n", m.kind, m.i);
+	m.d = 2.5;
+	printf("%f %d\n", m.d, (int)sizeof(m.small));
+	m.i += 3;
+	m.small.s = 0x4142;
+	printf("%d %d\n", m.small.c[0], m.small.c[1]);
+	return 0;
+}
+`
+
+func TestRunUnions(t *testing.T) {
+	testTranspileOut(t, runUnionsSrc)
+}